@@ -0,0 +1,345 @@
+// Command filetype detects the type of files, hashes them, and validates
+// them against an optional policy.
+//
+// Usage:
+//
+//	filetype [flags] [path ...]
+//
+// The standard input is read when no paths are provided, or when a path
+// is "-". Directories are only accepted with -r. The standard input, the
+// named pipes and the devices are copied to a temporary file, so they go
+// through the same checks as the regular files. Streams bigger than
+// -max-size are rejected with an error instead of being truncated.
+//
+// The exit code is 0 if all the files are valid and respect the policy,
+// 1 if at least one file is invalid or doesn't respect the policy, and 2
+// if a file could not be read or if the arguments are invalid.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/Nivl/go-types/filetype"
+	"github.com/Nivl/go-types/octets"
+)
+
+// List of the exit codes
+const (
+	exitOK       = 0
+	exitRejected = 1
+	exitError    = 2
+)
+
+// stdinPath is the path used to read the standard input
+const stdinPath = "-"
+
+// defaultMaxStreamSize is the default maximum size of the data read
+// from a stream
+const defaultMaxStreamSize = octets.Size(octets.GiB)
+
+// streamFilename is the name of the temporary file used to store the
+// content of a stream
+const streamFilename = "stream"
+
+// errIsDirectory is returned when a directory is provided without -r
+var errIsDirectory = errors.New("is a directory (use -r to walk it)")
+
+// report contains the information gathered about a file
+type report struct {
+	// Path is the path of the file, "-" for the standard input
+	Path string `json:"path"`
+
+	// Size is the size of the file, in bytes
+	Size int64 `json:"size"`
+
+	// MimeType is the detected type of the file
+	MimeType string `json:"mime_type"`
+
+	// SHA256 is the SHA256 sum of the file, encoded in hexadecimal
+	SHA256 string `json:"sha256"`
+
+	// IsImage is true if the file is an image
+	IsImage bool `json:"is_image"`
+
+	// Validation contains the result of the validation of the file.
+	// nil if there are no validators for the type of the file
+	Validation *filetype.ValidationResult `json:"validation,omitempty"`
+
+	// Violations contains the rules of the policy the file doesn't respect
+	Violations []filetype.Violation `json:"violations,omitempty"`
+
+	// Error contains the error that prevented the file from being read
+	Error string `json:"error,omitempty"`
+}
+
+// rejected returns true if the file is invalid or doesn't respect the
+// policy
+func (r *report) rejected() bool {
+	return len(r.Violations) > 0 || (r.Validation != nil && !r.Validation.Valid)
+}
+
+// config contains the parsed command line
+type config struct {
+	recursive bool
+	json      bool
+	workers   int
+	maxSize   octets.Size
+	policy    *filetype.Policy
+	paths     []string
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command and returns its exit code
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	cfg, err := parseArgs(args, stderr)
+	if err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+
+	reports := []*report{}
+	for _, p := range cfg.paths {
+		if p == stdinPath {
+			reports = append(reports, inspectStream(stdin, stdinPath, "", cfg)...)
+			continue
+		}
+		reports = append(reports, inspectPath(p, cfg)...)
+	}
+
+	if cfg.json {
+		err = writeJSON(stdout, reports)
+	} else {
+		err = writeText(stdout, reports)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+	return exitCode(reports)
+}
+
+// parseArgs parses the command line
+func parseArgs(args []string, stderr io.Writer) (*config, error) {
+	cfg := &config{maxSize: defaultMaxStreamSize}
+	var policyPath string
+	flags := flag.NewFlagSet("filetype", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.BoolVar(&cfg.recursive, "r", false, "walk the directories recursively")
+	flags.BoolVar(&cfg.json, "json", false, "print the reports as JSON")
+	flags.IntVar(&cfg.workers, "workers", 0, "number of files processed concurrently (defaults to the number of CPUs)")
+	flags.Var(&cfg.maxSize, "max-size", "maximum `size` of the data read from a stream (0 for no limit)")
+	flags.StringVar(&policyPath, "policy", "", "path to a JSON policy the files must respect")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: filetype [flags] [path ...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if cfg.maxSize < 0 {
+		return nil, fmt.Errorf("invalid value %q for flag -max-size: cannot be negative", cfg.maxSize)
+	}
+
+	cfg.paths = flags.Args()
+	if len(cfg.paths) == 0 {
+		cfg.paths = []string{stdinPath}
+	}
+	if policyPath != "" {
+		policy, err := loadPolicy(policyPath)
+		if err != nil {
+			return nil, fmt.Errorf("could not load the policy: %w", err)
+		}
+		cfg.policy = policy
+	}
+	return cfg, nil
+}
+
+// loadPolicy loads a JSON policy from a file
+func loadPolicy(p string) (policy *filetype.Policy, err error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil && closeErr != nil {
+			err = closeErr
+		}
+	}()
+	return filetype.LoadPolicy(f)
+}
+
+// inspectPath inspects a file, or all the files of a directory if the
+// walk is recursive. The reports are sorted by path
+func inspectPath(p string, cfg *config) []*report {
+	info, err := os.Stat(p)
+	if err != nil {
+		return []*report{{Path: p, Error: err.Error()}}
+	}
+
+	switch {
+	case info.IsDir():
+		if !cfg.recursive {
+			return []*report{{Path: p, Error: errIsDirectory.Error()}}
+		}
+		// WalkFS works on slash separated paths relative to the root of
+		// the file system
+		reports := []*report{}
+		for _, entry := range walkFiles(os.DirFS(p), ".", cfg) {
+			rep := newReport(entry, path.Base(entry.Path), cfg.policy)
+			rep.Path = filepath.Join(p, filepath.FromSlash(entry.Path))
+			reports = append(reports, rep)
+		}
+		sort.Slice(reports, func(i, j int) bool { return reports[i].Path < reports[j].Path })
+		return reports
+	case info.Mode().IsRegular():
+		return newReports(walkFiles(os.DirFS(filepath.Dir(p)), filepath.Base(p), cfg), p, filepath.Base(p), cfg.policy)
+	}
+
+	// Named pipes, devices, process substitutions (<(cmd)), etc. are not
+	// walked by WalkFS, so they are read as streams
+	f, err := os.Open(p)
+	if err != nil {
+		return []*report{{Path: p, Error: err.Error()}}
+	}
+	reports := inspectStream(f, p, filepath.Base(p), cfg)
+	if err = f.Close(); err != nil {
+		return []*report{{Path: p, Error: err.Error()}}
+	}
+	return reports
+}
+
+// inspectStream inspects a stream, like the standard input.
+// The stream is copied to a temporary file so it goes through the same
+// checks as the regular files. name is the name of the file used by the
+// policy to check the extension, empty if the stream has no name.
+// Streams bigger than cfg.maxSize are reported as errors
+func inspectStream(r io.Reader, p, name string, cfg *config) (reports []*report) {
+	dir, err := ioutil.TempDir("", "filetype")
+	if err != nil {
+		return []*report{{Path: p, Error: err.Error()}}
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			reports = []*report{{Path: p, Error: err.Error()}}
+		}
+	}()
+
+	if cfg.maxSize > 0 {
+		r = octets.LimitReader(r, cfg.maxSize.Bytes())
+	}
+	if err = copyToFile(filepath.Join(dir, streamFilename), r); err != nil {
+		return []*report{{Path: p, Error: err.Error()}}
+	}
+	return newReports(walkFiles(os.DirFS(dir), streamFilename, cfg), p, name, cfg.policy)
+}
+
+// copyToFile copies the content of a reader into a new file
+func copyToFile(p string, r io.Reader) (err error) {
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil && closeErr != nil {
+			err = closeErr
+		}
+	}()
+	_, err = io.Copy(f, r)
+	return err
+}
+
+// walkFiles sniffs, hashes and validates the files of fsys, starting at
+// root
+func walkFiles(fsys fs.FS, root string, cfg *config) []*filetype.FSEntry {
+	opts := &filetype.FSWalkOptions{Workers: cfg.workers}
+	if cfg.policy != nil {
+		opts.Limits = cfg.policy.Image
+	}
+	entries := []*filetype.FSEntry{}
+	// fn never fails, so WalkFS cannot fail
+	_ = filetype.WalkFSContext(context.Background(), fsys, root, opts, func(entry *filetype.FSEntry) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	return entries
+}
+
+// newReports creates the reports of the entries of a single file, using
+// p as path
+func newReports(entries []*filetype.FSEntry, p, name string, policy *filetype.Policy) []*report {
+	reports := make([]*report, 0, len(entries))
+	for _, entry := range entries {
+		rep := newReport(entry, name, policy)
+		rep.Path = p
+		reports = append(reports, rep)
+	}
+	return reports
+}
+
+// newReport creates a report from an entry of WalkFS(), and evaluates it
+// against the policy, if any. name is the name of the file used to check
+// its extension, empty if the file has no name
+func newReport(entry *filetype.FSEntry, name string, policy *filetype.Policy) *report {
+	rep := &report{
+		Size:       entry.Size,
+		MimeType:   entry.MimeType,
+		SHA256:     entry.SHA256,
+		Validation: entry.Validation,
+	}
+	if entry.Err != nil {
+		rep.Error = entry.Err.Error()
+		return rep
+	}
+	_, rep.IsImage = filetype.LookupImageFormat(rep.MimeType)
+
+	if policy != nil {
+		if name == "" {
+			// There is no extension to check
+			p := *policy
+			p.CheckExtension = false
+			policy = &p
+		}
+		fileReport := &filetype.FileReport{
+			Size:     entry.Size,
+			MimeType: entry.MimeType,
+		}
+		// The policy only knows how to evaluate the validation of images.
+		// The other files are rejected using report.rejected()
+		if rep.IsImage {
+			fileReport.Validation = entry.Validation
+		}
+		rep.Violations = policy.EvaluateReport(fileReport, name)
+	}
+	return rep
+}
+
+// exitCode returns the exit code matching a list of reports
+func exitCode(reports []*report) int {
+	code := exitOK
+	for _, rep := range reports {
+		if rep.Error != "" {
+			return exitError
+		}
+		if rep.rejected() {
+			code = exitRejected
+		}
+	}
+	return code
+}
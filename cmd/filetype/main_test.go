@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Nivl/go-types/octets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFiles creates the provided files in a temporary directory and
+// returns its path
+func writeFiles(t *testing.T, files map[string][]byte) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755), "MkdirAll() should have succeed")
+		require.NoError(t, ioutil.WriteFile(p, content, 0o600), "WriteFile() should have succeed")
+	}
+	return dir
+}
+
+// runJSON runs the command with -json and returns the exit code and the
+// decoded reports
+func runJSON(t *testing.T, stdin []byte, args ...string) (int, []*report) {
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	code := run(append([]string{"-json"}, args...), bytes.NewReader(stdin), stdout, stderr)
+	require.Empty(t, stderr.String(), "nothing should have been printed on stderr")
+
+	reports := []*report{}
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &reports), "the output should be valid json")
+	return code, reports
+}
+
+func TestRun(t *testing.T) {
+	png, err := ioutil.ReadFile(filepath.Join("..", "..", "filetype", "fixtures", "black_pixel.png"))
+	require.NoError(t, err, "ReadFile() should have succeed")
+	pdf, err := ioutil.ReadFile(filepath.Join("..", "..", "filetype", "fixtures", "black_pixel.pdf"))
+	require.NoError(t, err, "ReadFile() should have succeed")
+	truncatedPDF := pdf[:len(pdf)-30]
+
+	dir := writeFiles(t, map[string][]byte{
+		"images/pixel.png":     png,
+		"images/truncated.png": png[:len(png)-10],
+		"notes.txt":            []byte("hello world"),
+		"truncated.pdf":        truncatedPDF,
+		"script.png.html":      []byte("<html><script>alert(1)</script></html>"),
+	})
+	policyPath := filepath.Join(dir, "policy.json")
+	policy := `{"allowed_types": ["image/*", "text/plain"], "check_extension": true}`
+	require.NoError(t, ioutil.WriteFile(policyPath, []byte(policy), 0o600), "WriteFile() should have succeed")
+
+	t.Run("single valid file", func(t *testing.T) {
+		t.Parallel()
+
+		p := filepath.Join(dir, "images", "pixel.png")
+		code, reports := runJSON(t, nil, p)
+		assert.Equal(t, exitOK, code)
+		require.Len(t, reports, 1)
+		assert.Equal(t, p, reports[0].Path)
+		assert.Equal(t, "image/png", reports[0].MimeType)
+		assert.Equal(t, int64(len(png)), reports[0].Size)
+		assert.True(t, reports[0].IsImage)
+		require.NotNil(t, reports[0].Validation)
+		assert.True(t, reports[0].Validation.Valid)
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		t.Parallel()
+
+		code, reports := runJSON(t, nil, filepath.Join(dir, "images", "truncated.png"))
+		assert.Equal(t, exitRejected, code)
+		require.Len(t, reports, 1)
+		assert.False(t, reports[0].Validation.Valid)
+	})
+
+	t.Run("recursive walk with a policy", func(t *testing.T) {
+		t.Parallel()
+
+		code, reports := runJSON(t, nil, "-r", "-policy", policyPath, filepath.Join(dir, "images"), filepath.Join(dir, "script.png.html"))
+		assert.Equal(t, exitRejected, code)
+		require.Len(t, reports, 3)
+		assert.Equal(t, filepath.Join(dir, "images", "pixel.png"), reports[0].Path)
+		assert.Empty(t, reports[0].Violations)
+		assert.Equal(t, filepath.Join(dir, "images", "truncated.png"), reports[1].Path)
+		assert.NotEmpty(t, reports[1].Violations)
+		assert.Equal(t, filepath.Join(dir, "script.png.html"), reports[2].Path)
+		assert.Len(t, reports[2].Violations, 2, "the type and the double extension should have been reported")
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		t.Parallel()
+
+		code, reports := runJSON(t, []byte("hello world"), "-policy", policyPath)
+		assert.Equal(t, exitOK, code)
+		require.Len(t, reports, 1)
+		assert.Equal(t, "-", reports[0].Path)
+		assert.Equal(t, "text/plain; charset=utf-8", reports[0].MimeType)
+		assert.Empty(t, reports[0].Violations, "the extension of stdin should not be checked")
+	})
+
+	t.Run("stdin validated like a file", func(t *testing.T) {
+		t.Parallel()
+
+		code, stdinReports := runJSON(t, truncatedPDF)
+		assert.Equal(t, exitRejected, code)
+		require.Len(t, stdinReports, 1)
+		require.NotNil(t, stdinReports[0].Validation, "the document should have been validated")
+		assert.False(t, stdinReports[0].Validation.Valid)
+
+		_, fileReports := runJSON(t, nil, filepath.Join(dir, "truncated.pdf"))
+		require.Len(t, fileReports, 1)
+		assert.Equal(t, fileReports[0].Validation, stdinReports[0].Validation, "stdin and files should have the same checks")
+		assert.Equal(t, fileReports[0].SHA256, stdinReports[0].SHA256)
+	})
+
+	t.Run("stdin bigger than -max-size", func(t *testing.T) {
+		t.Parallel()
+
+		code, reports := runJSON(t, []byte("hello world"), "-max-size", "10B")
+		assert.Equal(t, exitError, code)
+		require.Len(t, reports, 1)
+		assert.Equal(t, "-", reports[0].Path)
+		assert.True(t, strings.Contains(reports[0].Error, octets.ErrMsgSizeExceeded), "the size should have been exceeded: %s", reports[0].Error)
+		assert.Empty(t, reports[0].SHA256, "the stream should not have been inspected")
+	})
+
+	t.Run("stdin within -max-size", func(t *testing.T) {
+		t.Parallel()
+
+		code, reports := runJSON(t, []byte("hello world"), "-max-size", "11B")
+		assert.Equal(t, exitOK, code)
+		require.Len(t, reports, 1)
+		assert.Empty(t, reports[0].Error)
+		assert.Equal(t, int64(11), reports[0].Size)
+	})
+
+	t.Run("device", func(t *testing.T) {
+		t.Parallel()
+
+		info, err := os.Stat(os.DevNull)
+		if err != nil || info.Mode().IsRegular() {
+			t.Skip("no device available")
+		}
+		code, reports := runJSON(t, nil, os.DevNull)
+		assert.Equal(t, exitOK, code)
+		require.Len(t, reports, 1, "the device should have been read")
+		assert.Equal(t, os.DevNull, reports[0].Path)
+		assert.Empty(t, reports[0].Error)
+		assert.Equal(t, int64(0), reports[0].Size)
+	})
+
+	t.Run("directory without -r", func(t *testing.T) {
+		t.Parallel()
+
+		code, reports := runJSON(t, nil, dir)
+		assert.Equal(t, exitError, code)
+		require.Len(t, reports, 1)
+		assert.Equal(t, errIsDirectory.Error(), reports[0].Error)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		t.Parallel()
+
+		code, reports := runJSON(t, nil, filepath.Join(dir, "missing"))
+		assert.Equal(t, exitError, code)
+		require.Len(t, reports, 1)
+		assert.NotEmpty(t, reports[0].Error)
+	})
+
+	t.Run("text output", func(t *testing.T) {
+		t.Parallel()
+
+		stdout := &bytes.Buffer{}
+		code := run([]string{filepath.Join(dir, "notes.txt")}, nil, stdout, &bytes.Buffer{})
+		assert.Equal(t, exitOK, code)
+		out := stdout.String()
+		assert.True(t, strings.Contains(out, "text/plain; charset=utf-8"), "the type should have been printed:\n%s", out)
+		assert.True(t, strings.Contains(out, "status:  OK"), "the status should have been printed:\n%s", out)
+	})
+}
+
+func TestRunInvalidArguments(t *testing.T) {
+	testCases := []struct {
+		description string
+		args        []string
+	}{
+		{"unknown flag", []string{"-unknown"}},
+		{"missing policy", []string{"-policy", "missing.json"}},
+		{"invalid max size", []string{"-max-size", "abc"}},
+		{"negative max size", []string{"-max-size", "-1KiB"}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			stderr := &bytes.Buffer{}
+			code := run(tc.args, nil, &bytes.Buffer{}, stderr)
+			assert.Equal(t, exitError, code)
+			assert.NotEmpty(t, stderr.String(), "the error should have been printed")
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// writeJSON prints the reports as a JSON array
+func writeJSON(w io.Writer, reports []*report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(reports)
+}
+
+// writeText prints the reports in a human readable format
+func writeText(w io.Writer, reports []*report) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, rep := range reports {
+		if i > 0 {
+			fmt.Fprintln(tw)
+		}
+		fmt.Fprintln(tw, rep.Path)
+		if rep.Error != "" {
+			fmt.Fprintf(tw, "  error:\t%s\n", rep.Error)
+			continue
+		}
+		fmt.Fprintf(tw, "  type:\t%s\n", orNone(rep.MimeType))
+		fmt.Fprintf(tw, "  size:\t%d bytes\n", rep.Size)
+		fmt.Fprintf(tw, "  sha256:\t%s\n", rep.SHA256)
+		fmt.Fprintf(tw, "  image:\t%s\n", yesNo(rep.IsImage))
+		fmt.Fprintf(tw, "  valid:\t%s\n", validity(rep))
+		for _, v := range rep.Violations {
+			fmt.Fprintf(tw, "  violation:\t%s (%s)\n", v.Message, v.Code)
+		}
+		if rep.rejected() {
+			fmt.Fprintln(tw, "  status:\tREJECTED")
+		} else {
+			fmt.Fprintln(tw, "  status:\tOK")
+		}
+	}
+	return tw.Flush()
+}
+
+// validity returns a human readable version of the validation of a file
+func validity(rep *report) string {
+	switch {
+	case rep.Validation == nil:
+		return "not validated"
+	case rep.Validation.Valid:
+		return "yes"
+	}
+
+	details := []string{string(rep.Validation.Reason)}
+	if rep.Validation.Offset >= 0 {
+		details = append(details, fmt.Sprintf("at offset %d", rep.Validation.Offset))
+	}
+	return fmt.Sprintf("no (%s)", strings.Join(details, " "))
+}
+
+// yesNo returns "yes" if b is true, "no" otherwise
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// orNone returns "none" if s is empty
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
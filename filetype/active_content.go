@@ -0,0 +1,455 @@
+package filetype
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// ErrMsgUnsupportedActiveContentFormat represents the error message
+// returned when trying to scan a file that cannot contain active content,
+// or that we don't support
+var ErrMsgUnsupportedActiveContentFormat = "unsupported format for active content scanning"
+
+// ErrMsgInvalidActiveContentFile represents the error message returned
+// when trying to scan an HTML document or a PDF file that is malformed
+var ErrMsgInvalidActiveContentFile = "invalid file"
+
+// maxActiveContentValueLength is the maximum length of the value stored in
+// an ActiveContent
+const maxActiveContentValueLength = 64
+
+// ActiveContentKind represents a type of active content
+type ActiveContentKind string
+
+// List of all the active content we detect
+const (
+	// ActiveContentScript means the file contains a script (<script>
+	// element, PDF JavaScript action, etc.)
+	ActiveContentScript ActiveContentKind = "script"
+
+	// ActiveContentEventHandler means an element has an event handler
+	// attribute (onload, onclick, etc.)
+	ActiveContentEventHandler ActiveContentKind = "event_handler"
+
+	// ActiveContentJavaScriptURL means an attribute or a style contains a
+	// URL that runs a script (javascript:, vbscript:, data:text/html, etc.)
+	ActiveContentJavaScriptURL ActiveContentKind = "javascript_url"
+
+	// ActiveContentExternalReference means the file loads a resource that
+	// is not embedded in the file (image, stylesheet, remote PDF, etc.)
+	ActiveContentExternalReference ActiveContentKind = "external_reference"
+
+	// ActiveContentEmbeddedContent means the file embeds content that can
+	// have its own active content (<foreignObject>, <iframe>, PDF
+	// attachments, etc.)
+	ActiveContentEmbeddedContent ActiveContentKind = "embedded_content"
+
+	// ActiveContentLaunchAction means a PDF file can start an application
+	ActiveContentLaunchAction ActiveContentKind = "launch_action"
+
+	// ActiveContentAutoAction means a PDF file runs an action when it's
+	// opened or when the user interacts with it
+	ActiveContentAutoAction ActiveContentKind = "auto_action"
+)
+
+// ActiveContent represents active content found in a file
+type ActiveContent struct {
+	// Kind is the type of the active content
+	Kind ActiveContentKind `json:"kind"`
+
+	// Element is the element (SVG and HTML) or the name (PDF) containing
+	// the active content
+	Element string `json:"element"`
+
+	// Attribute is the attribute containing the active content. Empty if
+	// the active content is the element itself
+	Attribute string `json:"attribute,omitempty"`
+
+	// Value is the beginning of the active content
+	Value string `json:"value,omitempty"`
+}
+
+// activeContentScanner represents a function that looks for the active
+// content of a file
+type activeContentScanner func(s *section) ([]ActiveContent, error)
+
+// activeContentScanners contains the scanners of all the supported
+// formats, indexed by the mimetype returned by MimeType()
+var activeContentScanners = map[string]activeContentScanner{
+	"image/svg+xml":   scanSVG,
+	"text/html":       scanHTML,
+	"application/pdf": scanPDF,
+}
+
+// scriptElements contains the elements (in lower case) that run scripts
+var scriptElements = map[string]bool{
+	"script": true,
+}
+
+// embeddedElements contains the elements (in lower case) that embed
+// content that can have its own active content
+var embeddedElements = map[string]bool{
+	"foreignobject": true,
+	"iframe":        true,
+	"frame":         true,
+	"object":        true,
+	"embed":         true,
+	"applet":        true,
+}
+
+// urlAttributes contains the attributes (in lower case) that make the
+// browser load a resource
+var urlAttributes = map[string]bool{
+	"href":       true,
+	"src":        true,
+	"srcset":     true,
+	"data":       true,
+	"poster":     true,
+	"background": true,
+}
+
+// linkElements contains the elements (in lower case) that have an href
+// attribute that is only followed when the user clicks on it
+var linkElements = map[string]bool{
+	"a":    true,
+	"area": true,
+}
+
+// cssURLRegexp matches the url() of a stylesheet
+var cssURLRegexp = regexp.MustCompile(`(?i)url\(\s*['"]?([^'")]*)`)
+
+var (
+	htmlCommentRegexp   = regexp.MustCompile(`(?s)<!--.*?-->`)
+	htmlTagRegexp       = regexp.MustCompile(`<([a-zA-Z][a-zA-Z0-9:-]*)((?:\s+[^\s/>"'=]+(?:\s*=\s*(?:"[^"]*"|'[^']*'|[^\s"'>]+))?)*)\s*/?>`)
+	htmlAttributeRegexp = regexp.MustCompile(`([^\s/>"'=]+)(?:\s*=\s*("[^"]*"|'[^']*'|[^\s"'>]+))?`)
+	htmlStyleRegexp     = regexp.MustCompile(`(?is)<style[^>]*>(.*?)</style\s*>`)
+)
+
+// ScanActiveContent looks for active content in an SVG image, an HTML
+// document or a PDF file: scripts, event handlers, javascript: URLs,
+// external references, PDF actions, etc.
+// An empty list is returned if the file doesn't contain active content.
+// The reader will be put back to its original position.
+func ScanActiveContent(r io.ReadSeeker) ([]ActiveContent, error) {
+	mimeType, err := MimeType(r)
+	if err != nil {
+		return nil, err
+	}
+	scan, found := activeContentScanners[baseMediaType(mimeType)]
+	if !found {
+		return nil, errors.New(ErrMsgUnsupportedActiveContentFormat)
+	}
+
+	var findings []ActiveContent
+	res, err := checkStructure(r, func(s *section) (err error) {
+		findings, err = scan(s)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !res.Valid {
+		if strings.HasPrefix(mimeType, "image/") {
+			return nil, errors.New(ErrMsgInvalidImage)
+		}
+		return nil, errors.New(ErrMsgInvalidActiveContentFile)
+	}
+	return findings, nil
+}
+
+// SanitizeSVG writes a copy of an SVG image without its active content:
+// <script> and <foreignObject> elements, event handlers, javascript: URLs,
+// and references to external resources are removed. The rest of the
+// document is copied as is.
+// The active content that got removed is returned.
+// The reader will be put back to its original position.
+func SanitizeSVG(r io.ReadSeeker, w io.Writer) ([]ActiveContent, error) {
+	var findings []ActiveContent
+	sanitized := &bytes.Buffer{}
+	res, err := checkStructure(r, func(s *section) error {
+		data, err := s.read(0, s.size)
+		if err != nil {
+			return err
+		}
+		findings, err = processSVG(data, sanitized)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !res.Valid {
+		return nil, errors.New(ErrMsgInvalidImage)
+	}
+	if _, err = w.Write(sanitized.Bytes()); err != nil {
+		return nil, err
+	}
+	return findings, nil
+}
+
+// scanSVG looks for the active content of an SVG image
+func scanSVG(s *section) ([]ActiveContent, error) {
+	data, err := s.read(0, s.size)
+	if err != nil {
+		return nil, err
+	}
+	return processSVG(data, nil)
+}
+
+// processSVG looks for the active content of an SVG image, and writes
+// a copy of the image without it in sanitized, if not nil
+func processSVG(data []byte, sanitized *bytes.Buffer) ([]ActiveContent, error) {
+	// walkSVG makes sure the document is safe to parse
+	if err := walkSVG(bytes.NewReader(data), nil); err != nil {
+		return nil, errMalformed
+	}
+
+	// We use the raw tokens to be able to copy the original content of
+	// the file, instead of re-encoding it
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = true
+	d.Entity = map[string]string{}
+
+	findings := []ActiveContent{}
+	var offset int64
+	// elements contains the local name (in lower case) of the elements
+	// we are currently in
+	elements := []string{}
+	// removedDepth is the depth of the element being removed, 0 if we are
+	// not in a removed element
+	removedDepth := 0
+	for {
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errMalformed
+		}
+		raw := data[offset:d.InputOffset()]
+		offset = d.InputOffset()
+		keep := removedDepth == 0
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			name := strings.ToLower(t.Name.Local)
+			elements = append(elements, name)
+			if kind, found := checkElement(name); found {
+				findings = append(findings, newActiveContent(kind, t.Name.Local, "", ""))
+				if removedDepth == 0 {
+					removedDepth = len(elements)
+				}
+				keep = false
+			}
+
+			attrs := make([]xml.Attr, 0, len(t.Attr))
+			for _, attr := range t.Attr {
+				kind, found := checkAttribute(name, strings.ToLower(attr.Name.Local), attr.Value)
+				if !found {
+					attrs = append(attrs, attr)
+					continue
+				}
+				findings = append(findings, newActiveContent(kind, t.Name.Local, xmlQualifiedName(attr.Name), attr.Value))
+			}
+			if keep && len(attrs) != len(t.Attr) {
+				keep = false
+				if sanitized != nil {
+					writeXMLStartElement(sanitized, t.Name, attrs, bytes.HasSuffix(raw, []byte("/>")))
+				}
+			}
+		case xml.EndElement:
+			if removedDepth == len(elements) {
+				removedDepth = 0
+				keep = false
+			}
+			if len(elements) > 0 {
+				elements = elements[:len(elements)-1]
+			}
+		case xml.CharData:
+			if len(elements) == 0 || elements[len(elements)-1] != "style" {
+				break
+			}
+			if kind, found := checkCSS(string(t)); found {
+				findings = append(findings, newActiveContent(kind, "style", "", string(t)))
+				keep = false
+			}
+		case xml.ProcInst:
+			// <?xml-stylesheet href="..."?>
+			if t.Target == "xml-stylesheet" {
+				findings = append(findings, newActiveContent(ActiveContentExternalReference, t.Target, "", string(t.Inst)))
+				keep = false
+			}
+		}
+
+		if keep && sanitized != nil {
+			sanitized.Write(raw)
+		}
+	}
+	return findings, nil
+}
+
+// scanHTML looks for the active content of an HTML document.
+// HTML documents are not parsed, the tags are matched as text
+func scanHTML(s *section) ([]ActiveContent, error) {
+	data, err := s.read(0, s.size)
+	if err != nil {
+		return nil, err
+	}
+	// HTML documents are text files, a NUL byte means the file is binary
+	if bytes.IndexByte(data, 0) != -1 {
+		return nil, errMalformed
+	}
+	data = htmlCommentRegexp.ReplaceAll(data, nil)
+
+	findings := []ActiveContent{}
+	for _, tag := range htmlTagRegexp.FindAllSubmatch(data, -1) {
+		element := string(tag[1])
+		name := strings.ToLower(element)
+		if kind, found := checkElement(name); found {
+			findings = append(findings, newActiveContent(kind, element, "", ""))
+		}
+		for _, attr := range htmlAttributeRegexp.FindAllSubmatch(tag[2], -1) {
+			value := string(attr[2])
+			if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') {
+				value = value[1 : len(value)-1]
+			}
+			value = html.UnescapeString(value)
+			if kind, found := checkAttribute(name, strings.ToLower(string(attr[1])), value); found {
+				findings = append(findings, newActiveContent(kind, element, string(attr[1]), value))
+			}
+		}
+	}
+	for _, style := range htmlStyleRegexp.FindAllSubmatch(data, -1) {
+		if kind, found := checkCSS(string(style[1])); found {
+			findings = append(findings, newActiveContent(kind, "style", "", string(style[1])))
+		}
+	}
+	return findings, nil
+}
+
+// checkElement checks if an element (in lower case) is active content
+func checkElement(name string) (ActiveContentKind, bool) {
+	switch {
+	case scriptElements[name]:
+		return ActiveContentScript, true
+	case embeddedElements[name]:
+		return ActiveContentEmbeddedContent, true
+	}
+	return "", false
+}
+
+// checkAttribute checks if an attribute of an element contains active
+// content. The element and attribute names must be in lower case
+func checkAttribute(element, name, value string) (ActiveContentKind, bool) {
+	switch {
+	case strings.HasPrefix(name, "on"):
+		return ActiveContentEventHandler, true
+	case isScriptURL(value):
+		// javascript: URLs can be in any attribute, like in the "to" and
+		// "values" attributes of the SVG animations
+		return ActiveContentJavaScriptURL, true
+	case name == "style":
+		return checkCSS(value)
+	case urlAttributes[name]:
+		if name == "href" && linkElements[element] {
+			return "", false
+		}
+		if name == "srcset" {
+			for _, candidate := range strings.Split(value, ",") {
+				if fields := strings.Fields(candidate); len(fields) > 0 && isExternalURL(fields[0]) {
+					return ActiveContentExternalReference, true
+				}
+			}
+			return "", false
+		}
+		if isExternalURL(value) {
+			return ActiveContentExternalReference, true
+		}
+	case strings.Contains(strings.ToLower(value), "url("):
+		// presentation attributes like fill="url(#gradient)"
+		return checkCSS(value)
+	}
+	return "", false
+}
+
+// checkCSS checks if a stylesheet contains active content
+func checkCSS(css string) (ActiveContentKind, bool) {
+	lower := strings.ToLower(css)
+	if strings.Contains(lower, "expression(") || isScriptURL(lower) {
+		return ActiveContentJavaScriptURL, true
+	}
+	if strings.Contains(lower, "@import") {
+		return ActiveContentExternalReference, true
+	}
+	for _, url := range cssURLRegexp.FindAllStringSubmatch(css, -1) {
+		if isExternalURL(url[1]) {
+			return ActiveContentExternalReference, true
+		}
+	}
+	return "", false
+}
+
+// isScriptURL checks if a value contains a URL that runs a script
+func isScriptURL(value string) bool {
+	// Browsers ignore the whitespaces and control characters in the
+	// scheme, "java\tscript:" is valid
+	value = strings.ToLower(strings.Map(func(r rune) rune {
+		if r <= ' ' {
+			return -1
+		}
+		return r
+	}, value))
+	if strings.Contains(value, "javascript:") || strings.Contains(value, "vbscript:") {
+		return true
+	}
+	return strings.HasPrefix(value, "data:") &&
+		(strings.Contains(value, "html") || strings.Contains(value, "script"))
+}
+
+// isExternalURL checks if a URL references a resource that is not
+// embedded in the file. Fragments (#id) and data: URLs are embedded
+func isExternalURL(url string) bool {
+	url = strings.TrimSpace(url)
+	return url != "" && !strings.HasPrefix(url, "#") &&
+		!strings.HasPrefix(strings.ToLower(url), "data:")
+}
+
+// newActiveContent returns a new ActiveContent, with its value truncated
+func newActiveContent(kind ActiveContentKind, element, attribute, value string) ActiveContent {
+	value = strings.TrimSpace(value)
+	if len(value) > maxActiveContentValueLength {
+		value = value[:maxActiveContentValueLength]
+	}
+	return ActiveContent{Kind: kind, Element: element, Attribute: attribute, Value: value}
+}
+
+// xmlQualifiedName returns the name of an element or attribute returned
+// by xml.Decoder.RawToken(), with its prefix
+func xmlQualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// writeXMLStartElement writes a start element returned by
+// xml.Decoder.RawToken()
+func writeXMLStartElement(buf *bytes.Buffer, name xml.Name, attrs []xml.Attr, selfClosing bool) {
+	buf.WriteString("<" + xmlQualifiedName(name))
+	for _, attr := range attrs {
+		buf.WriteString(" " + xmlQualifiedName(attr.Name) + `="`)
+		// EscapeText only fails if the writer fails, which never happens
+		// with a bytes.Buffer
+		_ = xml.EscapeText(buf, []byte(attr.Value))
+		buf.WriteString(`"`)
+	}
+	if selfClosing {
+		buf.WriteString("/>")
+		return
+	}
+	buf.WriteString(">")
+}
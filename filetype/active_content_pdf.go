@@ -0,0 +1,281 @@
+package filetype
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// pdfMaxObjectStreamSize is the maximum size of a compressed object
+// stream we decompress to look for active content
+const pdfMaxObjectStreamSize = 16 << 20
+
+// pdfMaxInflatedSize is the maximum amount of data we decompress from
+// a PDF file, to protect against decompression bombs
+const pdfMaxInflatedSize = 64 << 20
+
+// pdfHeaderSize is the size of the header of a PDF file: %PDF-x.y
+const pdfHeaderSize = 8
+
+// pdfActiveNames contains the PDF names that indicate active content
+var pdfActiveNames = map[string]ActiveContentKind{
+	"JavaScript":   ActiveContentScript,
+	"JS":           ActiveContentScript,
+	"Launch":       ActiveContentLaunchAction,
+	"OpenAction":   ActiveContentAutoAction,
+	"AA":           ActiveContentAutoAction,
+	"GoToR":        ActiveContentExternalReference,
+	"GoToE":        ActiveContentExternalReference,
+	"SubmitForm":   ActiveContentExternalReference,
+	"ImportData":   ActiveContentExternalReference,
+	"EmbeddedFile": ActiveContentEmbeddedContent,
+	"RichMedia":    ActiveContentEmbeddedContent,
+}
+
+// pdfScanner looks for active content in the objects of a PDF file.
+// The file is tokenized instead of being parsed, which allows us to
+// scan broken files
+type pdfScanner struct {
+	findings []ActiveContent
+	// found contains the names that have already been reported
+	found map[string]bool
+	// inflated is the amount of data decompressed so far
+	inflated int64
+}
+
+// scanPDF looks for the active content of a PDF file.
+// Each active name is only reported once.
+// The names contained in compressed object streams are also reported.
+// The file has to start with a valid header, but the rest of the file
+// doesn't have to be valid
+func scanPDF(s *section) ([]ActiveContent, error) {
+	head, err := s.read(0, minInt64(pdfHeaderSize, s.size))
+	if err != nil {
+		return nil, err
+	}
+	if !pdfVersionRegexp.Match(head) {
+		return nil, errMalformed
+	}
+	r, err := s.reader()
+	if err != nil {
+		return nil, err
+	}
+	p := &pdfScanner{findings: []ActiveContent{}, found: map[string]bool{}}
+	if err := p.scan(bufio.NewReader(r), false); err != nil {
+		return nil, err
+	}
+	return p.findings, nil
+}
+
+// scan tokenizes the provided content and reports the active names.
+// The object streams are decompressed and scanned, unless nested is true
+func (p *pdfScanner) scan(r *bufio.Reader, nested bool) error {
+	// flate and objStm are set if the current object is a compressed
+	// object stream
+	flate, objStm := false, false
+	for {
+		c, err := r.ReadByte()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case c == '%':
+			if err := pdfSkipComment(r); err != nil {
+				return pdfEOF(err)
+			}
+		case c == '(':
+			if err := pdfSkipString(r); err != nil {
+				return pdfEOF(err)
+			}
+		case c == '/':
+			name, err := pdfReadToken(r)
+			if err != nil && err != io.EOF {
+				return err
+			}
+			name = pdfDecodeName(name)
+			switch name {
+			case "FlateDecode", "Fl":
+				flate = true
+			case "ObjStm":
+				objStm = true
+			}
+			p.report(name)
+		case !pdfIsDelimiter(c):
+			if err := r.UnreadByte(); err != nil {
+				return err
+			}
+			keyword, err := pdfReadToken(r)
+			if err != nil && err != io.EOF {
+				return err
+			}
+			switch keyword {
+			case "obj":
+				flate, objStm = false, false
+			case "stream":
+				if err := p.scanStream(r, flate && objStm && !nested); err != nil {
+					return pdfEOF(err)
+				}
+				flate, objStm = false, false
+			}
+		}
+	}
+}
+
+// scanStream skips the data of a stream, and scans it if it needs to be
+// decompressed
+func (p *pdfScanner) scanStream(r *bufio.Reader, decompress bool) error {
+	// The data start after the end of line following the keyword
+	for _, eol := range []byte("\r\n") {
+		c, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if c != eol {
+			if err := r.UnreadByte(); err != nil {
+				return err
+			}
+		}
+	}
+
+	end := []byte("endstream")
+	data := []byte{}
+	// tail contains the last bytes read, to find the end of the stream
+	tail := make([]byte, 0, len(end))
+	for !bytes.Equal(tail, end) {
+		c, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if len(tail) == len(end) {
+			tail = append(tail[:0], tail[1:]...)
+		}
+		tail = append(tail, c)
+		if decompress && len(data) < pdfMaxObjectStreamSize+len(end) {
+			data = append(data, c)
+		}
+	}
+	if !decompress || len(data) > pdfMaxObjectStreamSize {
+		return nil
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(data[:len(data)-len(end)]))
+	if err != nil {
+		// The stream is invalid, there's nothing to scan
+		return nil
+	}
+	// A truncated or corrupted stream is scanned as much as possible
+	content, err := ioutil.ReadAll(io.LimitReader(zr, pdfMaxInflatedSize-p.inflated))
+	if err != nil && len(content) == 0 {
+		return nil
+	}
+	p.inflated += int64(len(content))
+	return p.scan(bufio.NewReader(bytes.NewReader(content)), true)
+}
+
+// report adds a name to the findings if it indicates active content
+func (p *pdfScanner) report(name string) {
+	kind, ok := pdfActiveNames[name]
+	if !ok || p.found[name] {
+		return
+	}
+	p.found[name] = true
+	p.findings = append(p.findings, ActiveContent{Kind: kind, Element: "/" + name})
+}
+
+// pdfReadToken reads the regular characters until the next delimiter
+// or whitespace
+func pdfReadToken(r *bufio.Reader) (string, error) {
+	token := []byte{}
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return string(token), err
+		}
+		if pdfIsDelimiter(c) {
+			return string(token), r.UnreadByte()
+		}
+		token = append(token, c)
+	}
+}
+
+// pdfSkipComment skips the data until the end of the line
+func pdfSkipComment(r *bufio.Reader) error {
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if c == '\r' || c == '\n' {
+			return nil
+		}
+	}
+}
+
+// pdfSkipString skips a literal string, which can contain balanced or
+// escaped parentheses
+func pdfSkipString(r *bufio.Reader) error {
+	depth := 1
+	for depth > 0 {
+		c, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		switch c {
+		case '\\':
+			if _, err := r.ReadByte(); err != nil {
+				return err
+			}
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+	}
+	return nil
+}
+
+// pdfDecodeName decodes the #xx sequences of a name. "J#61vaScript" is
+// the same name as "JavaScript"
+func pdfDecodeName(name string) string {
+	if !strings.Contains(name, "#") {
+		return name
+	}
+	decoded := make([]byte, 0, len(name))
+	for i := 0; i < len(name); i++ {
+		if name[i] == '#' && i+2 < len(name) {
+			if b, err := hex.DecodeString(name[i+1 : i+3]); err == nil {
+				decoded = append(decoded, b[0])
+				i += 2
+				continue
+			}
+		}
+		decoded = append(decoded, name[i])
+	}
+	return string(decoded)
+}
+
+// pdfIsDelimiter checks if a character is a whitespace or a delimiter
+func pdfIsDelimiter(c byte) bool {
+	switch c {
+	case 0, '\t', '\n', '\f', '\r', ' ', '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+// pdfEOF ignores the io.EOF errors. Files that end in the middle of a
+// comment, string or stream are scanned as much as possible
+func pdfEOF(err error) error {
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,88 @@
+package filetype_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"testing"
+
+	"github.com/Nivl/go-types/filetype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pdfObjectStream returns a compressed object stream containing the
+// provided objects
+func pdfObjectStream(t *testing.T, objects string) string {
+	buf := &bytes.Buffer{}
+	zw := zlib.NewWriter(buf)
+	_, err := zw.Write([]byte(objects))
+	require.NoError(t, err, "Write() should have succeed")
+	require.NoError(t, zw.Close(), "Close() should have succeed")
+	return fmt.Sprintf("5 0 obj\n<< /Type /ObjStm /N 1 /First 4 /Length %d /Filter /FlateDecode >>\nstream\r\n%s\r\nendstream\nendobj\n", buf.Len(), buf.String())
+}
+
+func TestScanActiveContentPDF(t *testing.T) {
+	testCases := []struct {
+		description string
+		content     []byte
+		expected    []filetype.ActiveContent
+	}{
+		{
+			"fixture",
+			readFixture(t, "black_pixel.pdf"),
+			[]filetype.ActiveContent{},
+		},
+		{
+			"open action with javascript",
+			pdfFile(1, "/OpenAction << /S /JavaScript /JS (app.alert\\(1\\)) >> "),
+			[]filetype.ActiveContent{
+				{Kind: filetype.ActiveContentAutoAction, Element: "/OpenAction"},
+				{Kind: filetype.ActiveContentScript, Element: "/JavaScript"},
+				{Kind: filetype.ActiveContentScript, Element: "/JS"},
+			},
+		},
+		{
+			"obfuscated names",
+			pdfFile(1, "/AA << /O << /S /L#61unch /F (calc.exe) >> >> "),
+			[]filetype.ActiveContent{
+				{Kind: filetype.ActiveContentAutoAction, Element: "/AA"},
+				{Kind: filetype.ActiveContentLaunchAction, Element: "/Launch"},
+			},
+		},
+		{
+			"names in strings and comments",
+			pdfFile(1, "/Title (a (nested /JS) string \\) /Launch) % /JavaScript\n"),
+			[]filetype.ActiveContent{},
+		},
+		{
+			"object stream",
+			append(pdfFile(1, ""), pdfObjectStream(t, "6 0 << /S /SubmitForm /F (https://example.com) >> /JS")...),
+			[]filetype.ActiveContent{
+				{Kind: filetype.ActiveContentExternalReference, Element: "/SubmitForm"},
+				{Kind: filetype.ActiveContentScript, Element: "/JS"},
+			},
+		},
+		{
+			"uncompressed stream",
+			append(pdfFile(1, ""), "5 0 obj\n<< /Length 9 >>\nstream\n/JS /Launch\nendstream\nendobj\n"...),
+			[]filetype.ActiveContent{},
+		},
+		{
+			"truncated file",
+			pdfFile(1, "/Names << /EmbeddedFiles << /Names [(a) << /Type /EmbeddedFile >> ] >> >> ")[:300],
+			[]filetype.ActiveContent{{Kind: filetype.ActiveContentEmbeddedContent, Element: "/EmbeddedFile"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			findings, err := filetype.ScanActiveContent(bytes.NewReader(tc.content))
+			require.NoError(t, err, "ScanActiveContent() should have succeed")
+			assert.Equal(t, tc.expected, findings)
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package filetype_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Nivl/go-types/filetype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// svgImage returns an SVG image with the provided content
+func svgImage(content string) []byte {
+	return []byte(`<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink">` + content + `</svg>`)
+}
+
+func TestScanActiveContentSVG(t *testing.T) {
+	testCases := []struct {
+		description string
+		content     []byte
+		expected    []filetype.ActiveContent
+	}{
+		{
+			"fixture",
+			readFixture(t, "black_pixel.svg"),
+			[]filetype.ActiveContent{},
+		},
+		{
+			"local references",
+			svgImage(`<defs><linearGradient id="g"/></defs><rect fill="url(#g)"/><use href="#g"/><image href="data:image/png;base64,AAAA"/><a href="https://example.com">link</a>`),
+			[]filetype.ActiveContent{},
+		},
+		{
+			"script",
+			svgImage(`<script>alert(1)</script>`),
+			[]filetype.ActiveContent{{Kind: filetype.ActiveContentScript, Element: "script"}},
+		},
+		{
+			"event handler",
+			svgImage(`<rect onclick="alert(1)"/>`),
+			[]filetype.ActiveContent{{Kind: filetype.ActiveContentEventHandler, Element: "rect", Attribute: "onclick", Value: "alert(1)"}},
+		},
+		{
+			"javascript url",
+			svgImage(`<a xlink:href="java&#x09;script:alert(1)">link</a>`),
+			[]filetype.ActiveContent{{Kind: filetype.ActiveContentJavaScriptURL, Element: "a", Attribute: "xlink:href", Value: "java\tscript:alert(1)"}},
+		},
+		{
+			"javascript url in an animation",
+			svgImage(`<a><set attributeName="href" to="javascript:alert(1)"/></a>`),
+			[]filetype.ActiveContent{{Kind: filetype.ActiveContentJavaScriptURL, Element: "set", Attribute: "to", Value: "javascript:alert(1)"}},
+		},
+		{
+			"external image",
+			svgImage(`<image href="https://example.com/a.png"/>`),
+			[]filetype.ActiveContent{{Kind: filetype.ActiveContentExternalReference, Element: "image", Attribute: "href", Value: "https://example.com/a.png"}},
+		},
+		{
+			"external url in a style",
+			svgImage(`<rect style="fill: url('https://example.com/a.svg#g')"/>`),
+			[]filetype.ActiveContent{{Kind: filetype.ActiveContentExternalReference, Element: "rect", Attribute: "style", Value: "fill: url('https://example.com/a.svg#g')"}},
+		},
+		{
+			"import in a stylesheet",
+			svgImage(`<style>@import "https://example.com/a.css";</style>`),
+			[]filetype.ActiveContent{{Kind: filetype.ActiveContentExternalReference, Element: "style", Value: `@import "https://example.com/a.css";`}},
+		},
+		{
+			"external stylesheet",
+			append([]byte(`<?xml-stylesheet href="https://example.com/a.css"?>`), svgImage("")...),
+			[]filetype.ActiveContent{{Kind: filetype.ActiveContentExternalReference, Element: "xml-stylesheet", Value: `href="https://example.com/a.css"`}},
+		},
+		{
+			"foreign object",
+			svgImage(`<foreignObject><iframe xmlns="http://www.w3.org/1999/xhtml" src="https://example.com"/></foreignObject>`),
+			[]filetype.ActiveContent{
+				{Kind: filetype.ActiveContentEmbeddedContent, Element: "foreignObject"},
+				{Kind: filetype.ActiveContentEmbeddedContent, Element: "iframe"},
+				{Kind: filetype.ActiveContentExternalReference, Element: "iframe", Attribute: "src", Value: "https://example.com"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			findings, err := filetype.ScanActiveContent(bytes.NewReader(tc.content))
+			require.NoError(t, err, "ScanActiveContent() should have succeed")
+			assert.Equal(t, tc.expected, findings)
+		})
+	}
+}
+
+func TestScanActiveContentHTML(t *testing.T) {
+	testCases := []struct {
+		description string
+		content     string
+		expected    []filetype.ActiveContent
+	}{
+		{
+			"no active content",
+			`<!DOCTYPE html><html><body><p class="a">Hello</p><a href="https://example.com">link</a></body></html>`,
+			[]filetype.ActiveContent{},
+		},
+		{
+			"commented script",
+			`<!DOCTYPE html><html><!-- <script>alert(1)</script> --></html>`,
+			[]filetype.ActiveContent{},
+		},
+		{
+			"script",
+			`<!DOCTYPE html><html><SCRIPT src="https://example.com/a.js"></SCRIPT></html>`,
+			[]filetype.ActiveContent{
+				{Kind: filetype.ActiveContentScript, Element: "SCRIPT"},
+				{Kind: filetype.ActiveContentExternalReference, Element: "SCRIPT", Attribute: "src", Value: "https://example.com/a.js"},
+			},
+		},
+		{
+			"event handler without quotes",
+			`<!DOCTYPE html><html><img src=a.png onerror=alert(1)></html>`,
+			[]filetype.ActiveContent{
+				{Kind: filetype.ActiveContentExternalReference, Element: "img", Attribute: "src", Value: "a.png"},
+				{Kind: filetype.ActiveContentEventHandler, Element: "img", Attribute: "onerror", Value: "alert(1)"},
+			},
+		},
+		{
+			"encoded javascript url",
+			`<!DOCTYPE html><html><a href='&#106;avascript:alert(1)'>link</a></html>`,
+			[]filetype.ActiveContent{
+				{Kind: filetype.ActiveContentJavaScriptURL, Element: "a", Attribute: "href", Value: "javascript:alert(1)"},
+			},
+		},
+		{
+			"iframe with a data url",
+			`<!DOCTYPE html><html><iframe src="data:text/html;base64,PHNjcmlwdD4="></iframe></html>`,
+			[]filetype.ActiveContent{
+				{Kind: filetype.ActiveContentEmbeddedContent, Element: "iframe"},
+				{Kind: filetype.ActiveContentJavaScriptURL, Element: "iframe", Attribute: "src", Value: "data:text/html;base64,PHNjcmlwdD4="},
+			},
+		},
+		{
+			"stylesheet",
+			`<!DOCTYPE html><html><style>body { background: url(https://example.com/a.png) }</style></html>`,
+			[]filetype.ActiveContent{
+				{Kind: filetype.ActiveContentExternalReference, Element: "style", Value: "body { background: url(https://example.com/a.png) }"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			findings, err := filetype.ScanActiveContent(strings.NewReader(tc.content))
+			require.NoError(t, err, "ScanActiveContent() should have succeed")
+			assert.Equal(t, tc.expected, findings)
+		})
+	}
+}
+
+func TestScanActiveContentErrors(t *testing.T) {
+	testCases := []struct {
+		description string
+		content     []byte
+		expectedErr string
+	}{
+		{"png", readFixture(t, "black_pixel.png"), filetype.ErrMsgUnsupportedActiveContentFormat},
+		{"invalid svg", []byte(`<svg><script>`), filetype.ErrMsgInvalidImage},
+		{"svg with an entity", []byte(`<svg>&a;</svg>`), filetype.ErrMsgInvalidImage},
+		{"html with a NUL byte", []byte("<!DOCTYPE html><html>\x00</html>"), filetype.ErrMsgInvalidActiveContentFile},
+		{"pdf without version", []byte("%PDF-\n1 0 obj\n<< /JS (a) >>\nendobj\n"), filetype.ErrMsgInvalidActiveContentFile},
+		{"pdf with an invalid version", []byte("%PDF-a.b\n"), filetype.ErrMsgInvalidActiveContentFile},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			findings, err := filetype.ScanActiveContent(bytes.NewReader(tc.content))
+			require.Error(t, err, "ScanActiveContent() should have failed")
+			assert.Equal(t, tc.expectedErr, err.Error())
+			assert.Nil(t, findings)
+		})
+	}
+}
+
+func TestSanitizeSVG(t *testing.T) {
+	testCases := []struct {
+		description string
+		content     []byte
+		expected    []byte
+		removed     int
+	}{
+		{
+			"fixture",
+			readFixture(t, "black_pixel.svg"),
+			readFixture(t, "black_pixel.svg"),
+			0,
+		},
+		{
+			"script",
+			svgImage(`<rect/><script type="text/javascript"><![CDATA[alert("</svg>")]]></script><g/>`),
+			svgImage(`<rect/><g/>`),
+			1,
+		},
+		{
+			"self-closing script",
+			svgImage(`<script href="https://example.com/a.js"/>`),
+			svgImage(``),
+			2,
+		},
+		{
+			"attributes",
+			svgImage(`<image x="1" xlink:href="https://example.com/a.png" onload="alert(1)" y="a&amp;&quot;b"></image>`),
+			svgImage(`<image x="1" y="a&amp;&#34;b"></image>`),
+			2,
+		},
+		{
+			"self-closing element with attributes",
+			svgImage(`<rect onclick="alert(1)" />`),
+			svgImage(`<rect/>`),
+			1,
+		},
+		{
+			"nested content",
+			svgImage(`<g><foreignObject><div xmlns="http://www.w3.org/1999/xhtml"><script/></div></foreignObject></g>`),
+			svgImage(`<g></g>`),
+			2,
+		},
+		{
+			"stylesheets",
+			append([]byte(`<?xml-stylesheet href="a.css"?>`), svgImage(`<style>@import "a.css";</style><style>rect { fill: red }</style>`)...),
+			svgImage(`<style></style><style>rect { fill: red }</style>`),
+			2,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			out := &bytes.Buffer{}
+			removed, err := filetype.SanitizeSVG(bytes.NewReader(tc.content), out)
+			require.NoError(t, err, "SanitizeSVG() should have succeed")
+			assert.Len(t, removed, tc.removed)
+			assert.Equal(t, string(tc.expected), out.String())
+
+			// The sanitized image must be valid and without active content
+			valid, err := filetype.IsSVG(bytes.NewReader(out.Bytes()))
+			require.NoError(t, err, "IsSVG() should have succeed")
+			assert.True(t, valid, "the sanitized image should be valid")
+			findings, err := filetype.ScanActiveContent(bytes.NewReader(out.Bytes()))
+			require.NoError(t, err, "ScanActiveContent() should have succeed")
+			assert.Empty(t, findings)
+		})
+	}
+}
+
+func TestSanitizeSVGInvalidImage(t *testing.T) {
+	out := &bytes.Buffer{}
+	removed, err := filetype.SanitizeSVG(strings.NewReader("<html></html>"), out)
+	require.Error(t, err, "SanitizeSVG() should have failed")
+	assert.Equal(t, filetype.ErrMsgInvalidImage, err.Error())
+	assert.Nil(t, removed)
+	assert.Zero(t, out.Len(), "nothing should have been written")
+}
+
+// failingWriter is a writer that always fails
+type failingWriter struct {
+	err error
+}
+
+func (w failingWriter) Write(p []byte) (int, error) {
+	return 0, w.err
+}
+
+func TestSanitizeSVGWriteFail(t *testing.T) {
+	expectedErr := errors.New("write failed")
+	removed, err := filetype.SanitizeSVG(bytes.NewReader(svgImage("")), failingWriter{expectedErr})
+	require.Error(t, err, "SanitizeSVG() should have failed")
+	assert.Equal(t, expectedErr, err)
+	assert.Nil(t, removed)
+}
@@ -0,0 +1,477 @@
+package filetype
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/Nivl/go-types/octets"
+)
+
+// ErrMsgUnsupportedArchiveFormat represents the error message returned
+// when trying to walk a file that is not a supported archive
+var ErrMsgUnsupportedArchiveFormat = "unsupported archive format"
+
+// ErrMsgArchiveTooManyEntries represents the error message returned
+// when an archive contains more entries than allowed
+var ErrMsgArchiveTooManyEntries = "archive contains too many entries"
+
+// ErrMsgArchiveEntryTooLarge represents the error message returned
+// when an entry of an archive is bigger than allowed
+var ErrMsgArchiveEntryTooLarge = "archive entry is too large"
+
+// ErrMsgArchiveTooLarge represents the error message returned when the
+// uncompressed size of an archive is bigger than allowed
+var ErrMsgArchiveTooLarge = "archive is too large once uncompressed"
+
+// ErrMsgArchiveCompressionRatio represents the error message returned
+// when the compression ratio of an archive is suspiciously high (zip bomb)
+var ErrMsgArchiveCompressionRatio = "archive has a suspicious compression ratio"
+
+// ErrMsgArchiveTooDeep represents the error message returned when an
+// archive contains too many levels of nested archives
+var ErrMsgArchiveTooDeep = "archive contains too many nested archives"
+
+// ErrMsgArchiveUnsafePath represents the error message returned when
+// an entry of an archive has a path that would be extracted outside of the
+// destination directory (../, absolute paths, etc.)
+var ErrMsgArchiveUnsafePath = "archive contains an unsafe path"
+
+// compressionRatioThreshold is the uncompressed size under which the
+// compression ratio is not checked. Small files can legitimately have a
+// very high compression ratio
+const compressionRatioThreshold = 1 * octets.MiB
+
+// maxArchiveLinkSize is the maximum size of the target of a symlink
+// stored in a zip archive, which is the content of the entry
+const maxArchiveLinkSize = 4 * octets.KiB
+
+// archiveTypes contains the mimetypes of the archives supported by
+// WalkArchive()
+var archiveTypes = map[string]bool{
+	"application/zip":    true,
+	"application/x-tar":  true,
+	"application/x-gzip": true,
+}
+
+// IsArchiveType returns true if files of the provided mimetype can be
+// walked using WalkArchive()
+func IsArchiveType(mimeType string) bool {
+	return archiveTypes[canonicalMimeType(mimeType)]
+}
+
+// ArchiveOptions contains the limits an archive must respect to be walked.
+// Those limits protect against zip bombs and path traversal attacks.
+// A value of 0 means there are no limits, except for MaxDepth.
+type ArchiveOptions struct {
+	// MaxEntries is the maximum number of files, including the files of
+	// the nested archives
+	MaxEntries int `json:"max_entries,omitempty" yaml:"max_entries,omitempty"`
+
+	// MaxEntrySize is the maximum uncompressed size of a file, in bytes.
+	// Each file is kept in memory while being walked.
+	MaxEntrySize int64 `json:"max_entry_size,omitempty" yaml:"max_entry_size,omitempty"`
+
+	// MaxTotalSize is the maximum uncompressed size of all the files,
+	// in bytes
+	MaxTotalSize int64 `json:"max_total_size,omitempty" yaml:"max_total_size,omitempty"`
+
+	// MaxCompressionRatio is the maximum ratio between the uncompressed
+	// and the compressed size of a file. It's only checked for files
+	// bigger than 1MiB
+	MaxCompressionRatio int64 `json:"max_compression_ratio,omitempty" yaml:"max_compression_ratio,omitempty"`
+
+	// MaxDepth is the maximum number of nested archives that will be
+	// walked. 0 means archives cannot contain other archives.
+	// An archive compressed with gzip (.tar.gz) is not considered nested
+	MaxDepth int `json:"max_depth,omitempty" yaml:"max_depth,omitempty"`
+}
+
+// DefaultArchiveOptions returns the limits used by WalkArchive() when no
+// options are provided
+func DefaultArchiveOptions() *ArchiveOptions {
+	return &ArchiveOptions{
+		MaxEntries:          10000,
+		MaxEntrySize:        100 * octets.MiB,
+		MaxTotalSize:        1 * octets.GiB,
+		MaxCompressionRatio: 100,
+		MaxDepth:            1,
+	}
+}
+
+// ArchiveEntry represents a file contained in an archive
+type ArchiveEntry struct {
+	// Name is the path of the file in its archive
+	Name string `json:"name"`
+
+	// Archive is the path of the nested archive containing the file,
+	// using the same format as Name. Empty for the files of the walked
+	// archive. Ex. "images.zip" for "images.zip/image.png"
+	Archive string `json:"archive,omitempty"`
+
+	// Depth is the number of nested archives containing the file. 0 for
+	// the files of the walked archive
+	Depth int `json:"depth"`
+
+	// Size is the uncompressed size of the file, in bytes
+	Size int64 `json:"size"`
+
+	// MimeType is the detected type of the file. Empty if the file is
+	// empty
+	MimeType string `json:"mime_type"`
+}
+
+// ArchiveWalkFunc represents the function called for each file of an
+// archive. r contains the content of the file, and can be used with any
+// FileValidator.
+// Returning an error stops the walk, and the error is returned by
+// WalkArchive()
+type ArchiveWalkFunc func(entry *ArchiveEntry, r io.ReadSeeker) error
+
+// WalkArchive calls fn for each file contained in a ZIP, TAR or GZIP
+// archive (see IsArchiveType()), including the files contained in nested
+// archives. The nested archives are also passed to fn before being
+// walked. Directories and links are not passed to fn.
+// The walk stops as soon as a limit is exceeded, an entry has an unsafe
+// path, or fn returns an error. If opts is nil, DefaultArchiveOptions()
+// will be used.
+// The reader will be put back to its original position.
+func WalkArchive(r io.ReadSeeker, opts *ArchiveOptions, fn ArchiveWalkFunc) (err error) {
+	if opts == nil {
+		opts = DefaultArchiveOptions()
+	}
+
+	mimeType, err := MimeType(r)
+	if err != nil {
+		return err
+	}
+	if !IsArchiveType(mimeType) {
+		return errors.New(ErrMsgUnsupportedArchiveFormat)
+	}
+
+	s, err := newSection(r)
+	if err != nil {
+		return err
+	}
+	// revert the pointer back to its original position
+	defer func() {
+		_, seekErr := r.Seek(s.start, io.SeekStart)
+		if err == nil {
+			err = seekErr
+		}
+	}()
+
+	w := &archiveWalker{opts: opts, fn: fn}
+	return w.walk(s, mimeType, "", 0)
+}
+
+// archiveWalker walks an archive and its nested archives, making sure
+// the limits are respected
+type archiveWalker struct {
+	opts *ArchiveOptions
+	fn   ArchiveWalkFunc
+	// entries is the number of files walked so far
+	entries int
+	// totalSize is the uncompressed size of the files walked so far
+	totalSize int64
+}
+
+// walk walks an archive of the provided type. archivePath is the path of
+// the archive if it's nested
+func (w *archiveWalker) walk(s *section, mimeType, archivePath string, depth int) error {
+	switch canonicalMimeType(mimeType) {
+	case "application/zip":
+		return w.walkZip(s, archivePath, depth)
+	case "application/x-tar":
+		r, err := s.reader()
+		if err != nil {
+			return err
+		}
+		return w.walkTar(r, archivePath, depth, noCompression)
+	case "application/x-gzip":
+		r, err := s.reader()
+		if err != nil {
+			return err
+		}
+		return w.walkGzip(r, archivePath, depth)
+	}
+	return errors.New(ErrMsgUnsupportedArchiveFormat)
+}
+
+// walkZip walks a zip archive
+func (w *archiveWalker) walkZip(s *section, archivePath string, depth int) error {
+	zr, err := zip.NewReader(s, s.size)
+	if err != nil {
+		return err
+	}
+	for _, f := range zr.File {
+		if !isSafeArchivePath(f.Name) {
+			return errors.New(ErrMsgArchiveUnsafePath)
+		}
+		if f.Mode()&os.ModeSymlink != 0 {
+			target, err := readZipLink(f)
+			if err != nil {
+				return err
+			}
+			if !isSafeArchiveLink(f.Name, target) {
+				return errors.New(ErrMsgArchiveUnsafePath)
+			}
+			continue
+		}
+		if !f.Mode().IsRegular() {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		compressedSize := int64(f.CompressedSize64)
+		err = w.visit(rc, f.Name, archivePath, depth, func() int64 { return compressedSize })
+		if closeErr := rc.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readZipLink returns the target of a symlink stored in a zip archive.
+// The target is the content of the entry, and cannot be bigger than
+// maxArchiveLinkSize
+func readZipLink(f *zip.File) (target string, err error) {
+	rc, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if closeErr := rc.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	content, err := ioutil.ReadAll(io.LimitReader(rc, maxArchiveLinkSize+1))
+	if err != nil {
+		return "", err
+	}
+	if int64(len(content)) > maxArchiveLinkSize {
+		return "", errors.New(ErrMsgArchiveUnsafePath)
+	}
+	return string(content), nil
+}
+
+// walkTar walks a tar archive. compressedSize returns the number of
+// compressed bytes read so far if the archive is compressed
+func (w *archiveWalker) walkTar(r io.Reader, archivePath string, depth int, compressedSize func() int64) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !isSafeArchivePath(hdr.Name) {
+			return errors.New(ErrMsgArchiveUnsafePath)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeReg, tar.TypeRegA:
+			if err = w.visit(tr, hdr.Name, archivePath, depth, compressedSize); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if !isSafeArchiveLink(hdr.Name, hdr.Linkname) {
+				return errors.New(ErrMsgArchiveUnsafePath)
+			}
+		case tar.TypeLink:
+			if !isSafeArchivePath(hdr.Linkname) {
+				return errors.New(ErrMsgArchiveUnsafePath)
+			}
+		}
+	}
+}
+
+// walkGzip walks a gzip file. tar.gz files are walked as tar archives,
+// any other content is considered as a single file
+func (w *archiveWalker) walkGzip(r io.Reader, archivePath string, depth int) error {
+	cr := &countingReader{r: r}
+	zr, err := gzip.NewReader(cr)
+	if err != nil {
+		return err
+	}
+	compressedSize := func() int64 { return cr.n }
+
+	content := &compressionRatioReader{r: zr, compressedSize: compressedSize, maxRatio: w.opts.MaxCompressionRatio}
+	mimeType, replay, err := Peek(content)
+	if err != nil && err != errEmptyFile {
+		return err
+	}
+	if mimeType == "application/x-tar" {
+		return w.walkTar(replay, archivePath, depth, compressedSize)
+	}
+	if replay == nil {
+		replay = content
+	}
+
+	// The name of the file is optional
+	name := path.Base(zr.Name)
+	if zr.Name == "" {
+		name = "content"
+	}
+	return w.visit(replay, name, archivePath, depth, compressedSize)
+}
+
+// visit reads a file of an archive, and passes it to the walk function.
+// The file is walked if it's an archive.
+// compressedSize returns the compressed size of the file
+func (w *archiveWalker) visit(r io.Reader, name, archivePath string, depth int, compressedSize func() int64) error {
+	w.entries++
+	if w.opts.MaxEntries > 0 && w.entries > w.opts.MaxEntries {
+		return errors.New(ErrMsgArchiveTooManyEntries)
+	}
+
+	content, err := w.readEntry(r, compressedSize)
+	if err != nil {
+		return err
+	}
+
+	entry := &ArchiveEntry{
+		Name:    name,
+		Archive: archivePath,
+		Depth:   depth,
+		Size:    int64(len(content)),
+	}
+	if len(content) > 0 {
+		entry.MimeType = detectContentType(content)
+	}
+	if err = w.fn(entry, bytes.NewReader(content)); err != nil {
+		return err
+	}
+
+	if !IsArchiveType(entry.MimeType) {
+		return nil
+	}
+	if depth >= w.opts.MaxDepth {
+		return errors.New(ErrMsgArchiveTooDeep)
+	}
+	s, err := newSection(bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	return w.walk(s, entry.MimeType, path.Join(archivePath, name), depth+1)
+}
+
+// readEntry reads the content of a file of an archive, making sure the
+// size limits are respected
+func (w *archiveWalker) readEntry(r io.Reader, compressedSize func() int64) ([]byte, error) {
+	r = &compressionRatioReader{r: r, compressedSize: compressedSize, maxRatio: w.opts.MaxCompressionRatio}
+
+	// We read one more byte than allowed to know if the limits are
+	// exceeded
+	limit := int64(-1)
+	if w.opts.MaxEntrySize > 0 {
+		limit = w.opts.MaxEntrySize
+	}
+	if w.opts.MaxTotalSize > 0 && (limit < 0 || w.opts.MaxTotalSize-w.totalSize < limit) {
+		limit = w.opts.MaxTotalSize - w.totalSize
+	}
+	if limit >= 0 {
+		r = io.LimitReader(r, limit+1)
+	}
+
+	buf := &bytes.Buffer{}
+	if _, err := io.Copy(buf, r); err != nil {
+		return nil, err
+	}
+	size := int64(buf.Len())
+	w.totalSize += size
+
+	switch {
+	case w.opts.MaxEntrySize > 0 && size > w.opts.MaxEntrySize:
+		return nil, errors.New(ErrMsgArchiveEntryTooLarge)
+	case w.opts.MaxTotalSize > 0 && w.totalSize > w.opts.MaxTotalSize:
+		return nil, errors.New(ErrMsgArchiveTooLarge)
+	}
+	return buf.Bytes(), nil
+}
+
+// isSafeArchivePath checks that a path stays inside the directory the
+// archive would be extracted to
+func isSafeArchivePath(name string) bool {
+	if name == "" || isAbsArchivePath(name) || strings.ContainsRune(name, 0) {
+		return false
+	}
+	// zip files created on Windows can use backslashes
+	cleaned := path.Clean(strings.Replace(name, `\`, "/", -1))
+	return cleaned != ".." && !strings.HasPrefix(cleaned, "../")
+}
+
+// isSafeArchiveLink checks that the target of a symlink stays inside the
+// directory the archive would be extracted to. The target of a symlink is
+// relative to its directory
+func isSafeArchiveLink(name, target string) bool {
+	return !isAbsArchivePath(target) && isSafeArchivePath(path.Join(path.Dir(name), target))
+}
+
+// isAbsArchivePath checks if a path is absolute, on Unix or on Windows
+// (\dir, C:\dir, etc.)
+func isAbsArchivePath(name string) bool {
+	return strings.HasPrefix(name, "/") || strings.HasPrefix(name, `\`) || (len(name) >= 2 && name[1] == ':')
+}
+
+// noCompression is used as compressed size for the files that are not
+// compressed
+func noCompression() int64 {
+	return 0
+}
+
+// countingReader is a reader that counts the number of bytes read
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read implements the io.Reader interface
+// https://golang.org/pkg/io/#Reader
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// compressionRatioReader is a reader that fails when the ratio between
+// the uncompressed and the compressed data exceeds maxRatio
+type compressionRatioReader struct {
+	r io.Reader
+	// compressedSize returns the number of compressed bytes.
+	// The ratio is not checked if it returns 0
+	compressedSize func() int64
+	maxRatio       int64
+	// n is the number of uncompressed bytes read
+	n int64
+}
+
+// Read implements the io.Reader interface
+// https://golang.org/pkg/io/#Reader
+func (r *compressionRatioReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	if r.maxRatio > 0 && r.n > compressionRatioThreshold {
+		compressed := r.compressedSize()
+		if compressed > 0 && r.n/compressed > r.maxRatio {
+			return n, errors.New(ErrMsgArchiveCompressionRatio)
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,407 @@
+package filetype_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Nivl/go-types/filetype"
+	"github.com/Nivl/go-types/octets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// archiveFile represents a file to put in an archive
+type archiveFile struct {
+	name    string
+	content []byte
+}
+
+// zipFile returns a zip archive containing the provided files
+func zipFile(t *testing.T, files ...archiveFile) []byte {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		require.NoError(t, err, "Create() should have succeed")
+		_, err = w.Write(f.content)
+		require.NoError(t, err, "Write() should have succeed")
+	}
+	require.NoError(t, zw.Close(), "Close() should have succeed")
+	return buf.Bytes()
+}
+
+// zipSymlink returns a zip archive containing a symlink to target
+func zipSymlink(t *testing.T, name, target string) []byte {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	hdr := &zip.FileHeader{Name: name, Method: zip.Deflate}
+	hdr.SetMode(os.ModeSymlink | 0777)
+	w, err := zw.CreateHeader(hdr)
+	require.NoError(t, err, "CreateHeader() should have succeed")
+	_, err = w.Write([]byte(target))
+	require.NoError(t, err, "Write() should have succeed")
+	require.NoError(t, zw.Close(), "Close() should have succeed")
+	return buf.Bytes()
+}
+
+// tarFile returns a tar archive containing the provided files
+func tarFile(t *testing.T, files ...archiveFile) []byte {
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	for _, f := range files {
+		hdr := &tar.Header{Name: f.name, Mode: 0600, Size: int64(len(f.content)), Typeflag: tar.TypeReg}
+		require.NoError(t, tw.WriteHeader(hdr), "WriteHeader() should have succeed")
+		_, err := tw.Write(f.content)
+		require.NoError(t, err, "Write() should have succeed")
+	}
+	require.NoError(t, tw.Close(), "Close() should have succeed")
+	return buf.Bytes()
+}
+
+// gzipFile returns the provided content compressed with gzip
+func gzipFile(t *testing.T, name string, content []byte) []byte {
+	buf := &bytes.Buffer{}
+	zw := gzip.NewWriter(buf)
+	zw.Name = name
+	_, err := zw.Write(content)
+	require.NoError(t, err, "Write() should have succeed")
+	require.NoError(t, zw.Close(), "Close() should have succeed")
+	return buf.Bytes()
+}
+
+// walkedEntry contains the data received by an ArchiveWalkFunc
+type walkedEntry struct {
+	filetype.ArchiveEntry
+	isPNG bool
+}
+
+// walkArchive walks an archive and returns all the entries that were
+// walked
+func walkArchive(t *testing.T, content []byte, opts *filetype.ArchiveOptions) ([]walkedEntry, error) {
+	entries := []walkedEntry{}
+	err := filetype.WalkArchive(bytes.NewReader(content), opts, func(entry *filetype.ArchiveEntry, r io.ReadSeeker) error {
+		isPNG, err := filetype.IsPNG(r)
+		require.NoError(t, err, "IsPNG() should have succeed")
+		entries = append(entries, walkedEntry{ArchiveEntry: *entry, isPNG: isPNG})
+		return nil
+	})
+	return entries, err
+}
+
+func TestWalkArchive(t *testing.T) {
+	png := readFixture(t, "black_pixel.png")
+	pngSize := int64(len(png))
+	text := []byte("this is a test")
+
+	testCases := []struct {
+		description string
+		content     []byte
+		expected    []walkedEntry
+	}{
+		{
+			"zip",
+			zipFile(t, archiveFile{"images/", nil}, archiveFile{"images/a.png", png}, archiveFile{"readme.txt", text}),
+			[]walkedEntry{
+				{filetype.ArchiveEntry{Name: "images/a.png", Size: pngSize, MimeType: "image/png"}, true},
+				{filetype.ArchiveEntry{Name: "readme.txt", Size: 14, MimeType: "text/plain; charset=utf-8"}, false},
+			},
+		},
+		{
+			"tar",
+			tarFile(t, archiveFile{"a.png", png}, archiveFile{"empty", nil}),
+			[]walkedEntry{
+				{filetype.ArchiveEntry{Name: "a.png", Size: pngSize, MimeType: "image/png"}, true},
+				{filetype.ArchiveEntry{Name: "empty"}, false},
+			},
+		},
+		{
+			"tar.gz",
+			gzipFile(t, "", tarFile(t, archiveFile{"a.png", png})),
+			[]walkedEntry{
+				{filetype.ArchiveEntry{Name: "a.png", Size: pngSize, MimeType: "image/png"}, true},
+			},
+		},
+		{
+			"gzip",
+			gzipFile(t, "dir/a.png", png),
+			[]walkedEntry{
+				{filetype.ArchiveEntry{Name: "a.png", Size: pngSize, MimeType: "image/png"}, true},
+			},
+		},
+		{
+			"gzip without name",
+			gzipFile(t, "", text),
+			[]walkedEntry{
+				{filetype.ArchiveEntry{Name: "content", Size: 14, MimeType: "text/plain; charset=utf-8"}, false},
+			},
+		},
+		{
+			"nested zip",
+			zipFile(t, archiveFile{"a.png", png}, archiveFile{"more.zip", zipFile(t, archiveFile{"b.png", png})}),
+			[]walkedEntry{
+				{filetype.ArchiveEntry{Name: "a.png", Size: pngSize, MimeType: "image/png"}, true},
+				{filetype.ArchiveEntry{Name: "more.zip", Size: int64(len(zipFile(t, archiveFile{"b.png", png}))), MimeType: "application/zip"}, false},
+				{filetype.ArchiveEntry{Name: "b.png", Archive: "more.zip", Depth: 1, Size: pngSize, MimeType: "image/png"}, true},
+			},
+		},
+		{
+			"highly compressible small file",
+			zipFile(t, archiveFile{"zeros", make([]byte, 500*1024)}),
+			[]walkedEntry{
+				{filetype.ArchiveEntry{Name: "zeros", Size: 500 * 1024, MimeType: "application/octet-stream"}, false},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			entries, err := walkArchive(t, tc.content, nil)
+			require.NoError(t, err, "WalkArchive() should have succeed")
+			assert.Equal(t, tc.expected, entries, "invalid entries")
+		})
+	}
+}
+
+func TestWalkArchiveLimits(t *testing.T) {
+	png := readFixture(t, "black_pixel.png")
+	zeros := make([]byte, 2*octets.MiB)
+
+	testCases := []struct {
+		description string
+		content     []byte
+		opts        *filetype.ArchiveOptions
+		expectedErr string
+	}{
+		{
+			"too many entries",
+			zipFile(t, archiveFile{"a.png", png}, archiveFile{"b.png", png}),
+			&filetype.ArchiveOptions{MaxEntries: 1},
+			filetype.ErrMsgArchiveTooManyEntries,
+		},
+		{
+			"entry too large",
+			zipFile(t, archiveFile{"a.png", png}),
+			&filetype.ArchiveOptions{MaxEntrySize: 10},
+			filetype.ErrMsgArchiveEntryTooLarge,
+		},
+		{
+			"archive too large",
+			tarFile(t, archiveFile{"a.png", png}, archiveFile{"b.png", png}),
+			&filetype.ArchiveOptions{MaxTotalSize: int64(len(png)) + 1},
+			filetype.ErrMsgArchiveTooLarge,
+		},
+		{
+			"zip bomb",
+			zipFile(t, archiveFile{"zeros", zeros}),
+			&filetype.ArchiveOptions{MaxCompressionRatio: 100},
+			filetype.ErrMsgArchiveCompressionRatio,
+		},
+		{
+			"gzip bomb",
+			gzipFile(t, "zeros", zeros),
+			&filetype.ArchiveOptions{MaxCompressionRatio: 100},
+			filetype.ErrMsgArchiveCompressionRatio,
+		},
+		{
+			"tar.gz bomb",
+			gzipFile(t, "", tarFile(t, archiveFile{"zeros", zeros})),
+			&filetype.ArchiveOptions{MaxCompressionRatio: 100},
+			filetype.ErrMsgArchiveCompressionRatio,
+		},
+		{
+			"nested archives not allowed",
+			zipFile(t, archiveFile{"more.zip", zipFile(t, archiveFile{"a.png", png})}),
+			&filetype.ArchiveOptions{},
+			filetype.ErrMsgArchiveTooDeep,
+		},
+		{
+			"too many nested archives",
+			zipFile(t, archiveFile{"more.zip", zipFile(t, archiveFile{"evenmore.tar", tarFile(t, archiveFile{"a.png", png})})}),
+			&filetype.ArchiveOptions{MaxDepth: 1},
+			filetype.ErrMsgArchiveTooDeep,
+		},
+		{
+			"path traversal",
+			zipFile(t, archiveFile{"../../a.png", png}),
+			nil,
+			filetype.ErrMsgArchiveUnsafePath,
+		},
+		{
+			"path traversal in a directory",
+			zipFile(t, archiveFile{"images/../../a.png", png}),
+			nil,
+			filetype.ErrMsgArchiveUnsafePath,
+		},
+		{
+			"windows path traversal",
+			zipFile(t, archiveFile{`..\a.png`, png}),
+			nil,
+			filetype.ErrMsgArchiveUnsafePath,
+		},
+		{
+			"path traversal in a zip symlink",
+			zipSymlink(t, "images/a.png", "../../etc/passwd"),
+			nil,
+			filetype.ErrMsgArchiveUnsafePath,
+		},
+		{
+			"absolute path",
+			tarFile(t, archiveFile{"/etc/passwd", png}),
+			nil,
+			filetype.ErrMsgArchiveUnsafePath,
+		},
+		{
+			"windows absolute path",
+			zipFile(t, archiveFile{`C:\Windows\a.png`, png}),
+			nil,
+			filetype.ErrMsgArchiveUnsafePath,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := walkArchive(t, tc.content, tc.opts)
+			require.Error(t, err, "WalkArchive() should have failed")
+			assert.Equal(t, tc.expectedErr, err.Error(), "invalid error")
+		})
+	}
+}
+
+func TestWalkArchiveLinks(t *testing.T) {
+	testCases := []struct {
+		description string
+		typeflag    byte
+		linkname    string
+		isSafe      bool
+	}{
+		{"safe symlink", tar.TypeSymlink, "../b.png", true},
+		{"unsafe symlink", tar.TypeSymlink, "../../etc/passwd", false},
+		{"absolute symlink", tar.TypeSymlink, "/etc/passwd", false},
+		{"safe hard link", tar.TypeLink, "images/b.png", true},
+		{"unsafe hard link", tar.TypeLink, "../etc/passwd", false},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			buf := &bytes.Buffer{}
+			tw := tar.NewWriter(buf)
+			hdr := &tar.Header{Name: "images/a.png", Typeflag: tc.typeflag, Linkname: tc.linkname, Mode: 0600}
+			require.NoError(t, tw.WriteHeader(hdr), "WriteHeader() should have succeed")
+			require.NoError(t, tw.Close(), "Close() should have succeed")
+
+			entries, err := walkArchive(t, buf.Bytes(), nil)
+			if !tc.isSafe {
+				require.Error(t, err, "WalkArchive() should have failed")
+				assert.Equal(t, filetype.ErrMsgArchiveUnsafePath, err.Error(), "invalid error")
+				return
+			}
+			require.NoError(t, err, "WalkArchive() should have succeed")
+			assert.Empty(t, entries, "links should not be walked")
+		})
+	}
+}
+
+func TestWalkArchiveZipSymlinks(t *testing.T) {
+	testCases := []struct {
+		description string
+		target      string
+		isSafe      bool
+	}{
+		{"safe symlink", "../b.png", true},
+		{"unsafe symlink", "../../etc/passwd", false},
+		{"absolute symlink", "/etc/passwd", false},
+		{"windows absolute symlink", `C:\Windows`, false},
+		{"target too long", strings.Repeat("a/", 4*1024), false},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			entries, err := walkArchive(t, zipSymlink(t, "images/a.png", tc.target), nil)
+			if !tc.isSafe {
+				require.Error(t, err, "WalkArchive() should have failed")
+				assert.Equal(t, filetype.ErrMsgArchiveUnsafePath, err.Error(), "invalid error")
+				return
+			}
+			require.NoError(t, err, "WalkArchive() should have succeed")
+			assert.Empty(t, entries, "links should not be walked")
+		})
+	}
+}
+
+func TestWalkArchiveUnsupportedFormat(t *testing.T) {
+	t.Parallel()
+
+	_, err := walkArchive(t, readFixture(t, "black_pixel.png"), nil)
+	require.Error(t, err, "WalkArchive() should have failed")
+	assert.Equal(t, filetype.ErrMsgUnsupportedArchiveFormat, err.Error(), "invalid error")
+}
+
+func TestWalkArchiveCorrupted(t *testing.T) {
+	t.Parallel()
+
+	content := zipFile(t, archiveFile{"a.png", readFixture(t, "black_pixel.png")})
+	_, err := walkArchive(t, content[:len(content)-10], nil)
+	require.Error(t, err, "WalkArchive() should have failed")
+}
+
+func TestWalkArchiveWalkFuncError(t *testing.T) {
+	t.Parallel()
+
+	content := zipFile(t, archiveFile{"a.txt", []byte("a")}, archiveFile{"b.txt", []byte("b")})
+	r := bytes.NewReader(content)
+	_, err := r.Seek(0, io.SeekStart)
+	require.NoError(t, err, "Seek() should have succeed")
+
+	calls := 0
+	expectedErr := errors.New("stop")
+	err = filetype.WalkArchive(r, nil, func(entry *filetype.ArchiveEntry, r io.ReadSeeker) error {
+		calls++
+		return expectedErr
+	})
+	assert.Equal(t, expectedErr, err, "the error of the walk function should have been returned")
+	assert.Equal(t, 1, calls, "the walk should have stopped after the first error")
+
+	pos, err := r.Seek(0, io.SeekCurrent)
+	require.NoError(t, err, "Seek() should have succeed")
+	assert.Equal(t, int64(0), pos, "the reader should have been put back to its original position")
+}
+
+func TestIsArchiveType(t *testing.T) {
+	testCases := []struct {
+		mimeType string
+		expected bool
+	}{
+		{"application/zip", true},
+		{"application/x-tar", true},
+		{"application/x-gzip", true},
+		{"application/gzip", true},
+		{"image/png", false},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.mimeType, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, filetype.IsArchiveType(tc.mimeType))
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package filetype
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrMsgUnsupportedDocumentFormat represents the error message returned
+// for an unsupported document type
+var ErrMsgUnsupportedDocumentFormat = "unsupported document format"
+
+// ErrMsgInvalidDocument represents the error message returned when a
+// document cannot be parsed
+var ErrMsgInvalidDocument = "invalid document"
+
+// DocumentInfo contains the information extracted from the structure of
+// a document
+type DocumentInfo struct {
+	// MimeType is the type of the document. Encrypted office documents
+	// are stored in an OLE container that hides their real type, and
+	// are reported as application/x-ole-storage
+	MimeType string `json:"mime_type"`
+
+	// Version is the version of the format. Only set for PDF files
+	Version string `json:"version,omitempty"`
+
+	// PageCount is the number of pages of the document. Only set for PDF
+	// files, 0 if the number of pages cannot be found without decompressing
+	// the document
+	PageCount int `json:"page_count,omitempty"`
+
+	// Encrypted is true if the document is protected by a password or
+	// encrypted
+	Encrypted bool `json:"encrypted"`
+
+	// MacroEnabled is true if the document contains macros
+	MacroEnabled bool `json:"macro_enabled"`
+}
+
+// documentParser represents a function that parses the structure of a
+// document
+type documentParser func(s *section) (*DocumentInfo, error)
+
+// documentParsers contains the parsers of all the supported documents
+// formats, indexed by the mimetype returned by MimeType()
+var documentParsers = map[string]documentParser{
+	"application/pdf":                                 parsePDF,
+	"application/zip":                                 parseZipDocument,
+	"application/vnd.oasis.opendocument.text":         parseODF,
+	"application/vnd.oasis.opendocument.spreadsheet":  parseODF,
+	"application/vnd.oasis.opendocument.presentation": parseODF,
+	"application/x-ole-storage":                       parseCFB,
+}
+
+// InspectDocument returns the information of a PDF, an office document
+// (OOXML or ODF), or a legacy office document (OLE).
+// The reader will be put back to its original position.
+func InspectDocument(r io.ReadSeeker) (*DocumentInfo, error) {
+	mimeType, err := MimeType(r)
+	if err != nil {
+		return nil, err
+	}
+	parse, found := documentParsers[baseMediaType(mimeType)]
+	if !found {
+		return nil, errors.New(ErrMsgUnsupportedDocumentFormat)
+	}
+
+	var info *DocumentInfo
+	res, err := checkStructure(r, func(s *section) (err error) {
+		info, err = parse(s)
+		return err
+	})
+	switch {
+	case err == errUnsupportedFormat:
+		// ex. a zip file that is not a document
+		return nil, errors.New(ErrMsgUnsupportedDocumentFormat)
+	case err != nil:
+		return nil, err
+	case !res.Valid:
+		return nil, errors.New(ErrMsgInvalidDocument)
+	}
+	return info, nil
+}
+
+// IsPDF validates the structure of a PDF file (header, cross-reference
+// table and trailer). Encrypted files are valid.
+// Use InspectDocument() to know if a file is encrypted.
+func IsPDF(r io.ReadSeeker) (bool, error) {
+	return validateDocument(r, parsePDF, "application/pdf")
+}
+
+// IsDOCX validates the structure of a Word document (.docx and .docm).
+// Use InspectDocument() to know if a document contains macros.
+func IsDOCX(r io.ReadSeeker) (bool, error) {
+	return validateDocument(r, parseOOXML,
+		"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		"application/vnd.ms-word.document.macroenabled.12")
+}
+
+// IsXLSX validates the structure of an Excel spreadsheet (.xlsx and
+// .xlsm).
+// Use InspectDocument() to know if a document contains macros.
+func IsXLSX(r io.ReadSeeker) (bool, error) {
+	return validateDocument(r, parseOOXML,
+		"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		"application/vnd.ms-excel.sheet.macroenabled.12")
+}
+
+// IsODT validates the structure of an OpenDocument text file (.odt).
+// Encrypted files are valid.
+// Use InspectDocument() to know if a document contains macros or is
+// encrypted.
+func IsODT(r io.ReadSeeker) (bool, error) {
+	return validateDocument(r, parseODF, "application/vnd.oasis.opendocument.text")
+}
+
+// validateDocument checks that a document can be parsed and is of one
+// of the expected types
+func validateDocument(r io.ReadSeeker, parse documentParser, mimeTypes ...string) (bool, error) {
+	return validateStructure(r, func(s *section) error {
+		info, err := parse(s)
+		if err == errUnsupportedFormat {
+			return errMalformed
+		}
+		if err != nil {
+			return err
+		}
+		for _, mimeType := range mimeTypes {
+			if info.MimeType == mimeType {
+				return nil
+			}
+		}
+		return errMalformed
+	})
+}
@@ -0,0 +1,455 @@
+package filetype
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+	"unicode/utf16"
+)
+
+// maxOfficeXMLSize is the maximum size of the XML files we parse to
+// validate an office document
+const maxOfficeXMLSize = 1 << 20
+
+// ooxmlDocumentType contains the information of an OOXML document
+// type, indexed by the content type of its main part
+type ooxmlDocumentType struct {
+	mimeType     string
+	macroEnabled bool
+}
+
+// ooxmlMainTypes contains the OOXML documents we support, indexed by the
+// content type (in lower case) of their main part
+var ooxmlMainTypes = map[string]ooxmlDocumentType{
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml":   {mimeType: "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+	"application/vnd.ms-word.document.macroenabled.main+xml":                             {mimeType: "application/vnd.ms-word.document.macroenabled.12", macroEnabled: true},
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml":         {mimeType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+	"application/vnd.ms-excel.sheet.macroenabled.main+xml":                               {mimeType: "application/vnd.ms-excel.sheet.macroenabled.12", macroEnabled: true},
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation.main+xml": {mimeType: "application/vnd.openxmlformats-officedocument.presentationml.presentation"},
+	"application/vnd.ms-powerpoint.presentation.macroenabled.main+xml":                   {mimeType: "application/vnd.ms-powerpoint.presentation.macroenabled.12", macroEnabled: true},
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.template.main+xml":   {mimeType: "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.template.main+xml":      {mimeType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+	"application/vnd.openxmlformats-officedocument.presentationml.template.main+xml":     {mimeType: "application/vnd.openxmlformats-officedocument.presentationml.presentation"},
+	"application/vnd.openxmlformats-officedocument.presentationml.slideshow.main+xml":    {mimeType: "application/vnd.openxmlformats-officedocument.presentationml.presentation"},
+	"application/vnd.ms-word.template.macroenabledtemplate.main+xml":                     {mimeType: "application/vnd.ms-word.document.macroenabled.12", macroEnabled: true},
+	"application/vnd.ms-excel.template.macroenabled.main+xml":                            {mimeType: "application/vnd.ms-excel.sheet.macroenabled.12", macroEnabled: true},
+	"application/vnd.ms-powerpoint.slideshow.macroenabled.main+xml":                      {mimeType: "application/vnd.ms-powerpoint.presentation.macroenabled.12", macroEnabled: true},
+	"application/vnd.ms-powerpoint.template.macroenabled.main+xml":                       {mimeType: "application/vnd.ms-powerpoint.presentation.macroenabled.12", macroEnabled: true},
+}
+
+// ooxmlVBAContentType is the content type of the part containing the
+// macros of an OOXML document
+const ooxmlVBAContentType = "application/vnd.ms-office.vbaproject"
+
+// ooxmlContentTypes represents the [Content_Types].xml file of an OOXML
+// document
+type ooxmlContentTypes struct {
+	Defaults []struct {
+		ContentType string `xml:"ContentType,attr"`
+	} `xml:"Default"`
+	Overrides []struct {
+		PartName    string `xml:"PartName,attr"`
+		ContentType string `xml:"ContentType,attr"`
+	} `xml:"Override"`
+}
+
+// parseZipDocument parses an office document (OOXML or ODF) stored in a
+// zip file. errUnsupportedFormat is returned if the zip file is not an
+// office document
+func parseZipDocument(s *section) (*DocumentInfo, error) {
+	zr, err := openZip(s)
+	if err != nil {
+		return nil, err
+	}
+	files := zipFiles(zr)
+	if _, ok := files["mimetype"]; ok {
+		return odfInfo(files)
+	}
+	return ooxmlInfo(files)
+}
+
+// parseOOXML parses an OOXML document (docx, xlsx, pptx, etc.)
+func parseOOXML(s *section) (*DocumentInfo, error) {
+	zr, err := openZip(s)
+	if err != nil {
+		return nil, err
+	}
+	return ooxmlInfo(zipFiles(zr))
+}
+
+// parseODF parses an OpenDocument file (odt, ods, odp, etc.)
+func parseODF(s *section) (*DocumentInfo, error) {
+	zr, err := openZip(s)
+	if err != nil {
+		return nil, err
+	}
+	return odfInfo(zipFiles(zr))
+}
+
+// ooxmlInfo returns the information of an OOXML document using its
+// [Content_Types].xml file
+func ooxmlInfo(files map[string]*zip.File) (*DocumentInfo, error) {
+	f, ok := files["[Content_Types].xml"]
+	if !ok {
+		return nil, errUnsupportedFormat
+	}
+	data, err := readZipFile(f, maxOfficeXMLSize)
+	if err != nil {
+		return nil, err
+	}
+	contentTypes := &ooxmlContentTypes{}
+	if err = xml.Unmarshal(data, contentTypes); err != nil {
+		return nil, errMalformed
+	}
+
+	var info *DocumentInfo
+	for _, o := range contentTypes.Overrides {
+		docType, ok := ooxmlMainTypes[strings.ToLower(o.ContentType)]
+		if !ok || info != nil {
+			continue
+		}
+		// The main part must exist
+		if _, ok = files[strings.TrimPrefix(o.PartName, "/")]; !ok {
+			return nil, errMalformed
+		}
+		info = &DocumentInfo{MimeType: docType.mimeType, MacroEnabled: docType.macroEnabled}
+	}
+	// Zip files with a [Content_Types].xml are not all office documents
+	// (NuGet packages, XPS documents, etc.)
+	if info == nil {
+		return nil, errUnsupportedFormat
+	}
+
+	for _, o := range contentTypes.Overrides {
+		if strings.ToLower(o.ContentType) == ooxmlVBAContentType {
+			info.MacroEnabled = true
+		}
+	}
+	for _, d := range contentTypes.Defaults {
+		if strings.ToLower(d.ContentType) == ooxmlVBAContentType {
+			info.MacroEnabled = true
+		}
+	}
+	for name := range files {
+		if strings.EqualFold(path.Base(name), "vbaProject.bin") {
+			info.MacroEnabled = true
+		}
+	}
+	return info, nil
+}
+
+// odfInfo returns the information of an OpenDocument file using its
+// mimetype and manifest files
+func odfInfo(files map[string]*zip.File) (*DocumentInfo, error) {
+	f, ok := files["mimetype"]
+	if !ok {
+		return nil, errUnsupportedFormat
+	}
+	data, err := readZipFile(f, 256)
+	if err != nil {
+		return nil, err
+	}
+	mimeType := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(mimeType, "application/vnd.oasis.opendocument.") {
+		return nil, errUnsupportedFormat
+	}
+	info := &DocumentInfo{MimeType: mimeType}
+
+	if _, ok = files["content.xml"]; !ok {
+		return nil, errMalformed
+	}
+	manifest, ok := files["META-INF/manifest.xml"]
+	if !ok {
+		return nil, errMalformed
+	}
+	if data, err = readZipFile(manifest, maxOfficeXMLSize); err != nil {
+		return nil, err
+	}
+	if info.Encrypted, err = odfIsEncrypted(data); err != nil {
+		return nil, err
+	}
+
+	for name := range files {
+		if isODFMacro(name) {
+			info.MacroEnabled = true
+		}
+	}
+	return info, nil
+}
+
+// odfIsEncrypted checks if the manifest of an OpenDocument file contains
+// encryption data
+func odfIsEncrypted(manifest []byte) (bool, error) {
+	dec := xml.NewDecoder(bytes.NewReader(manifest))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, errMalformed
+		}
+		if el, ok := tok.(xml.StartElement); ok && el.Name.Local == "encryption-data" {
+			return true, nil
+		}
+	}
+}
+
+// isODFMacro checks if a file of an OpenDocument file contains macros.
+// Basic macros are stored in Basic/<library>/<module>.xml, and the
+// other scripts are stored in Scripts/
+func isODFMacro(name string) bool {
+	if strings.HasSuffix(name, "/") {
+		return false
+	}
+	if strings.HasPrefix(name, "Scripts/") {
+		return true
+	}
+	parts := strings.Split(name, "/")
+	return len(parts) == 3 && parts[0] == "Basic" &&
+		parts[2] != "script-lb.xml" && parts[2] != "dialog-lb.xml"
+}
+
+// openZip opens a zip file
+func openZip(s *section) (*zip.Reader, error) {
+	zr, err := zip.NewReader(s, s.size)
+	if err != nil {
+		return nil, zipError(err)
+	}
+	return zr, nil
+}
+
+// zipFiles returns the files of a zip file indexed by name
+func zipFiles(zr *zip.Reader) map[string]*zip.File {
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	return files
+}
+
+// readZipFile returns the content of a file of a zip file.
+// errMalformed is returned if the file is bigger than maxSize
+func readZipFile(f *zip.File, maxSize int64) (data []byte, err error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, zipError(err)
+	}
+	defer func() {
+		if closeErr := rc.Close(); err == nil && closeErr != nil {
+			data = nil
+			err = zipError(closeErr)
+		}
+	}()
+
+	data, err = ioutil.ReadAll(io.LimitReader(rc, maxSize+1))
+	if err != nil {
+		return nil, zipError(err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, errMalformed
+	}
+	return data, nil
+}
+
+// zipError converts the errors returned by archive/zip into errMalformed
+// when they are caused by an invalid file
+func zipError(err error) error {
+	switch err.(type) {
+	case flate.CorruptInputError:
+		return errMalformed
+	}
+	switch err {
+	case zip.ErrFormat, zip.ErrAlgorithm, zip.ErrChecksum, io.EOF:
+		return errMalformed
+	case io.ErrUnexpectedEOF:
+		return errTruncated
+	}
+	return err
+}
+
+// cfbSignature is the signature of the Compound File Binary format (OLE),
+// used by legacy office documents and encrypted OOXML documents
+var cfbSignature = []byte("\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1")
+
+// Special sectors of the Compound File Binary format
+const (
+	cfbEndOfChain = 0xFFFFFFFE
+	cfbMaxSector  = 0xFFFFFFFA
+)
+
+// cfbFile gives access to the sectors of a Compound File Binary file
+type cfbFile struct {
+	s          *section
+	sectorSize int64
+	// fat contains the next sector of each sector
+	fat []uint32
+}
+
+// cfbEntry represents an entry of the directory of a Compound File
+// Binary file
+type cfbEntry struct {
+	name        string
+	startSector uint32
+	size        uint64
+}
+
+// parseCFB parses a Compound File Binary file (legacy office documents,
+// or encrypted OOXML documents)
+func parseCFB(s *section) (*DocumentInfo, error) {
+	header, err := s.read(0, 512)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(header[:8], cfbSignature) {
+		return nil, errMalformed
+	}
+	shift := binary.LittleEndian.Uint16(header[0x1E:])
+	if shift != 9 && shift != 12 {
+		return nil, errMalformed
+	}
+	cfb := &cfbFile{s: s, sectorSize: 1 << shift}
+	if err = cfb.readFAT(header); err != nil {
+		return nil, err
+	}
+	entries, err := cfb.readDirectory(binary.LittleEndian.Uint32(header[0x30:]))
+	if err != nil {
+		return nil, err
+	}
+
+	info := &DocumentInfo{MimeType: "application/x-ole-storage"}
+	for _, entry := range entries {
+		switch entry.name {
+		case "WordDocument":
+			info.MimeType = "application/msword"
+			if cfb.isWordEncrypted(entry) {
+				info.Encrypted = true
+			}
+		case "Workbook", "Book":
+			info.MimeType = "application/vnd.ms-excel"
+		case "PowerPoint Document":
+			info.MimeType = "application/vnd.ms-powerpoint"
+		case "EncryptedPackage":
+			// encrypted OOXML document
+			info.Encrypted = true
+		case "Macros", "_VBA_PROJECT_CUR", "VBA":
+			info.MacroEnabled = true
+		}
+	}
+	return info, nil
+}
+
+// sectorOffset returns the offset of a sector in the file
+func (cfb *cfbFile) sectorOffset(sector uint32) int64 {
+	// The header takes the place of the first sector
+	return (int64(sector) + 1) * cfb.sectorSize
+}
+
+// readSector returns the content of a sector
+func (cfb *cfbFile) readSector(sector uint32) ([]byte, error) {
+	if sector > cfbMaxSector {
+		return nil, errMalformed
+	}
+	return cfb.s.read(cfb.sectorOffset(sector), cfb.sectorSize)
+}
+
+// readFAT reads the File Allocation Table of the file using the DIFAT
+func (cfb *cfbFile) readFAT(header []byte) error {
+	numFATSectors := int64(binary.LittleEndian.Uint32(header[0x2C:]))
+	if numFATSectors*cfb.sectorSize > cfb.s.size {
+		return errMalformed
+	}
+
+	// The first 109 FAT sectors are listed in the header, the other
+	// ones are listed in a chain of DIFAT sectors
+	fatSectors := make([]uint32, 0, numFATSectors)
+	for i := 0; i < 109 && int64(len(fatSectors)) < numFATSectors; i++ {
+		fatSectors = append(fatSectors, binary.LittleEndian.Uint32(header[0x4C+i*4:]))
+	}
+	difatSector := binary.LittleEndian.Uint32(header[0x44:])
+	for int64(len(fatSectors)) < numFATSectors {
+		data, err := cfb.readSector(difatSector)
+		if err != nil {
+			return err
+		}
+		// The last entry of a DIFAT sector is the next DIFAT sector
+		last := len(data) - 4
+		for i := 0; i < last && int64(len(fatSectors)) < numFATSectors; i += 4 {
+			fatSectors = append(fatSectors, binary.LittleEndian.Uint32(data[i:]))
+		}
+		difatSector = binary.LittleEndian.Uint32(data[last:])
+	}
+
+	cfb.fat = make([]uint32, 0, numFATSectors*cfb.sectorSize/4)
+	for _, sector := range fatSectors {
+		data, err := cfb.readSector(sector)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < len(data); i += 4 {
+			cfb.fat = append(cfb.fat, binary.LittleEndian.Uint32(data[i:]))
+		}
+	}
+	return nil
+}
+
+// readDirectory returns all the entries of the directory, which starts
+// at the provided sector
+func (cfb *cfbFile) readDirectory(sector uint32) ([]cfbEntry, error) {
+	entries := []cfbEntry{}
+	// We cannot visit more sectors than the file contains, which
+	// protects us against loops
+	for visited := 0; sector != cfbEndOfChain; visited++ {
+		if visited > len(cfb.fat) || int(sector) >= len(cfb.fat) {
+			return nil, errMalformed
+		}
+		data, err := cfb.readSector(sector)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i+128 <= len(data); i += 128 {
+			raw := data[i : i+128]
+			// 0 means the entry is not used
+			if raw[0x42] == 0 {
+				continue
+			}
+			nameLen := int(binary.LittleEndian.Uint16(raw[0x40:]))
+			if nameLen < 2 || nameLen > 64 || nameLen%2 != 0 {
+				return nil, errMalformed
+			}
+			// The length includes the null terminator
+			name := make([]uint16, nameLen/2-1)
+			for j := range name {
+				name[j] = binary.LittleEndian.Uint16(raw[j*2:])
+			}
+			entries = append(entries, cfbEntry{
+				name:        string(utf16.Decode(name)),
+				startSector: binary.LittleEndian.Uint32(raw[0x74:]),
+				size:        binary.LittleEndian.Uint64(raw[0x78:]),
+			})
+		}
+		sector = cfb.fat[sector]
+	}
+	return entries, nil
+}
+
+// isWordEncrypted checks the fEncrypted flag of the header of the
+// WordDocument stream
+func (cfb *cfbFile) isWordEncrypted(entry cfbEntry) bool {
+	// Streams smaller than 4096 bytes are stored in the mini stream, but
+	// the WordDocument stream is always bigger
+	if entry.size < 4096 {
+		return false
+	}
+	data, err := cfb.readSector(entry.startSector)
+	if err != nil {
+		return false
+	}
+	return binary.LittleEndian.Uint16(data[0x0A:])&0x0100 != 0
+}
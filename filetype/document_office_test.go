@@ -0,0 +1,349 @@
+package filetype_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/Nivl/go-types/filetype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ooxmlContentTypes returns a [Content_Types].xml file declaring the
+// provided main part
+func ooxmlContentTypes(partName, contentType, extra string) archiveFile {
+	return archiveFile{"[Content_Types].xml", []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+	<Default Extension="xml" ContentType="application/xml"/>
+	<Override PartName="` + partName + `" ContentType="` + contentType + `"/>` + extra + `
+</Types>`)}
+}
+
+// docxFile returns a Word document
+func docxFile(t *testing.T, files ...archiveFile) []byte {
+	files = append([]archiveFile{
+		ooxmlContentTypes("/word/document.xml", "application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml", ""),
+		{"word/document.xml", []byte("<document/>")},
+	}, files...)
+	return zipFile(t, files...)
+}
+
+// odfFile returns an OpenDocument file of the provided type
+func odfFile(t *testing.T, mimeType, manifest string, files ...archiveFile) []byte {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	// The mimetype file must be the first one and must not be compressed
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	require.NoError(t, err, "CreateHeader() should have succeed")
+	_, err = w.Write([]byte(mimeType))
+	require.NoError(t, err, "Write() should have succeed")
+
+	files = append([]archiveFile{
+		{"content.xml", []byte("<office:document-content/>")},
+		{"META-INF/manifest.xml", []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0">
+	<manifest:file-entry manifest:full-path="/" manifest:media-type="` + mimeType + `"/>` + manifest + `
+</manifest:manifest>`)},
+	}, files...)
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		require.NoError(t, err, "Create() should have succeed")
+		_, err = w.Write(f.content)
+		require.NoError(t, err, "Write() should have succeed")
+	}
+	require.NoError(t, zw.Close(), "Close() should have succeed")
+	return buf.Bytes()
+}
+
+// cfbStream represents a stream of a Compound File Binary file
+type cfbStream struct {
+	name string
+	data []byte
+}
+
+// cfbFile returns a Compound File Binary file (OLE) using 512 bytes
+// sectors, containing the provided streams.
+// Sector 0 contains the FAT, sector 1 the directory, and the streams are
+// stored after
+func cfbFile(streams ...cfbStream) []byte {
+	const sectorSize = 512
+	const endOfChain, freeSector, fatSector = 0xFFFFFFFE, 0xFFFFFFFF, 0xFFFFFFFD
+	le := binary.LittleEndian
+
+	header := make([]byte, sectorSize)
+	copy(header, "\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1")
+	le.PutUint16(header[0x18:], 0x3E)
+	le.PutUint16(header[0x1A:], 3)
+	le.PutUint16(header[0x1C:], 0xFFFE)
+	le.PutUint16(header[0x1E:], 9)
+	le.PutUint16(header[0x20:], 6)
+	le.PutUint32(header[0x2C:], 1)
+	le.PutUint32(header[0x30:], 1)
+	le.PutUint32(header[0x38:], 4096)
+	le.PutUint32(header[0x3C:], endOfChain)
+	le.PutUint32(header[0x44:], endOfChain)
+	for i := 0x4C; i < sectorSize; i += 4 {
+		le.PutUint32(header[i:], freeSector)
+	}
+	le.PutUint32(header[0x4C:], 0)
+
+	fat := make([]byte, sectorSize)
+	for i := 0; i < sectorSize; i += 4 {
+		le.PutUint32(fat[i:], freeSector)
+	}
+	le.PutUint32(fat[0:], fatSector)
+	le.PutUint32(fat[4:], endOfChain)
+
+	dir := make([]byte, sectorSize)
+	data := []byte{}
+	entries := append([]cfbStream{{name: "Root Entry"}}, streams...)
+	for i, stream := range entries {
+		entry := dir[i*128 : (i+1)*128]
+		name := utf16.Encode([]rune(stream.name))
+		for j, c := range name {
+			le.PutUint16(entry[j*2:], c)
+		}
+		le.PutUint16(entry[0x40:], uint16(len(name)*2+2))
+		entry[0x42] = 2
+		if i == 0 {
+			entry[0x42] = 5
+		}
+		le.PutUint32(entry[0x74:], endOfChain)
+		if len(stream.data) == 0 {
+			continue
+		}
+
+		// Each stream is stored in a chain of sectors starting after the
+		// directory
+		start := uint32(2 + len(data)/sectorSize)
+		numSectors := uint32((len(stream.data) + sectorSize - 1) / sectorSize)
+		for s := start; s < start+numSectors-1; s++ {
+			le.PutUint32(fat[s*4:], s+1)
+		}
+		le.PutUint32(fat[(start+numSectors-1)*4:], endOfChain)
+		le.PutUint32(entry[0x74:], start)
+		le.PutUint64(entry[0x78:], uint64(len(stream.data)))
+		data = append(data, stream.data...)
+		data = append(data, make([]byte, int(numSectors)*sectorSize-len(stream.data))...)
+	}
+
+	file := append(header, fat...)
+	file = append(file, dir...)
+	return append(file, data...)
+}
+
+// wordDocumentStream returns the WordDocument stream of a .doc file
+func wordDocumentStream(encrypted bool) cfbStream {
+	data := make([]byte, 4096)
+	binary.LittleEndian.PutUint16(data, 0xA5EC)
+	if encrypted {
+		binary.LittleEndian.PutUint16(data[0x0A:], 0x0100)
+	}
+	return cfbStream{"WordDocument", data}
+}
+
+func TestInspectDocumentOffice(t *testing.T) {
+	testCases := []struct {
+		description string
+		content     []byte
+		expected    *filetype.DocumentInfo
+	}{
+		{
+			"docx",
+			docxFile(t),
+			&filetype.DocumentInfo{MimeType: "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		},
+		{
+			"docx with a vba project",
+			docxFile(t, archiveFile{"word/vbaProject.bin", []byte("vba")}),
+			&filetype.DocumentInfo{MimeType: "application/vnd.openxmlformats-officedocument.wordprocessingml.document", MacroEnabled: true},
+		},
+		{
+			"docm",
+			zipFile(t,
+				ooxmlContentTypes("/word/document.xml", "application/vnd.ms-word.document.macroEnabled.main+xml", ""),
+				archiveFile{"word/document.xml", []byte("<document/>")}),
+			&filetype.DocumentInfo{MimeType: "application/vnd.ms-word.document.macroenabled.12", MacroEnabled: true},
+		},
+		{
+			"xlsx",
+			zipFile(t,
+				ooxmlContentTypes("/xl/workbook.xml", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml", ""),
+				archiveFile{"xl/workbook.xml", []byte("<workbook/>")}),
+			&filetype.DocumentInfo{MimeType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+		},
+		{
+			"xlsx with a vba content type",
+			zipFile(t,
+				ooxmlContentTypes("/xl/workbook.xml", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml",
+					`<Override PartName="/xl/macros.bin" ContentType="application/vnd.ms-office.vbaProject"/>`),
+				archiveFile{"xl/workbook.xml", []byte("<workbook/>")}),
+			&filetype.DocumentInfo{MimeType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", MacroEnabled: true},
+		},
+		{
+			"odt",
+			odfFile(t, "application/vnd.oasis.opendocument.text", ""),
+			&filetype.DocumentInfo{MimeType: "application/vnd.oasis.opendocument.text"},
+		},
+		{
+			"ods with a basic macro",
+			odfFile(t, "application/vnd.oasis.opendocument.spreadsheet", "",
+				archiveFile{"Basic/Standard/Module1.xml", []byte("<script:module/>")},
+				archiveFile{"Basic/Standard/script-lb.xml", []byte("<library:library/>")}),
+			&filetype.DocumentInfo{MimeType: "application/vnd.oasis.opendocument.spreadsheet", MacroEnabled: true},
+		},
+		{
+			"encrypted odt",
+			odfFile(t, "application/vnd.oasis.opendocument.text",
+				`<manifest:file-entry manifest:full-path="content.xml" manifest:media-type="text/xml">
+		<manifest:encryption-data manifest:checksum-type="SHA1"/>
+	</manifest:file-entry>`),
+			&filetype.DocumentInfo{MimeType: "application/vnd.oasis.opendocument.text", Encrypted: true},
+		},
+		{
+			"doc",
+			cfbFile(wordDocumentStream(false), cfbStream{name: "1Table"}),
+			&filetype.DocumentInfo{MimeType: "application/msword"},
+		},
+		{
+			"encrypted doc with macros",
+			cfbFile(wordDocumentStream(true), cfbStream{name: "Macros"}),
+			&filetype.DocumentInfo{MimeType: "application/msword", Encrypted: true, MacroEnabled: true},
+		},
+		{
+			"xls",
+			cfbFile(cfbStream{"Workbook", []byte("workbook")}),
+			&filetype.DocumentInfo{MimeType: "application/vnd.ms-excel"},
+		},
+		{
+			"encrypted docx",
+			cfbFile(cfbStream{"EncryptionInfo", []byte("info")}, cfbStream{"EncryptedPackage", []byte("package")}),
+			&filetype.DocumentInfo{MimeType: "application/x-ole-storage", Encrypted: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			info, err := filetype.InspectDocument(bytes.NewReader(tc.content))
+			require.NoError(t, err, "InspectDocument() should have succeed")
+			assert.Equal(t, tc.expected, info)
+		})
+	}
+}
+
+func TestInspectDocumentInvalidOffice(t *testing.T) {
+	cfbTruncatedFAT := cfbFile(wordDocumentStream(false))
+	binary.LittleEndian.PutUint32(cfbTruncatedFAT[0x2C:], 2)
+	binary.LittleEndian.PutUint32(cfbTruncatedFAT[0x50:], 500)
+
+	cfbDirectoryLoop := cfbFile(wordDocumentStream(false))
+	binary.LittleEndian.PutUint32(cfbDirectoryLoop[512+4:], 1)
+
+	testCases := []struct {
+		description string
+		content     []byte
+	}{
+		{
+			"docx without main part",
+			zipFile(t, ooxmlContentTypes("/word/document.xml", "application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml", "")),
+		},
+		{
+			"docx with invalid content types",
+			zipFile(t, archiveFile{"[Content_Types].xml", []byte("<Types><Override")}),
+		},
+		{
+			"odt without content",
+			zipFile(t, archiveFile{"mimetype", []byte("application/vnd.oasis.opendocument.text")}),
+		},
+		{
+			"odt without manifest",
+			zipFile(t,
+				archiveFile{"mimetype", []byte("application/vnd.oasis.opendocument.text")},
+				archiveFile{"content.xml", []byte("<office:document-content/>")}),
+		},
+		{"cfb with a missing FAT sector", cfbTruncatedFAT},
+		{"cfb with a directory loop", cfbDirectoryLoop},
+		{"truncated cfb", cfbFile()[:600]},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			info, err := filetype.InspectDocument(bytes.NewReader(tc.content))
+			require.Error(t, err, "InspectDocument() should have failed")
+			assert.Equal(t, filetype.ErrMsgInvalidDocument, err.Error())
+			assert.Nil(t, info)
+		})
+	}
+}
+
+func TestInspectDocumentUnsupported(t *testing.T) {
+	testCases := []struct {
+		description string
+		content     []byte
+	}{
+		{"png", readFixture(t, "black_pixel.png")},
+		{"zip", zipFile(t, archiveFile{"a.txt", []byte("a")})},
+		{"nuget package", zipFile(t, ooxmlContentTypes("/package.nuspec", "application/octet", ""))},
+		{"epub", zipFile(t, archiveFile{"mimetype", []byte("application/epub+zip")})},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			info, err := filetype.InspectDocument(bytes.NewReader(tc.content))
+			require.Error(t, err, "InspectDocument() should have failed")
+			assert.Equal(t, filetype.ErrMsgUnsupportedDocumentFormat, err.Error())
+			assert.Nil(t, info)
+		})
+	}
+}
+
+func TestIsOfficeDocument(t *testing.T) {
+	docx := docxFile(t)
+	xlsx := zipFile(t,
+		ooxmlContentTypes("/xl/workbook.xml", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml", ""),
+		archiveFile{"xl/workbook.xml", []byte("<workbook/>")})
+	odt := odfFile(t, "application/vnd.oasis.opendocument.text", "")
+	ods := odfFile(t, "application/vnd.oasis.opendocument.spreadsheet", "")
+	zipped := zipFile(t, archiveFile{"a.txt", []byte("a")})
+
+	testCases := []struct {
+		description string
+		validator   filetype.FileValidator
+		content     []byte
+		expected    bool
+	}{
+		{"docx is a docx", filetype.IsDOCX, docx, true},
+		{"xlsx is not a docx", filetype.IsDOCX, xlsx, false},
+		{"zip is not a docx", filetype.IsDOCX, zipped, false},
+		{"truncated docx is not a docx", filetype.IsDOCX, docx[:len(docx)-10], false},
+		{"xlsx is a xlsx", filetype.IsXLSX, xlsx, true},
+		{"docx is not a xlsx", filetype.IsXLSX, docx, false},
+		{"odt is an odt", filetype.IsODT, odt, true},
+		{"ods is not an odt", filetype.IsODT, ods, false},
+		{"docx is not an odt", filetype.IsODT, docx, false},
+		{"pdf is not an odt", filetype.IsODT, readFixture(t, "black_pixel.pdf"), false},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			valid, err := tc.validator(bytes.NewReader(tc.content))
+			require.NoError(t, err, "the validator should have succeed")
+			assert.Equal(t, tc.expected, valid)
+		})
+	}
+}
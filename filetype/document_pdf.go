@@ -0,0 +1,354 @@
+package filetype
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+)
+
+// pdfMaxXrefSections is the maximum number of cross-reference sections
+// (incremental updates) we follow before considering the file malformed
+const pdfMaxXrefSections = 64
+
+// pdfMaxXrefSubsections is the maximum number of subsections of a
+// cross-reference table we read before considering the file malformed
+const pdfMaxXrefSubsections = 1024
+
+// pdfXrefEntrySize is the size of an entry of a cross-reference table
+const pdfXrefEntrySize = 20
+
+// pdfObjectWindow is the amount of data read to parse an object
+// dictionary or a trailer
+const pdfObjectWindow = 4096
+
+var (
+	pdfVersionRegexp = regexp.MustCompile(`^%PDF-(\d\.\d)`)
+	pdfRootRegexp    = regexp.MustCompile(`/Root\s+(\d+)\s+\d+\s+R`)
+	pdfPrevRegexp    = regexp.MustCompile(`/Prev\s+(\d+)`)
+	pdfPagesRegexp   = regexp.MustCompile(`/Pages\s+(\d+)\s+\d+\s+R`)
+	pdfCountRegexp   = regexp.MustCompile(`/Count\s+(\d+)`)
+	pdfEncryptRegexp = regexp.MustCompile(`/Encrypt[\s/<\d]`)
+	pdfObjRegexp     = regexp.MustCompile(`^\s*(\d+)\s+(\d+)\s+obj\b`)
+)
+
+// pdfFile gives access to the objects of a PDF file
+type pdfFile struct {
+	s *section
+	// base is the offset of the %PDF header. Some files have garbage
+	// before the header, and all the offsets are relative to the header
+	base int64
+	// xrefOffsets contains the offset of all the cross-reference tables,
+	// from the newest to the oldest. Empty if the file uses
+	// cross-reference streams
+	xrefOffsets []int64
+}
+
+// parsePDF parses the structure of a PDF file
+func parsePDF(s *section) (*DocumentInfo, error) {
+	// The header can be anywhere in the first 1024 bytes
+	head, err := s.read(0, minInt64(1024, s.size))
+	if err != nil {
+		return nil, err
+	}
+	base := bytes.Index(head, []byte("%PDF-"))
+	if base == -1 {
+		return nil, errMalformed
+	}
+	version := pdfVersionRegexp.FindSubmatch(head[base:])
+	if version == nil {
+		return nil, errMalformed
+	}
+	pdf := &pdfFile{s: s, base: int64(base)}
+	info := &DocumentInfo{MimeType: "application/pdf", Version: string(version[1])}
+
+	xrefOffset, err := pdf.startXref()
+	if err != nil {
+		return nil, err
+	}
+	trailer, err := pdf.trailer(xrefOffset)
+	if err != nil {
+		return nil, err
+	}
+	root := pdfRootRegexp.FindSubmatch(trailer)
+	if root == nil {
+		return nil, errMalformed
+	}
+	info.Encrypted = pdfEncryptRegexp.Match(trailer)
+
+	// The page count is only available if we can find the objects without
+	// decompressing the cross-reference streams
+	if len(pdf.xrefOffsets) > 0 {
+		info.PageCount = pdf.pageCount(atoi64(root[1]))
+	}
+	return info, nil
+}
+
+// startXref returns the offset of the last cross-reference section, as
+// written at the end of the file
+func (pdf *pdfFile) startXref() (int64, error) {
+	tailSize := minInt64(1024, pdf.s.size)
+	tail, err := pdf.s.read(pdf.s.size-tailSize, tailSize)
+	if err != nil {
+		return 0, err
+	}
+	i := bytes.LastIndex(tail, []byte("startxref"))
+	if i == -1 || !bytes.Contains(tail[i:], []byte("%%EOF")) {
+		return 0, errMalformed
+	}
+	fields := bytes.Fields(tail[i+len("startxref"):])
+	if len(fields) == 0 {
+		return 0, errMalformed
+	}
+	offset, err := strconv.ParseInt(string(fields[0]), 10, 64)
+	if err != nil {
+		return 0, errMalformed
+	}
+	return offset, nil
+}
+
+// trailer returns the trailer dictionary of the cross-reference section
+// located at the provided offset, and records the offsets of all the
+// sections of the file
+func (pdf *pdfFile) trailer(xrefOffset int64) ([]byte, error) {
+	data, err := pdf.window(xrefOffset)
+	if err != nil {
+		return nil, err
+	}
+
+	// PDF 1.5+ files can use cross-reference streams, in which case
+	// the trailer is the dictionary of the stream
+	if !bytes.HasPrefix(data, []byte("xref")) {
+		if pdfObjRegexp.Find(data) == nil {
+			return nil, errMalformed
+		}
+		return pdfDict(data)
+	}
+
+	var trailer []byte
+	for i := 0; i < pdfMaxXrefSections; i++ {
+		pdf.xrefOffsets = append(pdf.xrefOffsets, xrefOffset)
+		end, err := pdf.skipXrefTable(xrefOffset)
+		if err != nil {
+			return nil, err
+		}
+		data, err := pdf.window(end)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.HasPrefix(data, []byte("trailer")) {
+			return nil, errMalformed
+		}
+		dict, err := pdfDict(data)
+		if err != nil {
+			return nil, err
+		}
+		// We return the newest trailer, but we still need to find all the
+		// previous sections to be able to look for objects
+		if trailer == nil {
+			trailer = dict
+		}
+
+		prev := pdfPrevRegexp.FindSubmatch(dict)
+		if prev == nil {
+			return trailer, nil
+		}
+		xrefOffset = atoi64(prev[1])
+		if data, err = pdf.window(xrefOffset); err != nil || !bytes.HasPrefix(data, []byte("xref")) {
+			// The previous section is a stream (hybrid files) or is
+			// invalid. We only use the sections we found
+			return trailer, nil
+		}
+	}
+	return nil, errMalformed
+}
+
+// skipXrefTable returns the offset of the data following the
+// cross-reference table located at the provided offset
+func (pdf *pdfFile) skipXrefTable(offset int64) (int64, error) {
+	offset += int64(len("xref"))
+	for i := 0; i < pdfMaxXrefSubsections; i++ {
+		data, err := pdf.window(offset)
+		if err != nil {
+			return 0, err
+		}
+		_, count, headerLen, ok := pdfXrefSubsection(data)
+		if !ok {
+			return offset + int64(len(data)-len(bytes.TrimLeft(data, "\r\n\t "))), nil
+		}
+		next, err := pdf.skipXrefEntries(offset+int64(headerLen), count)
+		if err != nil {
+			return 0, err
+		}
+		if next <= offset {
+			return 0, errMalformed
+		}
+		offset = next
+	}
+	return 0, errMalformed
+}
+
+// skipXrefEntries returns the offset of the data following count entries
+// of a cross-reference table, starting at the provided offset.
+// errMalformed is returned if the entries don't fit in the file, which
+// also prevents the offset from overflowing
+func (pdf *pdfFile) skipXrefEntries(offset, count int64) (int64, error) {
+	remaining := pdf.s.size - pdf.base - offset
+	if remaining < 0 || count > remaining/pdfXrefEntrySize {
+		return 0, errMalformed
+	}
+	return offset + count*pdfXrefEntrySize, nil
+}
+
+// lookup returns the offset of an object using the cross-reference
+// tables. -1 is returned if the object cannot be found
+func (pdf *pdfFile) lookup(num int64) int64 {
+	for _, xrefOffset := range pdf.xrefOffsets {
+		offset := xrefOffset + int64(len("xref"))
+		for i := 0; i < pdfMaxXrefSubsections; i++ {
+			data, err := pdf.window(offset)
+			if err != nil {
+				break
+			}
+			start, count, headerLen, ok := pdfXrefSubsection(data)
+			if !ok {
+				break
+			}
+			entries := offset + int64(headerLen)
+			next, err := pdf.skipXrefEntries(entries, count)
+			if err != nil || next <= offset {
+				break
+			}
+			if num >= start && num-start < count {
+				entry, err := pdf.s.read(pdf.base+entries+(num-start)*pdfXrefEntrySize, 18)
+				if err != nil || entry[17] != 'n' {
+					break
+				}
+				objOffset, err := strconv.ParseInt(string(entry[:10]), 10, 64)
+				if err != nil {
+					break
+				}
+				return objOffset
+			}
+			offset = next
+		}
+	}
+	return -1
+}
+
+// object returns the dictionary of an object
+func (pdf *pdfFile) object(num int64) ([]byte, bool) {
+	offset := pdf.lookup(num)
+	if offset < 0 {
+		return nil, false
+	}
+	data, err := pdf.window(offset)
+	if err != nil {
+		return nil, false
+	}
+	header := pdfObjRegexp.FindSubmatch(data)
+	if header == nil || atoi64(header[1]) != num {
+		return nil, false
+	}
+	dict, err := pdfDict(data)
+	if err != nil {
+		return nil, false
+	}
+	return dict, true
+}
+
+// pageCount returns the number of pages of the document, using the page
+// tree of its catalog. 0 is returned if the pages cannot be found
+func (pdf *pdfFile) pageCount(catalog int64) int {
+	dict, ok := pdf.object(catalog)
+	if !ok {
+		return 0
+	}
+	pages := pdfPagesRegexp.FindSubmatch(dict)
+	if pages == nil {
+		return 0
+	}
+	if dict, ok = pdf.object(atoi64(pages[1])); !ok {
+		return 0
+	}
+	count := pdfCountRegexp.FindSubmatch(dict)
+	if count == nil {
+		return 0
+	}
+	n, err := strconv.Atoi(string(count[1]))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// window returns the data located at the provided offset (relative to
+// the header), up to pdfObjectWindow bytes
+func (pdf *pdfFile) window(offset int64) ([]byte, error) {
+	offset += pdf.base
+	if offset < 0 || offset >= pdf.s.size {
+		return nil, errMalformed
+	}
+	return pdf.s.read(offset, minInt64(pdfObjectWindow, pdf.s.size-offset))
+}
+
+// pdfXrefSubsection parses the header of a subsection of a
+// cross-reference table ("0 17\n"), that may be preceded by spaces
+func pdfXrefSubsection(data []byte) (start, count int64, headerLen int, ok bool) {
+	trimmed := bytes.TrimLeft(data, "\r\n\t ")
+	eol := bytes.IndexAny(trimmed, "\r\n")
+	if eol == -1 {
+		return 0, 0, 0, false
+	}
+	fields := bytes.Fields(trimmed[:eol])
+	if len(fields) != 2 {
+		return 0, 0, 0, false
+	}
+	start, err := strconv.ParseInt(string(fields[0]), 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, 0, false
+	}
+	count, err = strconv.ParseInt(string(fields[1]), 10, 64)
+	if err != nil || count < 0 {
+		return 0, 0, 0, false
+	}
+	// The entries start after the end of line, which can be \r\n
+	headerLen = len(data) - len(trimmed) + eol + 1
+	if eol+1 < len(trimmed) && trimmed[eol] == '\r' && trimmed[eol+1] == '\n' {
+		headerLen++
+	}
+	return start, count, headerLen, true
+}
+
+// pdfDict returns the first dictionary contained in data, including its
+// nested dictionaries
+func pdfDict(data []byte) ([]byte, error) {
+	start := bytes.Index(data, []byte("<<"))
+	if start == -1 {
+		return nil, errMalformed
+	}
+	depth := 0
+	for i := start; i < len(data)-1; i++ {
+		switch {
+		case data[i] == '<' && data[i+1] == '<':
+			depth++
+			i++
+		case data[i] == '>' && data[i+1] == '>':
+			depth--
+			i++
+			if depth == 0 {
+				return data[start : i+1], nil
+			}
+		}
+	}
+	return nil, errMalformed
+}
+
+// atoi64 converts a number matched by a regexp. -1 is returned if the
+// number is too big
+func atoi64(b []byte) int64 {
+	n, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
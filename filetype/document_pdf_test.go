@@ -0,0 +1,147 @@
+package filetype_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Nivl/go-types/filetype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pdfFile returns a PDF file containing the provided number of pages.
+// trailer is added to the trailer dictionary
+func pdfFile(pages int, trailer string) []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString("%PDF-1.4\n")
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [] /Count %d >>", pages),
+	}
+	offsets := make([]int, 0, len(objects))
+	for i, obj := range objects {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(buf, "xref\n0 %d\n0000000000 65535 f\r\n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(buf, "%010d 00000 n\r\n", offset)
+	}
+	fmt.Fprintf(buf, "trailer\n<< /Size %d /Root 1 0 R %s>>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, trailer, xref)
+	return buf.Bytes()
+}
+
+func TestIsPDF(t *testing.T) {
+	testFileValidator(t, filetype.IsPDF, "black_pixel.pdf")
+}
+
+func TestIsPDFGarbageBeforeHeader(t *testing.T) {
+	content := append([]byte("garbage\n"), pdfFile(1, "")...)
+	valid, err := filetype.IsPDF(bytes.NewReader(content))
+	require.NoError(t, err, "IsPDF() should have succeed")
+	assert.True(t, valid, "IsPDF() should have returned true")
+}
+
+func TestInspectDocumentPDF(t *testing.T) {
+	// xref stream, as written by PDF 1.5+ files
+	xrefStream := []byte("%PDF-1.5\n1 0 obj\n<< /Type /Catalog >>\nendobj\n" +
+		"2 0 obj\n<< /Type /XRef /Size 3 /Root 1 0 R /Encrypt 3 0 R >>\nstream\n\nendstream\nendobj\n" +
+		"startxref\n44\n%%EOF\n")
+
+	testCases := []struct {
+		description string
+		content     []byte
+		expected    *filetype.DocumentInfo
+	}{
+		{
+			"fixture",
+			readFixture(t, "black_pixel.pdf"),
+			&filetype.DocumentInfo{MimeType: "application/pdf", Version: "1.3", PageCount: 1},
+		},
+		{
+			"multiple pages",
+			pdfFile(12, ""),
+			&filetype.DocumentInfo{MimeType: "application/pdf", Version: "1.4", PageCount: 12},
+		},
+		{
+			"encrypted",
+			pdfFile(1, "/Encrypt << /Filter /Standard /V 2 >> "),
+			&filetype.DocumentInfo{MimeType: "application/pdf", Version: "1.4", PageCount: 1, Encrypted: true},
+		},
+		{
+			"xref stream",
+			xrefStream,
+			&filetype.DocumentInfo{MimeType: "application/pdf", Version: "1.5", Encrypted: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			info, err := filetype.InspectDocument(bytes.NewReader(tc.content))
+			require.NoError(t, err, "InspectDocument() should have succeed")
+			assert.Equal(t, tc.expected, info)
+		})
+	}
+}
+
+func TestInspectDocumentInvalidPDF(t *testing.T) {
+	valid := pdfFile(1, "")
+
+	testCases := []struct {
+		description string
+		content     []byte
+	}{
+		{"truncated", valid[:len(valid)-20]},
+		{"no startxref", bytes.Replace(valid, []byte("startxref"), []byte("startxxxx"), 1)},
+		{"startxref out of the file", bytes.Replace(valid, []byte("startxref\n"), []byte("startxref\n9999"), 1)},
+		{"startxref not pointing to a xref", bytes.Replace(valid, []byte("startxref\n"), []byte("startxref\n1"), 1)},
+		{"no root", bytes.Replace(valid, []byte("/Root"), []byte("/Ruut"), 1)},
+		{"unterminated trailer", bytes.Replace(valid, []byte(">>\nstartxref"), []byte("\nstartxref"), 1)},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			info, err := filetype.InspectDocument(bytes.NewReader(tc.content))
+			require.Error(t, err, "InspectDocument() should have failed")
+			assert.Equal(t, filetype.ErrMsgInvalidDocument, err.Error())
+			assert.Nil(t, info)
+
+			valid, err := filetype.IsPDF(bytes.NewReader(tc.content))
+			require.NoError(t, err, "IsPDF() should have succeed")
+			assert.False(t, valid, "IsPDF() should have returned false")
+		})
+	}
+}
+
+func TestIsPDFHugeXrefSubsection(t *testing.T) {
+	t.Parallel()
+
+	// The number of entries overflows once multiplied by the size of an
+	// entry, which used to make the parser loop forever
+	content := []byte("%PDF-1.4\nxref\n 0 3689348814741910322\ntrailer\n<< /Root 1 0 R >>\nstartxref\n9\n%%EOF\n")
+
+	done := make(chan struct{})
+	var valid bool
+	var err error
+	go func() {
+		defer close(done)
+		valid, err = filetype.IsPDF(bytes.NewReader(content))
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("IsPDF() should have returned")
+	}
+	require.NoError(t, err, "IsPDF() should have succeed")
+	assert.False(t, valid, "IsPDF() should have returned false")
+}
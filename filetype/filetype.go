@@ -3,31 +3,67 @@
 package filetype
 
 import (
-	"crypto/sha256"
-	"fmt"
+	"errors"
 	"io"
-	"net/http"
 )
 
+// ErrMsgEmptyFile represents the error message returned when trying to
+// get the type of an empty file
+var ErrMsgEmptyFile = "empty file"
+
+// errEmptyFile is the error returned when trying to get the type of an
+// empty file
+var errEmptyFile = errors.New(ErrMsgEmptyFile)
+
+// sniffLen is the amount of bytes needed by http.DetectContentType
+const sniffLen = 512
+
 // FileValidator represents a function that can validate a file type
 type FileValidator func(r io.ReadSeeker) (bool, error)
 
-// MimeType returns the mimetype of a file
-func MimeType(r io.ReadSeeker) (string, error) {
-	// DetectContentType needs the first 512 bytes
-	bytesNeeded := 512
-	buff := make([]byte, bytesNeeded)
-	n, err := r.Read(buff)
+// MimeType returns the mimetype of a file.
+// The reader will be put back to its original position, even if an error
+// occurred.
+func MimeType(r io.ReadSeeker) (mimeType string, err error) {
+	var initialPos int64
+
+	initialPos, err = r.Seek(0, io.SeekCurrent)
 	if err != nil {
 		return "", err
 	}
+	// revert the pointer back to its original position
+	defer func() {
+		_, seekErr := r.Seek(initialPos, io.SeekStart)
+		if err == nil && seekErr != nil {
+			mimeType = ""
+			err = seekErr
+		}
+	}()
 
-	// we seek back to where we were like we didn't do anything
-	if _, err := r.Seek(int64(-n), io.SeekCurrent); err != nil {
+	header, err := readHeader(r)
+	if err != nil {
 		return "", err
 	}
+	return detectContentType(header), nil
+}
 
-	return http.DetectContentType(buff), nil
+// readHeader reads the bytes needed to detect the type of a file.
+// Less bytes will be returned if the file is smaller than sniffLen, and an
+// error will be returned if the file is empty
+func readHeader(r io.Reader) ([]byte, error) {
+	buff := make([]byte, sniffLen)
+	// Read() is allowed to return less than what we asked for, even if there
+	// are more data available, so we rely on ReadFull() to do the looping
+	n, err := io.ReadFull(r, buff)
+	switch err {
+	case nil, io.ErrUnexpectedEOF:
+		// ErrUnexpectedEOF means we got a file smaller than sniffLen
+		return buff[:n], nil
+	case io.EOF:
+		return nil, errEmptyFile
+	default:
+		return nil, err
+	}
 }
 
 // SHA256Sum returns the SHA256 sum of a reader
@@ -46,13 +82,9 @@ func SHA256Sum(r io.ReadSeeker) (hash string, err error) {
 		}
 	}()
 
-	// copy the file to read it's content
-	h := sha256.New()
-	if _, err := io.Copy(h, r); err != nil {
+	digests, err := Hash(r, SHA256)
+	if err != nil {
 		return "", err
 	}
-
-	// cast the hash and return it
-	hash = fmt.Sprintf("%x", h.Sum(nil))
-	return hash, nil
+	return digests[SHA256].Hex(), nil
 }
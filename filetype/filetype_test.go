@@ -6,6 +6,7 @@ import (
 	"bytes"
 	"errors"
 	"io"
+	"io/ioutil"
 	"testing"
 
 	"os"
@@ -94,12 +95,84 @@ func TestMimeType(t *testing.T) {
 	}
 }
 
+func TestMimeTypeShortFiles(t *testing.T) {
+	testCases := []struct {
+		description string
+		content     string
+		expected    string
+	}{
+		{"one word", "hello", "text/plain; charset=utf-8"},
+		{"one letter", "a", "text/plain; charset=utf-8"},
+		{"gif header", "GIF89a", "image/gif"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			mime, err := filetype.MimeType(bytes.NewReader([]byte(tc.content)))
+			assert.NoError(t, err, "MimeType() should have succeed")
+			assert.Equal(t, tc.expected, mime, "invalid mimetype")
+		})
+	}
+}
+
+func TestMimeTypeEmptyFile(t *testing.T) {
+	mime, err := filetype.MimeType(bytes.NewReader([]byte{}))
+	require.Error(t, err, "MimeType() should have failed")
+	assert.Equal(t, filetype.ErrMsgEmptyFile, err.Error(), "invalid error")
+	assert.Empty(t, mime, "MimeType() should have not returned a value")
+}
+
+// shortReadSeeker is a io.ReadSeeker that never returns more than 10 bytes
+// per Read()
+type shortReadSeeker struct {
+	*bytes.Reader
+}
+
+func (r shortReadSeeker) Read(p []byte) (int, error) {
+	if len(p) > 10 {
+		p = p[:10]
+	}
+	return r.Reader.Read(p)
+}
+
+func TestMimeTypeShortReads(t *testing.T) {
+	content, err := ioutil.ReadFile(path.Join("fixtures", "black_pixel.png"))
+	require.NoError(t, err, "ReadFile() should have succeed")
+
+	// We move the cursor to make sure MimeType() puts it back where it
+	// was and not at the beginning of the file
+	offset := int64(4)
+	r := shortReadSeeker{bytes.NewReader(content)}
+	_, err = r.Seek(offset, io.SeekStart)
+	require.NoError(t, err, "Seek() should have succeed")
+
+	mime, err := filetype.MimeType(r)
+	assert.NoError(t, err, "MimeType() should have succeed")
+	// we skipped the first bytes of the PNG signature
+	assert.Equal(t, "application/octet-stream", mime, "invalid mimetype")
+
+	pos, err := r.Seek(0, io.SeekCurrent)
+	require.NoError(t, err, "Seek() should have succeed")
+	assert.Equal(t, offset, pos, "the reader should be at its original position")
+
+	_, err = r.Seek(0, io.SeekStart)
+	require.NoError(t, err, "Seek() should have succeed")
+	mime, err = filetype.MimeType(r)
+	assert.NoError(t, err, "MimeType() should have succeed")
+	assert.Equal(t, "image/png", mime, "invalid mimetype")
+}
+
 func TestMimeTypeReaderFail(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 
 	reader := NewMockReadSeeker(mockCtrl)
+	reader.EXPECT().Seek(int64(0), io.SeekCurrent).Return(int64(0), nil)
 	reader.EXPECT().Read(gomock.Any()).Return(0, errors.New("read failed"))
+	reader.EXPECT().Seek(int64(0), io.SeekStart).Return(int64(0), nil)
 
 	mime, err := filetype.MimeType(reader)
 	assert.Error(t, err, "MimeType() should have failed")
@@ -111,8 +184,21 @@ func TestMimeTypeSeekFail(t *testing.T) {
 	defer mockCtrl.Finish()
 
 	reader := NewMockReadSeeker(mockCtrl)
-	reader.EXPECT().Read(gomock.Any()).Return(50, nil)
-	reader.EXPECT().Seek(int64(-50), io.SeekCurrent).Return(int64(0), errors.New("seek failed"))
+	reader.EXPECT().Seek(int64(0), io.SeekCurrent).Return(int64(0), errors.New("seek failed"))
+
+	mime, err := filetype.MimeType(reader)
+	assert.Error(t, err, "MimeType() should have failed")
+	assert.Empty(t, mime, "MimeType() should have not returned a value")
+}
+
+func TestMimeTypeSeekBackFail(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	reader := NewMockReadSeeker(mockCtrl)
+	reader.EXPECT().Seek(int64(0), io.SeekCurrent).Return(int64(0), nil)
+	reader.EXPECT().Read(gomock.Any()).Return(50, io.EOF)
+	reader.EXPECT().Seek(int64(0), io.SeekStart).Return(int64(0), errors.New("seek failed"))
 
 	mime, err := filetype.MimeType(reader)
 	assert.Error(t, err, "MimeType() should have failed")
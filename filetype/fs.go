@@ -0,0 +1,256 @@
+package filetype
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"runtime"
+	"sync"
+)
+
+// FSWalkOptions contains the options used by WalkFSContext()
+type FSWalkOptions struct {
+	// Workers is the number of files processed concurrently.
+	// Defaults to the number of CPUs
+	Workers int `json:"workers,omitempty" yaml:"workers,omitempty"`
+
+	// Limits contains the limits the images must respect.
+	// Can be nil
+	Limits *ValidateOptions `json:"limits,omitempty" yaml:"limits,omitempty"`
+}
+
+// FSEntry contains the information gathered by WalkFS() for a file
+type FSEntry struct {
+	// Path is the path of the file in the walked file system
+	Path string `json:"path"`
+
+	// Size is the size of the file, in bytes
+	Size int64 `json:"size"`
+
+	// MimeType is the detected type of the file. Empty if the file is
+	// empty
+	MimeType string `json:"mime_type"`
+
+	// SHA256 is the SHA256 sum of the file, encoded in hexadecimal
+	SHA256 string `json:"sha256"`
+
+	// Validation contains the result of the validation of the file.
+	// nil if there are no validators for the type of the file. Images,
+	// documents (see InspectDocument()) and media (see InspectMedia())
+	// are validated
+	Validation *ValidationResult `json:"validation,omitempty"`
+
+	// Err is the error that prevented the file (or the directory) from
+	// being processed, if any
+	Err error `json:"-"`
+}
+
+// FSWalkFunc represents the function called for each file of a file
+// system.
+// Returning an error stops the walk, and the error is returned by
+// WalkFS()
+type FSWalkFunc func(entry *FSEntry) error
+
+// WalkFS sniffs, hashes and validates all the regular files of a file
+// system, starting at root, and calls fn for each of them.
+// The files are processed concurrently, using one worker per CPU, but
+// fn is never called concurrently. The order of the files is not
+// guaranteed.
+// The files and directories that cannot be read are passed to fn with
+// their error, and are not considered a failure of the walk.
+func WalkFS(fsys fs.FS, root string, fn FSWalkFunc) error {
+	return WalkFSContext(context.Background(), fsys, root, nil, fn)
+}
+
+// WalkFSContext works like WalkFS() but the walk stops when the context
+// is done, in which case the error of the context is returned.
+// opts can be nil.
+func WalkFSContext(ctx context.Context, fsys fs.FS, root string, opts *FSWalkOptions, fn FSWalkFunc) error {
+	if opts == nil {
+		opts = &FSWalkOptions{}
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	walkCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	paths := make(chan string)
+	entries := make(chan *FSEntry)
+	var wg sync.WaitGroup
+
+	// The walker sends the files to the workers, and reports the errors
+	// directly
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(paths)
+		// The only error that can be returned is the one of the context,
+		// which is returned by WalkFSContext()
+		_ = fs.WalkDir(fsys, root, func(path string, d fs.DirEntry, err error) error {
+			switch {
+			case err != nil:
+				return sendFSEntry(walkCtx, entries, &FSEntry{Path: path, Err: err})
+			case !d.Type().IsRegular():
+				return nil
+			}
+			select {
+			case paths <- path:
+				return nil
+			case <-walkCtx.Done():
+				return walkCtx.Err()
+			}
+		})
+	}()
+
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				if walkCtx.Err() != nil {
+					return
+				}
+				if sendFSEntry(walkCtx, entries, inspectFSFile(fsys, path, opts.Limits)) != nil {
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(entries)
+	}()
+
+	var fnErr error
+	for entry := range entries {
+		// We keep reading the channel until all the goroutines are done
+		if fnErr != nil {
+			continue
+		}
+		if fnErr = fn(entry); fnErr != nil {
+			cancel()
+		}
+	}
+	if fnErr != nil {
+		return fnErr
+	}
+	return ctx.Err()
+}
+
+// WalkFSResults works like WalkFSContext() but sends the files to the
+// returned channel instead of calling a function. The channel is closed
+// once all the files have been processed, or when the context is done.
+// Use ctx.Err() to know if the walk completed.
+func WalkFSResults(ctx context.Context, fsys fs.FS, root string, opts *FSWalkOptions) <-chan *FSEntry {
+	results := make(chan *FSEntry)
+	go func() {
+		defer close(results)
+		// The only error that can be returned is the one of the context
+		_ = WalkFSContext(ctx, fsys, root, opts, func(entry *FSEntry) error {
+			return sendFSEntry(ctx, results, entry)
+		})
+	}()
+	return results
+}
+
+// sendFSEntry sends an entry to a channel, unless the context is done
+func sendFSEntry(ctx context.Context, entries chan<- *FSEntry, entry *FSEntry) error {
+	select {
+	case entries <- entry:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// inspectFSFile sniffs, hashes and validates a file of a file system.
+// The files that are not seekable are inspected using Inspect()
+func inspectFSFile(fsys fs.FS, path string, limits *ValidateOptions) (entry *FSEntry) {
+	entry = &FSEntry{Path: path}
+	f, err := fsys.Open(path)
+	if err != nil {
+		entry.Err = err
+		return entry
+	}
+	defer func() {
+		if closeErr := f.Close(); entry.Err == nil && closeErr != nil {
+			entry.Err = closeErr
+		}
+	}()
+
+	r, ok := f.(io.ReadSeeker)
+	if !ok {
+		report, err := Inspect(f, &InspectOptions{Validate: limits})
+		if err != nil {
+			entry.Err = err
+			return entry
+		}
+		entry.Size = report.Size
+		entry.MimeType = report.MimeType
+		entry.SHA256 = report.Digests[SHA256].Hex()
+		entry.Validation = report.Validation
+		return entry
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		entry.Err = err
+		return entry
+	}
+	entry.Size = info.Size()
+	if entry.Size == 0 {
+		// The hash of an empty file is still useful to find duplicates
+		entry.SHA256, entry.Err = SHA256Sum(r)
+		return entry
+	}
+	if entry.MimeType, err = MimeType(r); err != nil {
+		entry.Err = err
+		return entry
+	}
+	if entry.SHA256, err = SHA256Sum(r); err != nil {
+		entry.Err = err
+		return entry
+	}
+	entry.Validation, entry.Err = checkFile(r, entry.MimeType, limits)
+	return entry
+}
+
+// checkFile validates a file using the validator matching its type.
+// nil is returned if there are no validators for the type
+func checkFile(r io.ReadSeeker, mimeType string, limits *ValidateOptions) (*ValidationResult, error) {
+	if _, isImage := LookupImageFormat(mimeType); isImage {
+		return CheckImageWithOptions(r, limits)
+	}
+
+	var parse func(s *section) error
+	if parseDocument, found := documentParsers[baseMediaType(mimeType)]; found {
+		parse = func(s *section) error {
+			_, err := parseDocument(s)
+			return err
+		}
+	}
+	if parseMedia, found := mediaParsers[baseMediaType(mimeType)]; found {
+		parse = func(s *section) error {
+			_, err := parseMedia(s)
+			return err
+		}
+	}
+	if parse == nil {
+		return nil, nil
+	}
+
+	res, err := checkStructure(r, parse)
+	if err == errUnsupportedFormat {
+		// ex. a zip file that is not a document
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	res.MimeType = mimeType
+	return res, nil
+}
@@ -0,0 +1,244 @@
+package filetype_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Nivl/go-types/filetype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unseekableFS is a file system whose regular files don't implement
+// io.Seeker
+type unseekableFS struct {
+	fs.FS
+}
+
+// Open implements the fs.FS interface
+// https://golang.org/pkg/io/fs/#FS
+func (fsys unseekableFS) Open(name string) (fs.File, error) {
+	f, err := fsys.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	// The directories need to implement fs.ReadDirFile to be walked
+	if _, isDir := f.(fs.ReadDirFile); isDir {
+		return f, nil
+	}
+	return struct{ fs.File }{f}, nil
+}
+
+// sha256Hex returns the SHA256 sum of data, encoded in hexadecimal
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// walkFSEntries walks a file system and returns the entries sorted by
+// path
+func walkFSEntries(t *testing.T, fsys fs.FS, root string) []*filetype.FSEntry {
+	entries := []*filetype.FSEntry{}
+	err := filetype.WalkFS(fsys, root, func(entry *filetype.FSEntry) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	require.NoError(t, err, "WalkFS() should have succeed")
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries
+}
+
+func TestWalkFS(t *testing.T) {
+	png := readFixture(t, "black_pixel.png")
+	pdf := readFixture(t, "black_pixel.pdf")
+	flac := flacFile(44100, 2, 110250)
+	fsys := fstest.MapFS{
+		"images/black_pixel.png": {Data: png},
+		"images/truncated.png":   {Data: png[:len(png)-10]},
+		"docs/black_pixel.pdf":   {Data: pdf},
+		"audio/voice_note.flac":  {Data: flac},
+		"notes.txt":              {Data: []byte("hello world")},
+		"empty":                  {Data: []byte{}},
+		"link":                   {Data: []byte("notes.txt"), Mode: fs.ModeSymlink},
+	}
+
+	testCases := []struct {
+		description string
+		fsys        fs.FS
+	}{
+		{"seekable files", fsys},
+		{"unseekable files", unseekableFS{fsys}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			entries := walkFSEntries(t, tc.fsys, ".")
+			require.Len(t, entries, 6, "the directories and the links should have been skipped")
+			for _, entry := range entries {
+				assert.NoError(t, entry.Err, "%s should have been processed", entry.Path)
+			}
+
+			flacEntry := entries[0]
+			assert.Equal(t, "audio/voice_note.flac", flacEntry.Path)
+			assert.Equal(t, "audio/flac", flacEntry.MimeType)
+			assert.Equal(t, sha256Hex(flac), flacEntry.SHA256)
+			assert.Equal(t, int64(len(flac)), flacEntry.Size)
+
+			pdfEntry := entries[1]
+			assert.Equal(t, "docs/black_pixel.pdf", pdfEntry.Path)
+			assert.Equal(t, "application/pdf", pdfEntry.MimeType)
+
+			emptyEntry := entries[2]
+			assert.Equal(t, "empty", emptyEntry.Path)
+			assert.Equal(t, "", emptyEntry.MimeType)
+			assert.Equal(t, sha256Hex(nil), emptyEntry.SHA256)
+			assert.Nil(t, emptyEntry.Validation)
+
+			pngEntry := entries[3]
+			assert.Equal(t, "images/black_pixel.png", pngEntry.Path)
+			assert.Equal(t, "image/png", pngEntry.MimeType)
+			assert.Equal(t, sha256Hex(png), pngEntry.SHA256)
+			assert.Equal(t, int64(len(png)), pngEntry.Size)
+			require.NotNil(t, pngEntry.Validation, "the image should have been validated")
+			assert.True(t, pngEntry.Validation.Valid, "the image should be valid")
+
+			truncatedEntry := entries[4]
+			assert.Equal(t, "images/truncated.png", truncatedEntry.Path)
+			require.NotNil(t, truncatedEntry.Validation, "the image should have been validated")
+			assert.False(t, truncatedEntry.Validation.Valid, "the image should be invalid")
+			assert.Equal(t, filetype.ReasonTruncated, truncatedEntry.Validation.Reason)
+
+			textEntry := entries[5]
+			assert.Equal(t, "notes.txt", textEntry.Path)
+			assert.Equal(t, "text/plain; charset=utf-8", textEntry.MimeType)
+			assert.Nil(t, textEntry.Validation, "text files have no validators")
+		})
+	}
+}
+
+func TestWalkFSValidation(t *testing.T) {
+	flac := flacFile(44100, 2, 110250)
+	pdf := pdfFile(1, "")
+	fsys := fstest.MapFS{
+		"valid.pdf":     {Data: pdf},
+		"truncated.pdf": {Data: pdf[:len(pdf)-20]},
+		"valid.flac":    {Data: flac},
+		"invalid.flac":  {Data: flac[:20]},
+		"archive.zip":   {Data: zipFile(t, archiveFile{"a.txt", []byte("a")})},
+	}
+
+	entries := walkFSEntries(t, fsys, ".")
+	require.Len(t, entries, 5)
+	validations := map[string]*filetype.ValidationResult{}
+	for _, entry := range entries {
+		require.NoError(t, entry.Err, "%s should have been processed", entry.Path)
+		validations[entry.Path] = entry.Validation
+	}
+
+	assert.Nil(t, validations["archive.zip"], "zip files that are not documents have no validators")
+	assert.True(t, validations["valid.pdf"].Valid, "the pdf should be valid")
+	assert.False(t, validations["truncated.pdf"].Valid, "the pdf should be invalid")
+	assert.Equal(t, "application/pdf", validations["truncated.pdf"].MimeType)
+	assert.True(t, validations["valid.flac"].Valid, "the flac file should be valid")
+	assert.False(t, validations["invalid.flac"].Valid, "the flac file should be invalid")
+}
+
+func TestWalkFSErrors(t *testing.T) {
+	t.Run("missing root", func(t *testing.T) {
+		t.Parallel()
+
+		entries := walkFSEntries(t, fstest.MapFS{}, "missing")
+		require.Len(t, entries, 1, "the error should have been reported")
+		assert.Equal(t, "missing", entries[0].Path)
+		assert.True(t, errors.Is(entries[0].Err, fs.ErrNotExist), "unexpected error: %v", entries[0].Err)
+	})
+
+	t.Run("callback error", func(t *testing.T) {
+		t.Parallel()
+
+		fsys := fstest.MapFS{}
+		for i := 0; i < 100; i++ {
+			fsys[fmt.Sprintf("file%d", i)] = &fstest.MapFile{Data: []byte("data")}
+		}
+		expectedErr := errors.New("stop")
+		calls := 0
+		err := filetype.WalkFSContext(context.Background(), fsys, ".", &filetype.FSWalkOptions{Workers: 2}, func(entry *filetype.FSEntry) error {
+			calls++
+			return expectedErr
+		})
+		assert.Equal(t, expectedErr, err)
+		assert.Equal(t, 1, calls, "fn should not have been called after returning an error")
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := filetype.WalkFSContext(ctx, fstest.MapFS{"a": {Data: []byte("a")}}, ".", nil, func(entry *filetype.FSEntry) error {
+			return nil
+		})
+		assert.Equal(t, context.Canceled, err)
+	})
+}
+
+func TestWalkFSContextConcurrency(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for i := 0; i < 50; i++ {
+		fsys[fmt.Sprintf("dir/file%d", i)] = &fstest.MapFile{Data: []byte("data")}
+	}
+
+	var mu sync.Mutex
+	running := 0
+	seen := map[string]bool{}
+	err := filetype.WalkFSContext(context.Background(), fsys, "dir", &filetype.FSWalkOptions{Workers: 4}, func(entry *filetype.FSEntry) error {
+		// fn must never be called concurrently
+		mu.Lock()
+		running++
+		require.Equal(t, 1, running, "fn should not be called concurrently")
+		mu.Unlock()
+
+		seen[entry.Path] = true
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	})
+	require.NoError(t, err, "WalkFSContext() should have succeed")
+	assert.Len(t, seen, 50, "all the files should have been walked once")
+}
+
+func TestWalkFSResults(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.txt": {Data: []byte("a")},
+		"b.txt": {Data: []byte("b")},
+	}
+
+	paths := []string{}
+	for entry := range filetype.WalkFSResults(context.Background(), fsys, ".", nil) {
+		require.NoError(t, entry.Err, "%s should have been processed", entry.Path)
+		paths = append(paths, entry.Path)
+	}
+	sort.Strings(paths)
+	assert.Equal(t, []string{"a.txt", "b.txt"}, paths)
+
+	// the channel must be closed when the context is canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	results := filetype.WalkFSResults(ctx, fsys, ".", nil)
+	cancel()
+	for range results {
+	}
+	assert.Equal(t, context.Canceled, ctx.Err())
+}
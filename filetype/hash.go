@@ -0,0 +1,197 @@
+package filetype
+
+import (
+	"bytes"
+	"crypto/md5"  //nolint:gosec // Used for checksums, not for security
+	"crypto/sha1" //nolint:gosec // Used for checksums, not for security
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+// ErrMsgUnsupportedHashAlgorithm represents the error message returned
+// when using an unknown hashing algorithm
+var ErrMsgUnsupportedHashAlgorithm = "unsupported hash algorithm"
+
+// ErrMsgNoHashAlgorithm represents the error message returned when
+// Hash() is called without any algorithm
+var ErrMsgNoHashAlgorithm = "no hash algorithm provided"
+
+// HashAlgorithm represents a hashing algorithm supported by Hash()
+type HashAlgorithm string
+
+// List of all the supported hashing algorithms
+const (
+	MD5    HashAlgorithm = "md5"
+	SHA1   HashAlgorithm = "sha1"
+	SHA256 HashAlgorithm = "sha256"
+	SHA512 HashAlgorithm = "sha512"
+	CRC32C HashAlgorithm = "crc32c"
+)
+
+// hashAlgorithm contains the information needed to compute and encode
+// a digest
+type hashAlgorithm struct {
+	new func() hash.Hash
+	// multihashCode is the code of the algorithm in the multicodec table,
+	// 0 if the algorithm has no code
+	// https://github.com/multiformats/multicodec/blob/master/table.csv
+	multihashCode uint64
+}
+
+// hashAlgorithms contains all the supported hashing algorithms
+var hashAlgorithms = map[HashAlgorithm]hashAlgorithm{
+	MD5:    {new: md5.New, multihashCode: 0xd5},
+	SHA1:   {new: sha1.New, multihashCode: 0x11},
+	SHA256: {new: sha256.New, multihashCode: 0x12},
+	SHA512: {new: sha512.New, multihashCode: 0x13},
+	CRC32C: {new: func() hash.Hash { return crc32.New(crc32.MakeTable(crc32.Castagnoli)) }},
+}
+
+// Digest represents the result of a hashing algorithm
+type Digest struct {
+	Algorithm HashAlgorithm
+	Sum       []byte
+}
+
+// Hex returns the digest encoded in hexadecimal
+func (d Digest) Hex() string {
+	return hex.EncodeToString(d.Sum)
+}
+
+// Base64 returns the digest encoded in standard base64 (the format used by
+// the Content-MD5 header, or the checksums of S3 and GCS)
+func (d Digest) Base64() string {
+	return base64.StdEncoding.EncodeToString(d.Sum)
+}
+
+// Multihash returns the digest encoded as a multihash
+// https://multiformats.io/multihash/
+func (d Digest) Multihash() ([]byte, error) {
+	algo, ok := hashAlgorithms[d.Algorithm]
+	if !ok || algo.multihashCode == 0 {
+		return nil, errors.New(ErrMsgUnsupportedHashAlgorithm)
+	}
+
+	buf := make([]byte, 2*binary.MaxVarintLen64, 2*binary.MaxVarintLen64+len(d.Sum))
+	n := binary.PutUvarint(buf, algo.multihashCode)
+	n += binary.PutUvarint(buf[n:], uint64(len(d.Sum)))
+	return append(buf[:n], d.Sum...), nil
+}
+
+// String implements the fmt.Stringer interface
+// https://golang.org/pkg/fmt/#Stringer
+func (d Digest) String() string {
+	return fmt.Sprintf("%s:%s", d.Algorithm, d.Hex())
+}
+
+// MarshalJSON implements the json.Marshaler interface. The digest is
+// encoded in hexadecimal
+// https://golang.org/pkg/encoding/json/#Marshaler
+func (d Digest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Hex())
+}
+
+// Digests contains the digests returned by Hash(), indexed by algorithm
+type Digests map[HashAlgorithm]Digest
+
+// Hash reads the whole reader and computes the digests of all the
+// provided algorithms in a single pass
+func Hash(r io.Reader, algos ...HashAlgorithm) (Digests, error) {
+	h, err := newHasher(algos)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
+	}
+	return h.digests(), nil
+}
+
+// hasher is a writer that computes the digests of several algorithms
+// at once
+type hasher struct {
+	io.Writer
+	hashes map[HashAlgorithm]hash.Hash
+}
+
+// newHasher returns a hasher that computes the digests of all the
+// provided algorithms
+func newHasher(algos []HashAlgorithm) (*hasher, error) {
+	if len(algos) == 0 {
+		return nil, errors.New(ErrMsgNoHashAlgorithm)
+	}
+
+	hashes := make(map[HashAlgorithm]hash.Hash, len(algos))
+	writers := make([]io.Writer, 0, len(algos))
+	for _, name := range algos {
+		algo, ok := hashAlgorithms[name]
+		if !ok {
+			return nil, errors.New(ErrMsgUnsupportedHashAlgorithm)
+		}
+		if _, ok := hashes[name]; ok {
+			continue
+		}
+		h := algo.new()
+		hashes[name] = h
+		writers = append(writers, h)
+	}
+	return &hasher{Writer: io.MultiWriter(writers...), hashes: hashes}, nil
+}
+
+// digests returns the digests of all the data written so far
+func (h *hasher) digests() Digests {
+	digests := make(Digests, len(h.hashes))
+	for name, hh := range h.hashes {
+		digests[name] = Digest{Algorithm: name, Sum: hh.Sum(nil)}
+	}
+	return digests
+}
+
+// S3ETag returns the ETag that S3 will compute for a file uploaded using
+// parts of partSize bytes.
+// Files that fit in one part are expected to have been uploaded without
+// using a multipart upload, and their ETag is their MD5 sum.
+// Multipart uploads have an ETag made of the MD5 sum of the MD5 sum of
+// each part, followed by the number of parts.
+func S3ETag(r io.Reader, partSize int64) (string, error) {
+	if partSize <= 0 {
+		digests, err := Hash(r, MD5)
+		if err != nil {
+			return "", err
+		}
+		return digests[MD5].Hex(), nil
+	}
+
+	sums := &bytes.Buffer{}
+	parts := 0
+	for {
+		h := md5.New() //nolint:gosec // S3 uses MD5
+		n, err := io.CopyN(h, r, partSize)
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		// An empty file still has one part
+		if n > 0 || parts == 0 {
+			sums.Write(h.Sum(nil))
+			parts++
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+
+	if parts == 1 {
+		return hex.EncodeToString(sums.Bytes()), nil
+	}
+	etag := md5.Sum(sums.Bytes()) //nolint:gosec // S3 uses MD5
+	return fmt.Sprintf("%x-%d", etag, parts), nil
+}
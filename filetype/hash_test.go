@@ -0,0 +1,170 @@
+package filetype_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Nivl/go-types/filetype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHash(t *testing.T) {
+	testCases := []struct {
+		algo           filetype.HashAlgorithm
+		expectedHex    string
+		expectedBase64 string
+	}{
+		{filetype.MD5, "54b0c58c7ce9f2a8b551351102ee0938", "VLDFjHzp8qi1UTURAu4JOA=="},
+		{filetype.SHA1, "fa26be19de6bff93f70bc2308434e4a440bbad02", "+ia+Gd5r/5P3C8IwhDTkpEC7rQI="},
+		{filetype.SHA256, "2e99758548972a8e8822ad47fa1017ff72f06f3ff6a016851f45c398732bc50c", "Lpl1hUiXKo6IIq1H+hAX/3Lwbz/2oBaFH0XDmHMrxQw="},
+		{filetype.SHA512, "7d0a8468ed220400c0b8e6f335baa7e070ce880a37e2ac5995b9a97b809026de626da636ac7365249bb974c719edf543b52ed286646f437dc7f810cc2068375c", "fQqEaO0iBADAuObzNbqn4HDOiAo34qxZlbmpe4CQJt5ibaY2rHNlJJu5dMcZ7fVDtS7ShmRvQ33H+BDMIGg3XA=="},
+		{filetype.CRC32C, "7cfc66a7", "fPxmpw=="},
+	}
+
+	// Compute all the digests at once
+	algos := make([]filetype.HashAlgorithm, len(testCases))
+	for i, tc := range testCases {
+		algos[i] = tc.algo
+	}
+	digests, err := filetype.Hash(strings.NewReader("this is a test"), algos...)
+	require.NoError(t, err, "Hash() should have succeed")
+	require.Len(t, digests, len(testCases), "Hash() should have returned one digest per algorithm")
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(string(tc.algo), func(t *testing.T) {
+			t.Parallel()
+
+			digest, found := digests[tc.algo]
+			require.True(t, found, "Hash() should have returned a digest")
+			assert.Equal(t, tc.algo, digest.Algorithm, "invalid algorithm")
+			assert.Equal(t, tc.expectedHex, hex.EncodeToString(digest.Sum), "invalid sum")
+			assert.Equal(t, tc.expectedHex, digest.Hex(), "invalid hex encoding")
+			assert.Equal(t, tc.expectedBase64, digest.Base64(), "invalid base64 encoding")
+			assert.Equal(t, string(tc.algo)+":"+tc.expectedHex, digest.String(), "invalid string")
+		})
+	}
+}
+
+func TestHashErrors(t *testing.T) {
+	testCases := []struct {
+		description string
+		algos       []filetype.HashAlgorithm
+		expectedErr string
+	}{
+		{"no algorithms", nil, filetype.ErrMsgNoHashAlgorithm},
+		{"unknown algorithm", []filetype.HashAlgorithm{filetype.MD5, "blake3"}, filetype.ErrMsgUnsupportedHashAlgorithm},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			digests, err := filetype.Hash(strings.NewReader("this is a test"), tc.algos...)
+			require.Error(t, err, "Hash() should have failed")
+			assert.Equal(t, tc.expectedErr, err.Error(), "invalid error")
+			assert.Nil(t, digests, "Hash() should not have returned any digests")
+		})
+	}
+}
+
+func TestHashDuplicateAlgorithms(t *testing.T) {
+	t.Parallel()
+
+	digests, err := filetype.Hash(strings.NewReader("this is a test"), filetype.MD5, filetype.MD5)
+	require.NoError(t, err, "Hash() should have succeed")
+	require.Len(t, digests, 1, "Hash() should have returned one digest")
+	assert.Equal(t, "54b0c58c7ce9f2a8b551351102ee0938", digests[filetype.MD5].Hex(), "invalid sum")
+}
+
+func TestHashReadFail(t *testing.T) {
+	t.Parallel()
+
+	digests, err := filetype.Hash(&failingReader{err: errors.New("read failed")}, filetype.SHA256)
+	require.Error(t, err, "Hash() should have failed")
+	assert.Equal(t, "read failed", err.Error(), "invalid error")
+	assert.Nil(t, digests, "Hash() should not have returned any digests")
+}
+
+func TestDigestMultihash(t *testing.T) {
+	testCases := []struct {
+		algo     filetype.HashAlgorithm
+		expected string
+	}{
+		{filetype.MD5, "d50110" + "54b0c58c7ce9f2a8b551351102ee0938"},
+		{filetype.SHA1, "1114" + "fa26be19de6bff93f70bc2308434e4a440bbad02"},
+		{filetype.SHA256, "1220" + "2e99758548972a8e8822ad47fa1017ff72f06f3ff6a016851f45c398732bc50c"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(string(tc.algo), func(t *testing.T) {
+			t.Parallel()
+
+			digests, err := filetype.Hash(strings.NewReader("this is a test"), tc.algo)
+			require.NoError(t, err, "Hash() should have succeed")
+			mh, err := digests[tc.algo].Multihash()
+			require.NoError(t, err, "Multihash() should have succeed")
+			assert.Equal(t, tc.expected, hex.EncodeToString(mh), "invalid multihash")
+		})
+	}
+}
+
+func TestDigestMultihashUnsupported(t *testing.T) {
+	t.Parallel()
+
+	digests, err := filetype.Hash(strings.NewReader("this is a test"), filetype.CRC32C)
+	require.NoError(t, err, "Hash() should have succeed")
+	mh, err := digests[filetype.CRC32C].Multihash()
+	require.Error(t, err, "Multihash() should have failed")
+	assert.Equal(t, filetype.ErrMsgUnsupportedHashAlgorithm, err.Error(), "invalid error")
+	assert.Nil(t, mh, "Multihash() should not have returned a value")
+}
+
+func TestS3ETag(t *testing.T) {
+	testCases := []struct {
+		description string
+		content     string
+		partSize    int64
+		expected    string
+	}{
+		{"no part size", "this is a test", 0, "54b0c58c7ce9f2a8b551351102ee0938"},
+		{"single part", "this is a test", 1024, "54b0c58c7ce9f2a8b551351102ee0938"},
+		{"exactly one part", "this is a test", 14, "54b0c58c7ce9f2a8b551351102ee0938"},
+		{"multipart", "this is a test", 5, "33809fa819fce5c851aa2e3a800fe328-3"},
+		{"empty file", "", 5, "d41d8cd98f00b204e9800998ecf8427e"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			etag, err := filetype.S3ETag(bytes.NewReader([]byte(tc.content)), tc.partSize)
+			require.NoError(t, err, "S3ETag() should have succeed")
+			assert.Equal(t, tc.expected, etag, "invalid etag")
+		})
+	}
+}
+
+func TestS3ETagReadFail(t *testing.T) {
+	t.Parallel()
+
+	etag, err := filetype.S3ETag(&failingReader{err: errors.New("read failed")}, 5)
+	require.Error(t, err, "S3ETag() should have failed")
+	assert.Empty(t, etag, "S3ETag() should not have returned a value")
+}
+
+// failingReader is a reader that always fails
+type failingReader struct {
+	err error
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
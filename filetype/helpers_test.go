@@ -0,0 +1,70 @@
+package filetype_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path"
+	"testing"
+
+	"github.com/Nivl/go-types/filetype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixtures contains all the files of the fixtures directory
+var fixtures = []string{
+	"black_pixel.bmp",
+	"black_pixel.gif",
+	"black_pixel.ico",
+	"black_pixel.jpg",
+	"black_pixel.pdf",
+	"black_pixel.png",
+	"black_pixel.svg",
+	"black_pixel.tiff",
+	"black_pixel.webp",
+	"LICENSE",
+}
+
+// readFixture returns the content of a file of the fixtures directory
+func readFixture(t *testing.T, filename string) []byte {
+	content, err := ioutil.ReadFile(path.Join("fixtures", filename))
+	require.NoError(t, err, "ReadFile() should have succeed")
+	return content
+}
+
+// testFileValidator runs a validator against all the fixtures, and makes
+// sure only the provided ones are valid.
+// It also makes sure that truncated versions of the valid files are
+// not valid.
+func testFileValidator(t *testing.T, validator filetype.FileValidator, validFixtures ...string) {
+	for _, filename := range fixtures {
+		filename := filename
+		shouldBeValid := false
+		for _, valid := range validFixtures {
+			if valid == filename {
+				shouldBeValid = true
+			}
+		}
+
+		t.Run(filename, func(t *testing.T) {
+			t.Parallel()
+
+			isValid, err := validator(bytes.NewReader(readFixture(t, filename)))
+			assert.NoError(t, err, "the validator should not have failed")
+			assert.Equal(t, shouldBeValid, isValid, "the validator did not return the expected value")
+		})
+
+		if shouldBeValid {
+			t.Run("truncated "+filename, func(t *testing.T) {
+				t.Parallel()
+
+				content := readFixture(t, filename)
+				r := bytes.NewReader(content[:len(content)/2])
+				isValid, err := validator(r)
+				assert.NoError(t, err, "the validator should not have failed")
+				assert.False(t, isValid, "a truncated file should not be valid")
+				assert.Equal(t, int64(0), r.Size()-int64(r.Len()), "the reader should be at its original position")
+			})
+		}
+	}
+}
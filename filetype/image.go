@@ -1,11 +1,7 @@
 package filetype
 
 import (
-	"errors"
 	"image"
-	"image/gif"
-	"image/jpeg"
-	"image/png"
 	"io"
 )
 
@@ -13,60 +9,50 @@ import (
 // unsupported image type
 var ErrMsgUnsupportedImageFormat = "unsupported image format"
 
+// ErrMsgInvalidImage represents the error message returned when an image
+// cannot be parsed
+var ErrMsgInvalidImage = "invalid image"
+
 // ImageDecoder is a type that represents an image decoder
 type ImageDecoder func(r io.Reader) (image.Image, error)
 
-// IsImage checks the specified reader contains an image
+// IsImage checks the specified reader contains an image.
+// The list of supported formats can be changed using RegisterImageFormat().
+// Use CheckImage() to get the reason why an image is not valid.
 func IsImage(r io.ReadSeeker) (isValid bool, mimeType string, err error) {
-	mimeType, err = MimeType(r)
-	if err != nil {
-		return false, "", err
-	}
-
-	validType := map[string]FileValidator{
-		"image/jpeg": IsJPG,
-		"image/png":  IsPNG,
-		"image/gif":  IsGIF,
-	}
-	validator, found := validType[mimeType]
-	if !found {
-		return false, "", errors.New(ErrMsgUnsupportedImageFormat)
-	}
-	isValid, err = validator(r)
-	return isValid, mimeType, err
+	return IsImageWithOptions(r, nil)
 }
 
-// IsPNG validates a PNG file
+// IsPNG validates a PNG file.
+// Use CheckPNG() to get the reason why an image is not valid.
 func IsPNG(r io.ReadSeeker) (bool, error) {
-	return ValidateImage(r, png.Decode)
+	return isValid(CheckPNG(r))
 }
 
-// IsJPG validates a JPG file
+// IsJPG validates a JPG file.
+// Use CheckJPG() to get the reason why an image is not valid.
 func IsJPG(r io.ReadSeeker) (bool, error) {
-	return ValidateImage(r, jpeg.Decode)
+	return isValid(CheckJPG(r))
 }
 
-// IsGIF validates a GIF file
+// IsGIF validates a GIF file.
+// Use CheckGIF() to get the reason why an image is not valid.
 func IsGIF(r io.ReadSeeker) (bool, error) {
-	return ValidateImage(r, gif.Decode)
+	return isValid(CheckGIF(r))
 }
 
 // ValidateImage check if an images has a valid format
 // Update when this gets done: https://github.com/golang/go/issues/18098
+// Use CheckDecode() to get the reason why an image is not valid.
 func ValidateImage(r io.ReadSeeker, decode ImageDecoder) (bool, error) {
-	initialPos, err := r.Seek(0, io.SeekCurrent)
-	if err != nil {
-		return false, err
-	}
-
-	// Parse the whole file
-	_, err = decode(r)
-	success := (err == nil)
+	return isValid(CheckDecode(r, decode))
+}
 
-	// revert the pointer back to its original position
-	_, err = r.Seek(initialPos, io.SeekStart)
+// isValid converts the result of a Checker to the values returned by
+// a FileValidator
+func isValid(res *ValidationResult, err error) (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	return success, nil
+	return res.Valid, nil
 }
@@ -0,0 +1,103 @@
+package filetype
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// IsBMP validates the structure of a BMP file.
+// The image data are not decoded, use RegisterImageFormat() with
+// golang.org/x/image/bmp to fully decode the files instead.
+func IsBMP(r io.ReadSeeker) (bool, error) {
+	return validateHeader(r, parseBMP)
+}
+
+// parseBMP parses the headers of a BMP file
+// https://docs.microsoft.com/en-us/windows/desktop/gdi/bitmap-storage
+func parseBMP(s *section) (*imageHeader, error) {
+	// The file header is 14 bytes long, and is followed by the DIB header
+	// that starts with its own size
+	header, err := s.read(0, 18)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(header[:2], []byte("BM")) {
+		return nil, errMalformed
+	}
+	pixelsOffset := int64(binary.LittleEndian.Uint32(header[10:14]))
+	dibSize := int64(binary.LittleEndian.Uint32(header[14:18]))
+
+	var width, height int64
+	var planes, bpp uint16
+	var compression uint32
+	switch dibSize {
+	case 12: // BITMAPCOREHEADER
+		dib, err := s.read(14, dibSize)
+		if err != nil {
+			return nil, err
+		}
+		width = int64(binary.LittleEndian.Uint16(dib[4:6]))
+		height = int64(binary.LittleEndian.Uint16(dib[6:8]))
+		planes = binary.LittleEndian.Uint16(dib[8:10])
+		bpp = binary.LittleEndian.Uint16(dib[10:12])
+	case 40, 52, 56, 64, 108, 124: // BITMAPINFOHEADER and its extensions
+		dib, err := s.read(14, dibSize)
+		if err != nil {
+			return nil, err
+		}
+		width = int64(int32(binary.LittleEndian.Uint32(dib[4:8])))
+		height = int64(int32(binary.LittleEndian.Uint32(dib[8:12])))
+		planes = binary.LittleEndian.Uint16(dib[12:14])
+		bpp = binary.LittleEndian.Uint16(dib[14:16])
+		compression = binary.LittleEndian.Uint32(dib[16:20])
+	default:
+		return nil, errMalformed
+	}
+
+	// A negative height means the image is stored top-down
+	if height < 0 {
+		height = -height
+	}
+	if width <= 0 || height == 0 || planes != 1 {
+		return nil, errMalformed
+	}
+	switch bpp {
+	case 1, 2, 4, 8, 16, 24, 32:
+	default:
+		return nil, errMalformed
+	}
+	// 0 to 6 are BI_RGB, BI_RLE8, BI_RLE4, BI_BITFIELDS, BI_JPEG, BI_PNG,
+	// and BI_ALPHABITFIELDS
+	if compression > 6 {
+		return nil, errMalformed
+	}
+	if pixelsOffset < 14+dibSize || pixelsOffset >= s.size {
+		return nil, errMalformed
+	}
+
+	// For uncompressed images we can make sure that all the pixels are there.
+	// Each row is padded to 4 bytes
+	if compression == 0 || compression == 3 {
+		rowSize := (int64(bpp)*width + 31) / 32 * 4
+		// rowSize*height can overflow, so we divide instead
+		if rowSize > (s.size-pixelsOffset)/height {
+			return nil, errTruncated
+		}
+	}
+
+	info := &imageHeader{
+		width:    int(width),
+		height:   int(height),
+		bitDepth: int(bpp),
+	}
+	switch {
+	case bpp <= 8:
+		info.colorModel = ColorModelPaletted
+	case bpp == 32:
+		info.colorModel = ColorModelRGBA
+	default:
+		info.colorModel = ColorModelRGB
+	}
+	return info, nil
+}
@@ -0,0 +1,33 @@
+package filetype_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/Nivl/go-types/filetype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsBMP(t *testing.T) {
+	testFileValidator(t, filetype.IsBMP, "black_pixel.bmp")
+}
+
+func TestIsBMPHugeDimensions(t *testing.T) {
+	t.Parallel()
+
+	// rowSize*height overflows an int64 with those dimensions
+	bmp := make([]byte, 64)
+	copy(bmp, "BM")
+	binary.LittleEndian.PutUint32(bmp[10:14], 54)         // pixels offset
+	binary.LittleEndian.PutUint32(bmp[14:18], 40)         // BITMAPINFOHEADER
+	binary.LittleEndian.PutUint32(bmp[18:22], 0x7fffffff) // width
+	binary.LittleEndian.PutUint32(bmp[22:26], 0x80000000) // height
+	binary.LittleEndian.PutUint16(bmp[26:28], 1)          // planes
+	binary.LittleEndian.PutUint16(bmp[28:30], 32)         // bits per pixel
+
+	valid, err := filetype.IsBMP(bytes.NewReader(bmp))
+	require.NoError(t, err, "IsBMP() should have succeed")
+	assert.False(t, valid, "the truncated image should be invalid")
+}
@@ -0,0 +1,102 @@
+package filetype
+
+import (
+	"encoding/binary"
+	"io"
+	"strings"
+)
+
+// IsHEIF validates the structure of a HEIF file (HEIC and AVIF images).
+// The image data are not decoded.
+func IsHEIF(r io.ReadSeeker) (bool, error) {
+	return validateHeader(r, parseHEIF)
+}
+
+// parseHEIF parses the boxes of a HEIF file, and returns the size of the
+// first image it finds
+// https://www.iso.org/standard/66067.html
+func parseHEIF(s *section) (*imageHeader, error) {
+	boxes, err := readBoxes(s, 0, s.size)
+	if err != nil {
+		return nil, err
+	}
+	if len(boxes) == 0 || boxes[0].typ != "ftyp" {
+		return nil, errMalformed
+	}
+	ftyp, err := s.read(0, minInt64(boxes[0].end, sniffLen))
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(sniffFtyp(ftyp), "image/") {
+		return nil, errMalformed
+	}
+
+	// The meta box is a full box that contains the description
+	// of all the images
+	meta := findBox(boxes, "meta")
+	if meta == nil {
+		return nil, errMalformed
+	}
+	metaBoxes, err := readChildBoxes(s, meta, 4)
+	if err != nil {
+		return nil, err
+	}
+
+	// The handler type must be "pict". The hdlr box is a full box containing
+	// 4 bytes of pre_defined, followed by the handler type
+	hdlr := findBox(metaBoxes, "hdlr")
+	if hdlr == nil {
+		return nil, errMalformed
+	}
+	handler, err := s.read(hdlr.dataStart+8, 4)
+	if err != nil {
+		return nil, err
+	}
+	if string(handler) != "pict" {
+		return nil, errMalformed
+	}
+
+	// The size of the images is in meta/iprp/ipco/ispe
+	info := &imageHeader{colorModel: ColorModelYCbCr}
+	iprp := findBox(metaBoxes, "iprp")
+	if iprp == nil {
+		return nil, errMalformed
+	}
+	iprpBoxes, err := readChildBoxes(s, iprp, 0)
+	if err != nil {
+		return nil, err
+	}
+	ipco := findBox(iprpBoxes, "ipco")
+	if ipco == nil {
+		return nil, errMalformed
+	}
+	properties, err := readChildBoxes(s, ipco, 0)
+	if err != nil {
+		return nil, err
+	}
+	ispe := findBox(properties, "ispe")
+	if ispe == nil {
+		return nil, errMalformed
+	}
+	// ispe is a full box that contains 2x4 bytes for the size
+	size, err := s.read(ispe.dataStart+4, 8)
+	if err != nil {
+		return nil, err
+	}
+	info.width = int(binary.BigEndian.Uint32(size[:4]))
+	info.height = int(binary.BigEndian.Uint32(size[4:]))
+	if info.width == 0 || info.height == 0 {
+		return nil, errMalformed
+	}
+
+	// pixi is an optional full box that contains the number of channels
+	// followed by the bit depth of each channel
+	if pixi := findBox(properties, "pixi"); pixi != nil {
+		depth, err := s.read(pixi.dataStart+5, 1)
+		if err != nil {
+			return nil, err
+		}
+		info.bitDepth = int(depth[0])
+	}
+	return info, nil
+}
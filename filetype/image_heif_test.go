@@ -0,0 +1,68 @@
+package filetype_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/Nivl/go-types/filetype"
+	"github.com/stretchr/testify/assert"
+)
+
+// isoBox returns an ISO-BMFF box containing the provided data
+func isoBox(typ string, data ...[]byte) []byte {
+	content := bytes.Join(data, nil)
+	b := make([]byte, 8, 8+len(content))
+	binary.BigEndian.PutUint32(b, uint32(8+len(content)))
+	copy(b[4:], typ)
+	return append(b, content...)
+}
+
+// heifFile returns a HEIF file of the given brand containing the
+// description of an image of 1x1 pixel
+func heifFile(brand, handler string) []byte {
+	fullBoxHeader := []byte{0, 0, 0, 0}
+	return bytes.Join([][]byte{
+		isoBox("ftyp", []byte(brand), []byte{0, 0, 0, 0}, []byte(brand)),
+		isoBox("meta",
+			fullBoxHeader,
+			isoBox("hdlr", fullBoxHeader, []byte{0, 0, 0, 0}, []byte(handler), make([]byte, 13)),
+			isoBox("iprp",
+				isoBox("ipco",
+					isoBox("ispe", fullBoxHeader, []byte{0, 0, 0, 1}, []byte{0, 0, 0, 1}),
+				),
+			),
+		),
+		isoBox("mdat", []byte{0}),
+	}, nil)
+}
+
+func TestIsHEIF(t *testing.T) {
+	// sugar
+	shouldBeValid := true
+
+	heic := heifFile("heic", "pict")
+	testCases := []struct {
+		description   string
+		content       []byte
+		shouldBeValid bool
+	}{
+		{"heic should work", heic, shouldBeValid},
+		{"avif should work", heifFile("avif", "pict"), shouldBeValid},
+		{"video handler should fail", heifFile("heic", "vide"), !shouldBeValid},
+		{"mp4 should fail", heifFile("isom", "pict"), !shouldBeValid},
+		{"truncated file should fail", heic[:len(heic)-1], !shouldBeValid},
+		{"png should fail", readFixture(t, "black_pixel.png"), !shouldBeValid},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			isValid, err := filetype.IsHEIF(bytes.NewReader(tc.content))
+			assert.NoError(t, err, "IsHEIF should not have failed")
+			assert.Equal(t, tc.shouldBeValid, isValid, "IsHEIF did not return the expected value")
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package filetype
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// IsICO validates the structure of an ICO or CUR file, and makes sure
+// all the images it contains are either PNG or BMP
+func IsICO(r io.ReadSeeker) (bool, error) {
+	return validateHeader(r, parseICO)
+}
+
+// parseICO parses the directory of an ICO file. The returned size is the
+// size of the biggest image of the file
+// https://docs.microsoft.com/en-us/previous-versions/ms997538(v=msdn.10)
+func parseICO(s *section) (*imageHeader, error) {
+	header, err := s.read(0, 6)
+	if err != nil {
+		return nil, err
+	}
+	// 2 reserved bytes, 2 bytes for the type (1 for ICO, 2 for CUR),
+	// and 2 bytes for the number of images
+	typ := binary.LittleEndian.Uint16(header[2:4])
+	count := int64(binary.LittleEndian.Uint16(header[4:6]))
+	if header[0] != 0 || header[1] != 0 || (typ != 1 && typ != 2) || count == 0 {
+		return nil, errMalformed
+	}
+
+	// Each directory entry is 16 bytes long
+	dirEnd := 6 + count*16
+	dir, err := s.read(6, count*16)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &imageHeader{colorModel: ColorModelRGBA}
+	for i := int64(0); i < count; i++ {
+		entry := dir[i*16 : (i+1)*16]
+		// a size of 0 means 256 pixels
+		width, height := int(entry[0]), int(entry[1])
+		if width == 0 {
+			width = 256
+		}
+		if height == 0 {
+			height = 256
+		}
+
+		size := int64(binary.LittleEndian.Uint32(entry[8:12]))
+		offset := int64(binary.LittleEndian.Uint32(entry[12:16]))
+		if offset < dirEnd || size < 8 {
+			return nil, errMalformed
+		}
+		if offset+size > s.size {
+			return nil, errTruncated
+		}
+
+		// The image is either a PNG file or a BMP file without its
+		// file header (the data start with the BITMAPINFOHEADER)
+		data, err := s.read(offset, 8)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(data, []byte("\x89PNG\x0D\x0A\x1A\x0A")) && binary.LittleEndian.Uint32(data[:4]) != 40 {
+			return nil, errMalformed
+		}
+
+		if width*height > info.width*info.height {
+			info.width, info.height = width, height
+			info.bitDepth = int(binary.LittleEndian.Uint16(entry[6:8]))
+		}
+	}
+	return info, nil
+}
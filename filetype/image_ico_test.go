@@ -0,0 +1,11 @@
+package filetype_test
+
+import (
+	"testing"
+
+	"github.com/Nivl/go-types/filetype"
+)
+
+func TestIsICO(t *testing.T) {
+	testFileValidator(t, filetype.IsICO, "black_pixel.ico")
+}
@@ -0,0 +1,439 @@
+package filetype
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+// List of the color models returned by ImageInfo()
+const (
+	ColorModelGray      = "gray"
+	ColorModelGrayAlpha = "gray+alpha"
+	ColorModelRGB       = "rgb"
+	ColorModelRGBA      = "rgba"
+	ColorModelPaletted  = "paletted"
+	ColorModelCMYK      = "cmyk"
+	ColorModelYCbCr     = "ycbcr"
+)
+
+// ImageMetadata contains the information about an image
+type ImageMetadata struct {
+	// MimeType is the mimetype of the image
+	MimeType string `json:"mime_type"`
+
+	// Width is the width of the image in pixels
+	Width int `json:"width"`
+
+	// Height is the height of the image in pixels
+	Height int `json:"height"`
+
+	// ColorModel is the color model of the image (ColorModelRGB,
+	// ColorModelPaletted, etc.). Empty if unknown
+	ColorModel string `json:"color_model,omitempty"`
+
+	// BitDepth is the number of bits per channel (or per pixel for
+	// paletted images). 0 if unknown
+	BitDepth int `json:"bit_depth,omitempty"`
+
+	// FrameCount is the number of frames of the image. Always 1 for
+	// images that cannot be animated
+	FrameCount int `json:"frame_count"`
+
+	// Orientation is the EXIF orientation of the image, from 1 to 8.
+	// 1 (no transformation needed) is used for the images that don't have
+	// an orientation
+	Orientation int `json:"orientation"`
+}
+
+// imageHeaderParsers contains the functions used to get the information
+// of the images of the supported types, indexed by mimetype
+var imageHeaderParsers = map[string]headerParser{
+	"image/png":     parsePNG,
+	"image/jpeg":    parseJPEG,
+	"image/gif":     parseGIF,
+	"image/webp":    parseWebP,
+	"image/bmp":     parseBMP,
+	"image/tiff":    parseTIFF,
+	"image/x-icon":  parseICO,
+	"image/heic":    parseHEIF,
+	"image/heif":    parseHEIF,
+	"image/avif":    parseHEIF,
+	"image/svg+xml": parseSVG,
+}
+
+// ImageInfo returns the information of an image, without decoding
+// the whole image when possible.
+// The information of the formats added using RegisterImageFormat() are
+// retrieved using image.DecodeConfig(), or by decoding the whole image if
+// the format is not known by the image package.
+// The reader will be put back to its original position.
+func ImageInfo(r io.ReadSeeker) (*ImageMetadata, error) {
+	info, err := imageInfo(r, nil)
+	switch err {
+	case nil:
+		return info, nil
+	case errUnsupportedFormat:
+		return nil, errors.New(ErrMsgUnsupportedImageFormat)
+	case errMalformed:
+		return nil, errors.New(ErrMsgInvalidImage)
+	default:
+		return nil, err
+	}
+}
+
+// imageInfo returns the information of an image.
+// decode is used to read the header of the images that have no parsers
+// nor registered format. Can be nil.
+// errUnsupportedFormat is returned if the type of the image is not
+// supported, and errMalformed is returned if the image is invalid
+func imageInfo(r io.ReadSeeker, decode ImageDecoder) (info *ImageMetadata, err error) {
+	mimeType, err := MimeType(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var header *imageHeader
+	parse, found := imageHeaderParsers[mimeType]
+	if !found {
+		format, found := LookupImageFormat(mimeType)
+		switch {
+		case found:
+			parse = decodeHeader(format.Decode)
+		case decode != nil:
+			parse = decodeHeader(decode)
+		default:
+			return nil, errUnsupportedFormat
+		}
+	}
+
+	isValid, err := validateStructure(r, func(s *section) (err error) {
+		header, err = parse(s)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !isValid {
+		return nil, errMalformed
+	}
+
+	info = &ImageMetadata{
+		MimeType:    mimeType,
+		Width:       header.width,
+		Height:      header.height,
+		ColorModel:  header.colorModel,
+		BitDepth:    header.bitDepth,
+		FrameCount:  header.frames,
+		Orientation: header.orientation,
+	}
+	if info.FrameCount == 0 {
+		info.FrameCount = 1
+	}
+	if info.Orientation < 1 || info.Orientation > 8 {
+		info.Orientation = 1
+	}
+	return info, nil
+}
+
+// decodeHeader returns a headerParser that uses image.DecodeConfig(), or
+// decode if the format is not registered in the image package
+func decodeHeader(decode ImageDecoder) headerParser {
+	return func(s *section) (*imageHeader, error) {
+		r, err := s.reader()
+		if err != nil {
+			return nil, err
+		}
+		conf, _, err := image.DecodeConfig(r)
+		if err == nil {
+			return &imageHeader{
+				width:      conf.Width,
+				height:     conf.Height,
+				colorModel: colorModelName(conf.ColorModel),
+			}, nil
+		}
+		if err != image.ErrFormat || decode == nil {
+			return nil, errMalformed
+		}
+
+		if r, err = s.reader(); err != nil {
+			return nil, err
+		}
+		img, err := decode(r)
+		if err != nil {
+			return nil, errMalformed
+		}
+		return &imageHeader{
+			width:      img.Bounds().Dx(),
+			height:     img.Bounds().Dy(),
+			colorModel: colorModelName(img.ColorModel()),
+		}, nil
+	}
+}
+
+// decodeConfig runs the provided DecodeConfig function on the whole section
+func decodeConfig(s *section, decode func(io.Reader) (image.Config, error)) (*imageHeader, error) {
+	r, err := s.reader()
+	if err != nil {
+		return nil, err
+	}
+	conf, err := decode(r)
+	if err != nil {
+		return nil, errMalformed
+	}
+	return &imageHeader{
+		width:      conf.Width,
+		height:     conf.Height,
+		colorModel: colorModelName(conf.ColorModel),
+	}, nil
+}
+
+// colorModelName returns the name of a color model of the image package
+func colorModelName(m color.Model) string {
+	switch m {
+	case color.GrayModel, color.Gray16Model:
+		return ColorModelGray
+	case color.RGBAModel, color.RGBA64Model, color.NRGBAModel, color.NRGBA64Model:
+		return ColorModelRGBA
+	case color.YCbCrModel:
+		return ColorModelYCbCr
+	case color.CMYKModel:
+		return ColorModelCMYK
+	}
+	if _, ok := m.(color.Palette); ok {
+		return ColorModelPaletted
+	}
+	return ""
+}
+
+// parsePNG returns the information contained in the chunks of a PNG file
+// located before the image data
+// https://www.w3.org/TR/PNG/#5DataRep
+func parsePNG(s *section) (*imageHeader, error) {
+	info, err := decodeConfig(s, png.DecodeConfig)
+	if err != nil {
+		return nil, err
+	}
+	info.frames = 1
+
+	// The IHDR chunk is always the first one, right after the signature.
+	// It contains 2x4 bytes for the size, followed by the bit depth and
+	// the color type
+	ihdr, err := s.read(16, 10)
+	if err != nil {
+		return nil, err
+	}
+	info.bitDepth = int(ihdr[8])
+	switch ihdr[9] {
+	case 0:
+		info.colorModel = ColorModelGray
+	case 2:
+		info.colorModel = ColorModelRGB
+	case 3:
+		info.colorModel = ColorModelPaletted
+	case 4:
+		info.colorModel = ColorModelGrayAlpha
+	case 6:
+		info.colorModel = ColorModelRGBA
+	}
+
+	// Each chunk has 4 bytes of length, 4 bytes of type, the data, and 4
+	// bytes of CRC
+	for offset := int64(8); ; {
+		chunk, err := s.read(offset, 8)
+		if err != nil {
+			return nil, err
+		}
+		length := int64(binary.BigEndian.Uint32(chunk[:4]))
+		switch string(chunk[4:]) {
+		case "IDAT", "IEND":
+			return info, nil
+		case "acTL":
+			// Animated PNG. The number of frames is the first field
+			frames, err := s.readUint32(offset+8, binary.BigEndian)
+			if err != nil {
+				return nil, err
+			}
+			info.frames = int(frames)
+		case "eXIf":
+			exif, err := s.read(offset+8, length)
+			if err != nil {
+				return nil, err
+			}
+			info.orientation = exifOrientation(exif)
+		}
+		offset += 12 + length
+	}
+}
+
+// parseJPEG returns the information contained in the segments of a JPEG
+// file located before the image data
+// https://www.w3.org/Graphics/JPEG/itu-t81.pdf
+func parseJPEG(s *section) (*imageHeader, error) {
+	info, err := decodeConfig(s, jpeg.DecodeConfig)
+	if err != nil {
+		return nil, err
+	}
+	info.frames = 1
+
+	for offset := int64(2); ; {
+		marker, err := s.read(offset, 2)
+		if err != nil {
+			return nil, err
+		}
+		if marker[0] != 0xff {
+			return nil, errMalformed
+		}
+		// Markers can be padded with 0xff
+		if marker[1] == 0xff {
+			offset++
+			continue
+		}
+		// The markers without data
+		if marker[1] == 0x01 || (marker[1] >= 0xd0 && marker[1] <= 0xd8) {
+			offset += 2
+			continue
+		}
+		// The image data start after SOS, and EOI is the end of the file
+		if marker[1] == 0xda || marker[1] == 0xd9 {
+			return info, nil
+		}
+
+		// The length includes itself, but not the marker
+		length, err := s.readUint16(offset+2, binary.BigEndian)
+		if err != nil {
+			return nil, err
+		}
+		if length < 2 {
+			return nil, errMalformed
+		}
+		dataStart, dataLength := offset+4, int64(length)-2
+
+		switch {
+		case marker[1] == 0xe1:
+			// APP1 contains the EXIF data, prefixed by "Exif\0\0"
+			data, err := s.read(dataStart, dataLength)
+			if err != nil {
+				return nil, err
+			}
+			if bytes.HasPrefix(data, []byte("Exif\x00\x00")) {
+				info.orientation = exifOrientation(data[6:])
+			}
+		case marker[1] >= 0xc0 && marker[1] <= 0xcf && marker[1] != 0xc4 && marker[1] != 0xc8 && marker[1] != 0xcc:
+			// Start of frame. The first byte is the precision of the samples
+			precision, err := s.read(dataStart, 1)
+			if err != nil {
+				return nil, err
+			}
+			info.bitDepth = int(precision[0])
+		}
+		offset = dataStart + dataLength
+	}
+}
+
+// parseGIF returns the information of a GIF file. All the blocks of the
+// file are parsed to count the number of frames
+// https://www.w3.org/Graphics/GIF/spec-gif89a.txt
+func parseGIF(s *section) (*imageHeader, error) {
+	info, err := decodeConfig(s, gif.DecodeConfig)
+	if err != nil {
+		return nil, err
+	}
+	info.colorModel = ColorModelPaletted
+
+	// The header is 6 bytes long and is followed by the 7 bytes of the
+	// logical screen descriptor
+	lsd, err := s.read(6, 7)
+	if err != nil {
+		return nil, err
+	}
+	info.bitDepth = int((lsd[4]>>4)&0x07) + 1
+	offset := int64(13) + gifColorTableSize(lsd[4])
+
+	for {
+		block, err := s.read(offset, 1)
+		if err != nil {
+			return nil, err
+		}
+		switch block[0] {
+		case 0x21:
+			// Extension: 1 byte of label followed by data sub-blocks
+			if offset, err = skipGIFSubBlocks(s, offset+2); err != nil {
+				return nil, err
+			}
+		case 0x2c:
+			// Image descriptor: 9 bytes of data followed by an optional
+			// local color table, 1 byte of LZW code size, and the
+			// data sub-blocks
+			info.frames++
+			descriptor, err := s.read(offset+1, 9)
+			if err != nil {
+				return nil, err
+			}
+			offset += 10 + gifColorTableSize(descriptor[8]) + 1
+			if offset, err = skipGIFSubBlocks(s, offset); err != nil {
+				return nil, err
+			}
+		case 0x3b:
+			// Trailer
+			if info.frames == 0 {
+				return nil, errMalformed
+			}
+			return info, nil
+		default:
+			return nil, errMalformed
+		}
+	}
+}
+
+// gifColorTableSize returns the size in bytes of the color table described
+// by the given packed fields
+func gifColorTableSize(fields byte) int64 {
+	if fields&0x80 == 0 {
+		return 0
+	}
+	return 3 * (1 << ((fields & 0x07) + 1))
+}
+
+// skipGIFSubBlocks returns the offset of the first byte after the
+// sub-blocks that start at the given offset
+func skipGIFSubBlocks(s *section, offset int64) (int64, error) {
+	for {
+		size, err := s.read(offset, 1)
+		if err != nil {
+			return 0, err
+		}
+		offset += 1 + int64(size[0])
+		if size[0] == 0 {
+			return offset, nil
+		}
+	}
+}
+
+// exifOrientation returns the orientation contained in EXIF data, or 0
+// https://www.cipa.jp/std/documents/e/DC-008-2012_E.pdf
+func exifOrientation(exif []byte) int {
+	s, err := newSection(bytes.NewReader(exif))
+	if err != nil {
+		return 0
+	}
+	order, ifdOffset, err := readTIFFHeader(s)
+	if err != nil {
+		return 0
+	}
+	entries, err := readTIFFDirectory(s, order, ifdOffset)
+	if err != nil {
+		return 0
+	}
+	orientation, err := tiffValue(s, order, entries[tiffTagOrientation])
+	if err != nil {
+		return 0
+	}
+	return int(orientation)
+}
@@ -0,0 +1,148 @@
+package filetype_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+
+	"github.com/Nivl/go-types/filetype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pngChunk returns a PNG chunk of the given type
+func pngChunk(typ string, data []byte) []byte {
+	chunk := make([]byte, 8, 12+len(data))
+	binary.BigEndian.PutUint32(chunk, uint32(len(data)))
+	copy(chunk[4:], typ)
+	chunk = append(chunk, data...)
+	crc := make([]byte, 4)
+	binary.BigEndian.PutUint32(crc, crc32.ChecksumIEEE(chunk[4:]))
+	return append(chunk, crc...)
+}
+
+// animatedGIF returns a GIF with the given number of frames
+func animatedGIF(t *testing.T, frames int) []byte {
+	anim := &gif.GIF{}
+	for i := 0; i < frames; i++ {
+		anim.Image = append(anim.Image, image.NewPaletted(image.Rect(0, 0, 2, 3), color.Palette{color.Black, color.White}))
+		anim.Delay = append(anim.Delay, 0)
+	}
+	buf := &bytes.Buffer{}
+	require.NoError(t, gif.EncodeAll(buf, anim), "EncodeAll() should have succeed")
+	return buf.Bytes()
+}
+
+func TestImageInfo(t *testing.T) {
+	// The JPEG fixture has an EXIF orientation of 1, we change it to 6
+	// (rotated 90° clockwise)
+	rotatedJPG := readFixture(t, "black_pixel.jpg")
+	rotatedJPG[0x31] = 6
+
+	// We add a eXIf chunk after the IHDR chunk of the PNG fixture
+	png := readFixture(t, "black_pixel.png")
+	exif := []byte("MM\x00*\x00\x00\x00\x08\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01\x00\x03\x00\x00\x00\x00\x00\x00")
+	rotatedPNG := append(append(append([]byte{}, png[:33]...), pngChunk("eXIf", exif)...), png[33:]...)
+
+	testCases := []struct {
+		description string
+		content     []byte
+		expected    filetype.ImageMetadata
+	}{
+		{
+			"png", png,
+			filetype.ImageMetadata{MimeType: "image/png", Width: 1, Height: 1, ColorModel: filetype.ColorModelRGBA, BitDepth: 8, FrameCount: 1, Orientation: 1},
+		},
+		{
+			"png with orientation", rotatedPNG,
+			filetype.ImageMetadata{MimeType: "image/png", Width: 1, Height: 1, ColorModel: filetype.ColorModelRGBA, BitDepth: 8, FrameCount: 1, Orientation: 3},
+		},
+		{
+			"jpg", readFixture(t, "black_pixel.jpg"),
+			filetype.ImageMetadata{MimeType: "image/jpeg", Width: 1, Height: 1, ColorModel: filetype.ColorModelYCbCr, BitDepth: 8, FrameCount: 1, Orientation: 1},
+		},
+		{
+			"jpg with orientation", rotatedJPG,
+			filetype.ImageMetadata{MimeType: "image/jpeg", Width: 1, Height: 1, ColorModel: filetype.ColorModelYCbCr, BitDepth: 8, FrameCount: 1, Orientation: 6},
+		},
+		{
+			"gif", readFixture(t, "black_pixel.gif"),
+			filetype.ImageMetadata{MimeType: "image/gif", Width: 1, Height: 1, ColorModel: filetype.ColorModelPaletted, BitDepth: 8, FrameCount: 1, Orientation: 1},
+		},
+		{
+			"animated gif", animatedGIF(t, 3),
+			filetype.ImageMetadata{MimeType: "image/gif", Width: 2, Height: 3, ColorModel: filetype.ColorModelPaletted, BitDepth: 1, FrameCount: 3, Orientation: 1},
+		},
+		{
+			"bmp", readFixture(t, "black_pixel.bmp"),
+			filetype.ImageMetadata{MimeType: "image/bmp", Width: 1, Height: 1, ColorModel: filetype.ColorModelRGB, BitDepth: 24, FrameCount: 1, Orientation: 1},
+		},
+		{
+			"ico", readFixture(t, "black_pixel.ico"),
+			filetype.ImageMetadata{MimeType: "image/x-icon", Width: 1, Height: 1, ColorModel: filetype.ColorModelRGBA, BitDepth: 32, FrameCount: 1, Orientation: 1},
+		},
+		{
+			"svg", readFixture(t, "black_pixel.svg"),
+			filetype.ImageMetadata{MimeType: "image/svg+xml", Width: 1, Height: 1, FrameCount: 1, Orientation: 1},
+		},
+		{
+			"svg with viewBox", []byte(`<svg viewBox="0,0 20.5 10"></svg>`),
+			filetype.ImageMetadata{MimeType: "image/svg+xml", Width: 21, Height: 10, FrameCount: 1, Orientation: 1},
+		},
+		{
+			"tiff", readFixture(t, "black_pixel.tiff"),
+			filetype.ImageMetadata{MimeType: "image/tiff", Width: 1, Height: 1, ColorModel: filetype.ColorModelGray, BitDepth: 8, FrameCount: 1, Orientation: 1},
+		},
+		{
+			"webp", readFixture(t, "black_pixel.webp"),
+			filetype.ImageMetadata{MimeType: "image/webp", Width: 1, Height: 1, ColorModel: filetype.ColorModelRGBA, BitDepth: 8, FrameCount: 1, Orientation: 1},
+		},
+		{
+			"heic", heifFile("heic", "pict"),
+			filetype.ImageMetadata{MimeType: "image/heic", Width: 1, Height: 1, ColorModel: filetype.ColorModelYCbCr, FrameCount: 1, Orientation: 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			r := bytes.NewReader(tc.content)
+			info, err := filetype.ImageInfo(r)
+			require.NoError(t, err, "ImageInfo() should have succeed")
+			assert.Equal(t, tc.expected, *info, "invalid info")
+			assert.Equal(t, len(tc.content), r.Len(), "the reader should be at its original position")
+		})
+	}
+}
+
+func TestImageInfoInvalidFiles(t *testing.T) {
+	png := readFixture(t, "black_pixel.png")
+
+	testCases := []struct {
+		description string
+		content     []byte
+		expectedErr string
+	}{
+		{"pdf", readFixture(t, "black_pixel.pdf"), filetype.ErrMsgUnsupportedImageFormat},
+		{"truncated png", png[:20], filetype.ErrMsgInvalidImage},
+		{"empty file", []byte{}, filetype.ErrMsgEmptyFile},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			info, err := filetype.ImageInfo(bytes.NewReader(tc.content))
+			require.Error(t, err, "ImageInfo() should have failed")
+			assert.Equal(t, tc.expectedErr, err.Error(), "invalid error")
+			assert.Nil(t, info, "ImageInfo() should not have returned info")
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package filetype
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrMsgImageTooLarge represents the error message returned when an image
+// exceeds one of the limits set in ValidateOptions
+var ErrMsgImageTooLarge = "image exceeds the allowed limits"
+
+// ValidateOptions contains the limits an image must respect to be
+// considered valid. Those limits are checked before the image gets decoded,
+// to protect against decompression bombs.
+// A value of 0 means there are no limits.
+type ValidateOptions struct {
+	// MaxWidth is the maximum width of the image, in pixels
+	MaxWidth int `json:"max_width,omitempty" yaml:"max_width,omitempty"`
+
+	// MaxHeight is the maximum height of the image, in pixels
+	MaxHeight int `json:"max_height,omitempty" yaml:"max_height,omitempty"`
+
+	// MaxPixels is the maximum number of pixels of one frame of the image
+	// (width*height)
+	MaxPixels int64 `json:"max_pixels,omitempty" yaml:"max_pixels,omitempty"`
+
+	// MaxFrames is the maximum number of frames of an animated image
+	MaxFrames int `json:"max_frames,omitempty" yaml:"max_frames,omitempty"`
+
+	// MaxBytes is the maximum size of the file, in bytes
+	MaxBytes int64 `json:"max_bytes,omitempty" yaml:"max_bytes,omitempty"`
+}
+
+// hasDimensionLimits returns true if the image needs to be parsed to
+// check the limits
+func (opts *ValidateOptions) hasDimensionLimits() bool {
+	return opts.MaxWidth > 0 || opts.MaxHeight > 0 || opts.MaxPixels > 0 || opts.MaxFrames > 0
+}
+
+// check makes sure an image with the provided information respects
+// all the limits
+func (opts *ValidateOptions) check(info *ImageMetadata) error {
+	switch {
+	case opts.MaxWidth > 0 && info.Width > opts.MaxWidth,
+		opts.MaxHeight > 0 && info.Height > opts.MaxHeight,
+		opts.MaxPixels > 0 && int64(info.Width)*int64(info.Height) > opts.MaxPixels,
+		opts.MaxFrames > 0 && info.FrameCount > opts.MaxFrames:
+		return errors.New(ErrMsgImageTooLarge)
+	}
+	return nil
+}
+
+// checkSize makes sure the data remaining in the reader are not bigger
+// than MaxBytes. The reader will be put back to its original position.
+func (opts *ValidateOptions) checkSize(r io.ReadSeeker) (exceeded bool, err error) {
+	if opts.MaxBytes <= 0 {
+		return false, nil
+	}
+	s, err := newSection(r)
+	if err != nil {
+		return false, err
+	}
+	if _, err = r.Seek(s.start, io.SeekStart); err != nil {
+		return false, err
+	}
+	return s.size > opts.MaxBytes, nil
+}
+
+// checkLimits makes sure an image respects all the limits, without
+// decoding it.
+// decode is used to get the dimensions of the images that have no header
+// parsers nor registered format, in which case the image is decoded.
+// Can be nil.
+// The reader will be put back to its original position.
+func (opts *ValidateOptions) checkLimits(r io.ReadSeeker, decode ImageDecoder) (*ValidationResult, error) {
+	exceeded, err := opts.checkSize(r)
+	if err != nil {
+		return nil, err
+	}
+	if exceeded {
+		return newInvalidResult("", ReasonTooLarge, errors.New(ErrMsgImageTooLarge), -1), nil
+	}
+	if !opts.hasDimensionLimits() {
+		return &ValidationResult{Valid: true, Offset: -1}, nil
+	}
+
+	// We cannot make sure the image is safe to decode if we cannot parse it
+	info, err := imageInfo(r, decode)
+	switch err {
+	case nil:
+	case errMalformed:
+		return newInvalidResult("", ReasonCorrupted, err, -1), nil
+	case errUnsupportedFormat:
+		return newInvalidResult("", ReasonUnsupportedType, err, 0), nil
+	default:
+		return nil, err
+	}
+
+	if err = opts.check(info); err != nil {
+		return newInvalidResult(info.MimeType, ReasonTooLarge, err, -1), nil
+	}
+	return &ValidationResult{Valid: true, MimeType: info.MimeType, Offset: -1}, nil
+}
+
+// ValidateImageWithOptions checks if an image has a valid format, and
+// respects the provided limits.
+// An error is returned if the image exceeds the limits.
+func ValidateImageWithOptions(r io.ReadSeeker, decode ImageDecoder, opts *ValidateOptions) (bool, error) {
+	return ValidateImageContext(context.Background(), r, decode, opts)
+}
+
+// ValidateImageContext checks if an image has a valid format, and
+// respects the provided limits.
+// The decoding of the image will be aborted if ctx is done, in which case
+// the error of ctx is returned. opts can be nil.
+// An error is returned if the image exceeds the limits, or if the limits
+// cannot be checked because the image is invalid. If the type of the
+// image is not supported, decode is used to get its dimensions.
+func ValidateImageContext(ctx context.Context, r io.ReadSeeker, decode ImageDecoder, opts *ValidateOptions) (bool, error) {
+	if opts != nil {
+		res, err := opts.checkLimits(r, decode)
+		if err != nil {
+			return false, err
+		}
+		if !res.Valid {
+			return false, resultError(res)
+		}
+	}
+	return isValid(checkDecode(ctx, r, decode))
+}
+
+// IsImageWithOptions checks the specified reader contains an image that
+// respects the provided limits. The limits are checked before the image
+// gets validated. opts can be nil.
+// An error is returned if the image exceeds the limits.
+// Use CheckImageWithOptions() to get the reason why an image is not valid.
+func IsImageWithOptions(r io.ReadSeeker, opts *ValidateOptions) (isValid bool, mimeType string, err error) {
+	res, err := CheckImageWithOptions(r, opts)
+	if err != nil {
+		return false, "", err
+	}
+	switch res.Reason {
+	case ReasonUnsupportedType:
+		return false, "", resultError(res)
+	case ReasonTooLarge:
+		return false, res.MimeType, resultError(res)
+	}
+	return res.Valid, res.MimeType, nil
+}
+
+// resultError returns the error matching the reason of an invalid
+// result, or nil if the result is valid
+func resultError(res *ValidationResult) error {
+	if res.Valid {
+		return nil
+	}
+	switch res.Reason {
+	case ReasonTooLarge:
+		return res.Err
+	case ReasonUnsupportedType:
+		return errors.New(ErrMsgUnsupportedImageFormat)
+	}
+	return errors.New(ErrMsgInvalidImage)
+}
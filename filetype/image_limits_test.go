@@ -0,0 +1,170 @@
+package filetype_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/gif"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/Nivl/go-types/filetype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pngBomb returns a PNG file that claims to be 100000x100000 pixels
+func pngBomb() []byte {
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:4], 100000)
+	binary.BigEndian.PutUint32(ihdr[4:8], 100000)
+	ihdr[8] = 8 // bit depth
+	ihdr[9] = 6 // RGBA
+	return bytes.Join([][]byte{
+		[]byte("\x89PNG\x0D\x0A\x1A\x0A"),
+		pngChunk("IHDR", ihdr),
+		pngChunk("IDAT", []byte{0x78, 0x9c, 0x03, 0x00, 0x00, 0x00, 0x00, 0x01}),
+		pngChunk("IEND", nil),
+	}, nil)
+}
+
+// customDecoder decodes any file as a 10x10 image, to test the formats
+// that are not supported by the package
+func customDecoder(r io.Reader) (image.Image, error) {
+	if _, err := ioutil.ReadAll(r); err != nil {
+		return nil, err
+	}
+	return image.NewRGBA(image.Rect(0, 0, 10, 10)), nil
+}
+
+func TestValidateImageWithOptions(t *testing.T) {
+	// sugar
+	shouldBeValid := true
+
+	testCases := []struct {
+		description   string
+		content       []byte
+		decoder       filetype.ImageDecoder
+		opts          *filetype.ValidateOptions
+		shouldBeValid bool
+		expectedErr   string
+	}{
+		{
+			"no limits should work", readFixture(t, "black_pixel.png"), png.Decode,
+			&filetype.ValidateOptions{}, shouldBeValid, "",
+		},
+		{
+			"image within the limits should work", readFixture(t, "black_pixel.png"), png.Decode,
+			&filetype.ValidateOptions{MaxWidth: 1, MaxHeight: 1, MaxPixels: 1, MaxFrames: 1, MaxBytes: 86}, shouldBeValid, "",
+		},
+		{
+			"decompression bomb should fail", pngBomb(), png.Decode,
+			&filetype.ValidateOptions{MaxPixels: 4096 * 4096}, !shouldBeValid, filetype.ErrMsgImageTooLarge,
+		},
+		{
+			"too wide should fail", pngBomb(), png.Decode,
+			&filetype.ValidateOptions{MaxWidth: 4096}, !shouldBeValid, filetype.ErrMsgImageTooLarge,
+		},
+		{
+			"too high should fail", pngBomb(), png.Decode,
+			&filetype.ValidateOptions{MaxHeight: 4096}, !shouldBeValid, filetype.ErrMsgImageTooLarge,
+		},
+		{
+			"too many frames should fail", animatedGIF(t, 3), gif.Decode,
+			&filetype.ValidateOptions{MaxFrames: 2}, !shouldBeValid, filetype.ErrMsgImageTooLarge,
+		},
+		{
+			"too many bytes should fail", readFixture(t, "black_pixel.png"), png.Decode,
+			&filetype.ValidateOptions{MaxBytes: 85}, !shouldBeValid, filetype.ErrMsgImageTooLarge,
+		},
+		{
+			"invalid image should fail", readFixture(t, "black_pixel.png")[:20], png.Decode,
+			&filetype.ValidateOptions{MaxWidth: 4096}, !shouldBeValid, filetype.ErrMsgInvalidImage,
+		},
+		{
+			"custom format within the limits should work", []byte("custom image"), customDecoder,
+			&filetype.ValidateOptions{MaxWidth: 10, MaxPixels: 100}, shouldBeValid, "",
+		},
+		{
+			"custom format too large should fail", []byte("custom image"), customDecoder,
+			&filetype.ValidateOptions{MaxWidth: 5}, !shouldBeValid, filetype.ErrMsgImageTooLarge,
+		},
+		{
+			"invalid custom format should fail", []byte("custom image"), gif.Decode,
+			&filetype.ValidateOptions{MaxWidth: 10}, !shouldBeValid, filetype.ErrMsgInvalidImage,
+		},
+		{
+			"wrong decoder should fail", readFixture(t, "black_pixel.png"), gif.Decode,
+			&filetype.ValidateOptions{MaxWidth: 4096}, !shouldBeValid, "",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			r := bytes.NewReader(tc.content)
+			isValid, err := filetype.ValidateImageWithOptions(r, tc.decoder, tc.opts)
+			if tc.expectedErr != "" {
+				require.Error(t, err, "ValidateImageWithOptions() should have failed")
+				assert.Equal(t, tc.expectedErr, err.Error(), "invalid error")
+			} else {
+				assert.NoError(t, err, "ValidateImageWithOptions() should not have failed")
+			}
+			assert.Equal(t, tc.shouldBeValid, isValid, "ValidateImageWithOptions() did not return the expected value")
+			assert.Equal(t, len(tc.content), r.Len(), "the reader should be at its original position")
+		})
+	}
+}
+
+func TestValidateImageContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r := bytes.NewReader(readFixture(t, "black_pixel.png"))
+	isValid, err := filetype.ValidateImageContext(ctx, r, png.Decode, nil)
+	assert.Equal(t, context.Canceled, err, "ValidateImageContext() should have failed")
+	assert.False(t, isValid, "isValid should be false")
+	assert.Equal(t, int(r.Size()), r.Len(), "the reader should be at its original position")
+}
+
+func TestIsImageWithOptions(t *testing.T) {
+	// sugar
+	shouldBeValid := true
+
+	testCases := []struct {
+		description   string
+		content       []byte
+		opts          *filetype.ValidateOptions
+		shouldBeValid bool
+		expectedErr   string
+	}{
+		{"nil options should work", readFixture(t, "black_pixel.webp"), nil, shouldBeValid, ""},
+		{"webp within the limits should work", readFixture(t, "black_pixel.webp"), &filetype.ValidateOptions{MaxPixels: 1}, shouldBeValid, ""},
+		{"svg too large should fail", []byte(`<svg width="5000" height="10"/>`), &filetype.ValidateOptions{MaxWidth: 4096}, !shouldBeValid, filetype.ErrMsgImageTooLarge},
+		{"svg with huge dimensions should fail", []byte(`<svg width="1e30" height="1e30"/>`), &filetype.ValidateOptions{MaxWidth: 100, MaxPixels: 10000}, !shouldBeValid, ""},
+		{"svg with a huge viewBox should fail", []byte(`<svg viewBox="0 0 1e30 1e30"/>`), &filetype.ValidateOptions{MaxWidth: 100, MaxPixels: 10000}, !shouldBeValid, ""},
+		{"bomb should fail", pngBomb(), &filetype.ValidateOptions{MaxPixels: 4096 * 4096}, !shouldBeValid, filetype.ErrMsgImageTooLarge},
+		{"pdf should fail", readFixture(t, "black_pixel.pdf"), &filetype.ValidateOptions{}, !shouldBeValid, filetype.ErrMsgUnsupportedImageFormat},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			isValid, _, err := filetype.IsImageWithOptions(bytes.NewReader(tc.content), tc.opts)
+			if tc.expectedErr != "" {
+				require.Error(t, err, "IsImageWithOptions() should have failed")
+				assert.Equal(t, tc.expectedErr, err.Error(), "invalid error")
+			} else {
+				assert.NoError(t, err, "IsImageWithOptions() should not have failed")
+			}
+			assert.Equal(t, tc.shouldBeValid, isValid, "IsImageWithOptions() did not return the expected value")
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package filetype
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+// ErrMsgUnsupportedOutputFormat represents the error message returned
+// when trying to encode an image in a format that is not supported
+var ErrMsgUnsupportedOutputFormat = "unsupported output format"
+
+// ErrMsgInvalidQuality represents the error message returned when the
+// quality of an image is not between 1 and 100
+var ErrMsgInvalidQuality = "invalid quality, must be between 1 and 100"
+
+// imageEncoder represents a function that encodes an image
+type imageEncoder func(w io.Writer, img image.Image, opts *NormalizeOptions) error
+
+// imageEncoders contains the formats an image can be written in,
+// indexed by mimetype
+var imageEncoders = map[string]imageEncoder{
+	"image/jpeg": encodeJPEG,
+	"image/png":  encodePNG,
+	"image/gif":  encodeGIF,
+}
+
+// NormalizeOptions contains the options used to normalize an image
+type NormalizeOptions struct {
+	// Format is the mimetype of the output image (image/jpeg, image/png
+	// or image/gif). If empty, the format of the input image is kept, or
+	// PNG is used if the format of the input image cannot be encoded
+	Format string
+
+	// Quality is the quality of the JPEG images, from 1 to 100.
+	// Defaults to jpeg.DefaultQuality
+	Quality int
+
+	// Background is the color used to replace the transparent pixels
+	// when converting an image to a format that doesn't support
+	// transparency (JPEG). Defaults to white
+	Background color.Color
+
+	// Limits are checked before the image gets decoded, to protect
+	// against decompression bombs. Can be nil
+	Limits *ValidateOptions
+}
+
+// DecodeImage decodes an image of one of the formats supported by
+// IsImage() that has a decoder, and applies its EXIF orientation.
+// The mimetype of the image is returned with the image.
+// The list of supported formats can be changed using
+// RegisterImageFormat().
+// The reader will be put back to its original position.
+func DecodeImage(r io.ReadSeeker) (img image.Image, mimeType string, err error) {
+	info, err := ImageInfo(r)
+	if err != nil {
+		return nil, "", err
+	}
+	img, err = decodeOrientedImage(r, info)
+	if err != nil {
+		return nil, "", err
+	}
+	return img, info.MimeType, nil
+}
+
+// decodeOrientedImage decodes an image using the decoder registered for
+// its type, and applies its EXIF orientation.
+// The reader will be put back to its original position.
+func decodeOrientedImage(r io.ReadSeeker, info *ImageMetadata) (image.Image, error) {
+	format, found := LookupImageFormat(info.MimeType)
+	if !found || format.Decode == nil {
+		return nil, errors.New(ErrMsgUnsupportedImageFormat)
+	}
+	img, err := decodeImage(r, format.Decode)
+	if err != nil {
+		return nil, err
+	}
+	return applyOrientation(img, info.Orientation), nil
+}
+
+// Normalize decodes an image, applies its EXIF orientation, and writes
+// a clean copy of the image in w.
+// The image is re-encoded, so all its metadata (EXIF, GPS location, ICC
+// profile, comments, etc.) are removed. Animated GIFs stay animated if
+// they are written as GIF.
+// Nothing is written if the image is invalid, but w may contain partial
+// data if the encoding fails.
+// The information of the written image is returned.
+// The reader will be put back to its original position.
+func Normalize(r io.ReadSeeker, w io.Writer, opts *NormalizeOptions) (*ImageMetadata, error) {
+	if opts == nil {
+		opts = &NormalizeOptions{}
+	}
+	if opts.Quality < 0 || opts.Quality > 100 {
+		return nil, errors.New(ErrMsgInvalidQuality)
+	}
+
+	if opts.Limits != nil {
+		res, err := opts.Limits.checkLimits(r, nil)
+		if err != nil {
+			return nil, err
+		}
+		if !res.Valid {
+			return nil, resultError(res)
+		}
+	}
+
+	info, err := ImageInfo(r)
+	if err != nil {
+		return nil, err
+	}
+	outputType, encode, err := outputFormat(opts.Format, info.MimeType)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.MimeType == "image/gif" && outputType == "image/gif" && info.FrameCount > 1 {
+		return normalizeAnimatedGIF(r, w)
+	}
+
+	img, err := decodeOrientedImage(r, info)
+	if err != nil {
+		return nil, err
+	}
+	if err = encode(w, img, opts); err != nil {
+		return nil, err
+	}
+	return &ImageMetadata{
+		MimeType:    outputType,
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+		ColorModel:  colorModelName(img.ColorModel()),
+		FrameCount:  1,
+		Orientation: 1,
+	}, nil
+}
+
+// outputFormat returns the format an image should be written in, and
+// its encoder. The format of the input image is used if format is empty,
+// or PNG if the format of the input image cannot be encoded
+func outputFormat(format, inputType string) (string, imageEncoder, error) {
+	if format == "" {
+		format = inputType
+		if _, found := imageEncoders[format]; !found {
+			format = "image/png"
+		}
+	}
+	encode, found := imageEncoders[format]
+	if !found {
+		return "", nil, errors.New(ErrMsgUnsupportedOutputFormat)
+	}
+	return format, encode, nil
+}
+
+// normalizeAnimatedGIF decodes all the frames of a GIF image and
+// re-encodes them
+func normalizeAnimatedGIF(r io.ReadSeeker, w io.Writer) (*ImageMetadata, error) {
+	var g *gif.GIF
+	_, err := decodeImage(r, func(r io.Reader) (image.Image, error) {
+		var err error
+		g, err = gif.DecodeAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return g.Image[0], nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = gif.EncodeAll(w, g); err != nil {
+		return nil, err
+	}
+	return &ImageMetadata{
+		MimeType:    "image/gif",
+		Width:       g.Config.Width,
+		Height:      g.Config.Height,
+		ColorModel:  ColorModelPaletted,
+		FrameCount:  len(g.Image),
+		Orientation: 1,
+	}, nil
+}
+
+// decodeImage decodes an image and puts the reader back to its original
+// position
+func decodeImage(r io.ReadSeeker, decode ImageDecoder) (img image.Image, err error) {
+	initialPos, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	// revert the pointer back to its original position
+	defer func() {
+		_, seekErr := r.Seek(initialPos, io.SeekStart)
+		if err == nil && seekErr != nil {
+			img = nil
+			err = seekErr
+		}
+	}()
+
+	dr := &decodeReader{ctx: context.Background(), r: r}
+	img, err = decode(dr)
+	switch {
+	case dr.err != nil && dr.err != io.EOF:
+		// The decoder failed because we couldn't read the file
+		return nil, dr.err
+	case err != nil:
+		return nil, errors.New(ErrMsgInvalidImage)
+	}
+	return img, nil
+}
+
+// encodeJPEG encodes an image in JPEG, using the quality set in the
+// options. Transparent pixels are replaced by the background color
+func encodeJPEG(w io.Writer, img image.Image, opts *NormalizeOptions) error {
+	quality := opts.Quality
+	if quality == 0 {
+		quality = jpeg.DefaultQuality
+	}
+	if o, ok := img.(interface{ Opaque() bool }); !ok || !o.Opaque() {
+		background := opts.Background
+		if background == nil {
+			background = color.White
+		}
+		b := img.Bounds()
+		flattened := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+		draw.Draw(flattened, flattened.Rect, image.NewUniform(background), image.Point{}, draw.Src)
+		draw.Draw(flattened, flattened.Rect, img, b.Min, draw.Over)
+		img = flattened
+	}
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+}
+
+// encodePNG encodes an image in PNG
+func encodePNG(w io.Writer, img image.Image, opts *NormalizeOptions) error {
+	return png.Encode(w, img)
+}
+
+// encodeGIF encodes an image in GIF
+func encodeGIF(w io.Writer, img image.Image, opts *NormalizeOptions) error {
+	return gif.Encode(w, img, nil)
+}
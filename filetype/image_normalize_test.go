@@ -0,0 +1,299 @@
+package filetype_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/Nivl/go-types/filetype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// markedImage returns a 2x3 black image with a red pixel at (0, 0)
+func markedImage() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 3))
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 2; x++ {
+			img.Set(x, y, color.Black)
+		}
+	}
+	img.Set(0, 0, color.NRGBA{R: 255, A: 255})
+	return img
+}
+
+// exifOrientation returns EXIF data (big endian TIFF) containing the
+// provided orientation
+func exifOrientation(orientation int) []byte {
+	exif := []byte("MM\x00*\x00\x00\x00\x08\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01\x00\x00\x00\x00\x00\x00\x00\x00")
+	binary.BigEndian.PutUint16(exif[18:], uint16(orientation))
+	return exif
+}
+
+// orientedPNG returns a PNG image with the provided EXIF orientation
+func orientedPNG(t *testing.T, img image.Image, orientation int) []byte {
+	buf := &bytes.Buffer{}
+	require.NoError(t, png.Encode(buf, img), "Encode() should have succeed")
+	content := buf.Bytes()
+	// The eXIf chunk is added right after the IHDR chunk
+	return append(append(append([]byte{}, content[:33]...), pngChunk("eXIf", exifOrientation(orientation))...), content[33:]...)
+}
+
+// orientedJPEG returns a JPEG image with the provided EXIF orientation
+func orientedJPEG(t *testing.T, img image.Image, orientation int) []byte {
+	buf := &bytes.Buffer{}
+	require.NoError(t, jpeg.Encode(buf, img, nil), "Encode() should have succeed")
+	content := buf.Bytes()
+
+	exif := append([]byte("Exif\x00\x00"), exifOrientation(orientation)...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(exif)+2))
+	app1 = append(app1, exif...)
+	// The APP1 segment is added right after the SOI marker
+	return append(append(append([]byte{}, content[:2]...), app1...), content[2:]...)
+}
+
+func TestDecodeImageOrientation(t *testing.T) {
+	testCases := []struct {
+		orientation    int
+		expectedBounds image.Rectangle
+		expectedRed    image.Point
+	}{
+		{1, image.Rect(0, 0, 2, 3), image.Pt(0, 0)},
+		{2, image.Rect(0, 0, 2, 3), image.Pt(1, 0)},
+		{3, image.Rect(0, 0, 2, 3), image.Pt(1, 2)},
+		{4, image.Rect(0, 0, 2, 3), image.Pt(0, 2)},
+		{5, image.Rect(0, 0, 3, 2), image.Pt(0, 0)},
+		{6, image.Rect(0, 0, 3, 2), image.Pt(2, 0)},
+		{7, image.Rect(0, 0, 3, 2), image.Pt(2, 1)},
+		{8, image.Rect(0, 0, 3, 2), image.Pt(0, 1)},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(strconv.Itoa(tc.orientation), func(t *testing.T) {
+			t.Parallel()
+
+			img, mimeType, err := filetype.DecodeImage(bytes.NewReader(orientedPNG(t, markedImage(), tc.orientation)))
+			require.NoError(t, err, "DecodeImage() should have succeed")
+			assert.Equal(t, "image/png", mimeType)
+			require.Equal(t, tc.expectedBounds, img.Bounds())
+			for y := 0; y < tc.expectedBounds.Dy(); y++ {
+				for x := 0; x < tc.expectedBounds.Dx(); x++ {
+					r, _, _, _ := img.At(x, y).RGBA()
+					assert.Equal(t, image.Pt(x, y) == tc.expectedRed, r > 0, "invalid color for (%d, %d)", x, y)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeImageErrors(t *testing.T) {
+	content := readFixture(t, "black_pixel.png")
+
+	testCases := []struct {
+		description string
+		content     []byte
+		expectedErr string
+	}{
+		{"no decoder", readFixture(t, "black_pixel.webp"), filetype.ErrMsgUnsupportedImageFormat},
+		{"not an image", readFixture(t, "black_pixel.pdf"), filetype.ErrMsgUnsupportedImageFormat},
+		{"corrupted data", append(append([]byte{}, content[:len(content)-20]...), bytes.Repeat([]byte{0}, 20)...), filetype.ErrMsgInvalidImage},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			img, mimeType, err := filetype.DecodeImage(bytes.NewReader(tc.content))
+			require.Error(t, err, "DecodeImage() should have failed")
+			assert.Equal(t, tc.expectedErr, err.Error())
+			assert.Nil(t, img)
+			assert.Empty(t, mimeType)
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	transparent := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+
+	testCases := []struct {
+		description string
+		content     []byte
+		opts        *filetype.NormalizeOptions
+		expected    *filetype.ImageMetadata
+	}{
+		{
+			"rotated jpeg",
+			orientedJPEG(t, markedImage(), 6),
+			nil,
+			&filetype.ImageMetadata{MimeType: "image/jpeg", Width: 3, Height: 2, ColorModel: filetype.ColorModelRGBA, FrameCount: 1, Orientation: 1},
+		},
+		{
+			"png to jpeg",
+			orientedPNG(t, transparent, 1),
+			&filetype.NormalizeOptions{Format: "image/jpeg", Quality: 100},
+			&filetype.ImageMetadata{MimeType: "image/jpeg", Width: 4, Height: 4, ColorModel: filetype.ColorModelRGBA, FrameCount: 1, Orientation: 1},
+		},
+		{
+			"jpeg to png",
+			orientedJPEG(t, markedImage(), 1),
+			&filetype.NormalizeOptions{Format: "image/png"},
+			&filetype.ImageMetadata{MimeType: "image/png", Width: 2, Height: 3, ColorModel: filetype.ColorModelYCbCr, FrameCount: 1, Orientation: 1},
+		},
+		{
+			"animated gif",
+			animatedGIF(t, 3),
+			nil,
+			&filetype.ImageMetadata{MimeType: "image/gif", Width: 2, Height: 3, ColorModel: filetype.ColorModelPaletted, FrameCount: 3, Orientation: 1},
+		},
+		{
+			"animated gif to png",
+			animatedGIF(t, 3),
+			&filetype.NormalizeOptions{Format: "image/png"},
+			&filetype.ImageMetadata{MimeType: "image/png", Width: 2, Height: 3, ColorModel: filetype.ColorModelPaletted, FrameCount: 1, Orientation: 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			r := bytes.NewReader(tc.content)
+			out := &bytes.Buffer{}
+			info, err := filetype.Normalize(r, out, tc.opts)
+			require.NoError(t, err, "Normalize() should have succeed")
+			assert.Equal(t, tc.expected, info)
+
+			pos, err := r.Seek(0, io.SeekCurrent)
+			require.NoError(t, err, "Seek() should have succeed")
+			assert.Equal(t, int64(0), pos, "the reader should have been put back to its original position")
+
+			// The output must be a valid image without metadata
+			assert.NotContains(t, out.String(), "Exif", "the EXIF data should have been removed")
+			assert.NotContains(t, out.String(), "eXIf", "the EXIF data should have been removed")
+			outInfo, err := filetype.ImageInfo(bytes.NewReader(out.Bytes()))
+			require.NoError(t, err, "ImageInfo() should have succeed")
+			assert.Equal(t, tc.expected.MimeType, outInfo.MimeType)
+			assert.Equal(t, tc.expected.Width, outInfo.Width)
+			assert.Equal(t, tc.expected.Height, outInfo.Height)
+			assert.Equal(t, tc.expected.FrameCount, outInfo.FrameCount)
+			assert.Equal(t, 1, outInfo.Orientation)
+		})
+	}
+}
+
+func TestNormalizeBackground(t *testing.T) {
+	transparent := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+
+	testCases := []struct {
+		description string
+		background  color.Color
+		expected    color.Gray
+	}{
+		{"default", nil, color.Gray{Y: 255}},
+		{"black", color.Black, color.Gray{Y: 0}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			out := &bytes.Buffer{}
+			opts := &filetype.NormalizeOptions{Format: "image/jpeg", Quality: 100, Background: tc.background}
+			_, err := filetype.Normalize(bytes.NewReader(orientedPNG(t, transparent, 1)), out, opts)
+			require.NoError(t, err, "Normalize() should have succeed")
+
+			img, err := jpeg.Decode(out)
+			require.NoError(t, err, "Decode() should have succeed")
+			gray := color.GrayModel.Convert(img.At(0, 0)).(color.Gray)
+			assert.InDelta(t, tc.expected.Y, gray.Y, 2)
+		})
+	}
+}
+
+func TestNormalizeErrors(t *testing.T) {
+	testCases := []struct {
+		description string
+		content     []byte
+		opts        *filetype.NormalizeOptions
+		expectedErr string
+	}{
+		{
+			"invalid quality",
+			readFixture(t, "black_pixel.jpg"),
+			&filetype.NormalizeOptions{Quality: 101},
+			filetype.ErrMsgInvalidQuality,
+		},
+		{
+			"unsupported output format",
+			readFixture(t, "black_pixel.jpg"),
+			&filetype.NormalizeOptions{Format: "image/bmp"},
+			filetype.ErrMsgUnsupportedOutputFormat,
+		},
+		{
+			"no decoder",
+			readFixture(t, "black_pixel.webp"),
+			nil,
+			filetype.ErrMsgUnsupportedImageFormat,
+		},
+		{
+			"limits exceeded",
+			pngBomb(),
+			&filetype.NormalizeOptions{Limits: &filetype.ValidateOptions{MaxPixels: 1000}},
+			filetype.ErrMsgImageTooLarge,
+		},
+		{
+			"limits on a corrupted image",
+			readFixture(t, "black_pixel.png")[:20],
+			&filetype.NormalizeOptions{Limits: &filetype.ValidateOptions{MaxPixels: 1000}},
+			filetype.ErrMsgInvalidImage,
+		},
+		{
+			"limits on an unsupported image",
+			readFixture(t, "black_pixel.pdf"),
+			&filetype.NormalizeOptions{Limits: &filetype.ValidateOptions{MaxPixels: 1000}},
+			filetype.ErrMsgUnsupportedImageFormat,
+		},
+		{
+			"invalid gif",
+			animatedGIF(t, 3)[:60],
+			nil,
+			filetype.ErrMsgInvalidImage,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			out := &bytes.Buffer{}
+			info, err := filetype.Normalize(bytes.NewReader(tc.content), out, tc.opts)
+			require.Error(t, err, "Normalize() should have failed")
+			assert.Equal(t, tc.expectedErr, err.Error())
+			assert.Nil(t, info)
+			assert.Zero(t, out.Len(), "nothing should have been written")
+		})
+	}
+}
+
+func TestNormalizeGIFConversion(t *testing.T) {
+	out := &bytes.Buffer{}
+	info, err := filetype.Normalize(bytes.NewReader(readFixture(t, "black_pixel.png")), out, &filetype.NormalizeOptions{Format: "image/gif"})
+	require.NoError(t, err, "Normalize() should have succeed")
+	assert.Equal(t, "image/gif", info.MimeType)
+
+	_, err = gif.Decode(out)
+	require.NoError(t, err, "the output should be a valid GIF")
+}
@@ -0,0 +1,283 @@
+package filetype
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrMsgUnsupportedPerceptualHash represents the error message returned
+// for an unknown perceptual hash algorithm
+var ErrMsgUnsupportedPerceptualHash = "unsupported perceptual hash algorithm"
+
+// ErrMsgInvalidPerceptualHash represents the error message returned when
+// a perceptual hash cannot be parsed
+var ErrMsgInvalidPerceptualHash = "invalid perceptual hash"
+
+// ErrMsgPerceptualHashMismatch represents the error message returned
+// when comparing hashes computed with different algorithms
+var ErrMsgPerceptualHashMismatch = "cannot compare perceptual hashes of different algorithms"
+
+// PerceptualHashAlgorithm represents an algorithm used to compute the
+// perceptual hash of an image
+type PerceptualHashAlgorithm string
+
+// List of all the supported perceptual hash algorithms
+const (
+	// AHash (average hash) compares each pixel of an 8x8 grayscale
+	// version of the image to the average color. It's fast, but has a lot
+	// of false positives
+	AHash PerceptualHashAlgorithm = "ahash"
+
+	// DHash (difference hash) compares the adjacent pixels of a 9x8
+	// grayscale version of the image. It's fast and resists well to
+	// color and brightness changes
+	DHash PerceptualHashAlgorithm = "dhash"
+
+	// PHash uses the low frequencies of the discrete cosine transform of
+	// a 32x32 grayscale version of the image. It's the slowest but most
+	// accurate algorithm
+	PHash PerceptualHashAlgorithm = "phash"
+)
+
+// perceptualHashers contains the functions computing the hashes, indexed
+// by algorithm
+var perceptualHashers = map[PerceptualHashAlgorithm]func(img image.Image) uint64{
+	AHash: averageHash,
+	DHash: differenceHash,
+	PHash: dctHash,
+}
+
+// PerceptualHash represents the perceptual hash of an image. Two
+// images that look alike have hashes with a small Hamming distance, even
+// if they have been resized or re-encoded.
+//
+// The hash is stored in SQL and JSON as a string ("phash:8f373714acfcf4d0").
+// Use Bits() to store it as a BIGINT and compute the distances in SQL.
+type PerceptualHash struct {
+	Algorithm PerceptualHashAlgorithm
+	Hash      uint64
+}
+
+// NewPerceptualHash computes the perceptual hash of an image
+func NewPerceptualHash(img image.Image, algo PerceptualHashAlgorithm) (*PerceptualHash, error) {
+	hasher, found := perceptualHashers[algo]
+	if !found {
+		return nil, errors.New(ErrMsgUnsupportedPerceptualHash)
+	}
+	if img.Bounds().Empty() {
+		return nil, errors.New(ErrMsgInvalidImage)
+	}
+	return &PerceptualHash{Algorithm: algo, Hash: hasher(img)}, nil
+}
+
+// ImagePerceptualHash decodes an image and computes its perceptual hash.
+// The EXIF orientation of the image is applied before computing the
+// hash.
+// The list of supported formats can be changed using
+// RegisterImageFormat().
+// The reader will be put back to its original position.
+func ImagePerceptualHash(r io.ReadSeeker, algo PerceptualHashAlgorithm) (*PerceptualHash, error) {
+	if _, found := perceptualHashers[algo]; !found {
+		return nil, errors.New(ErrMsgUnsupportedPerceptualHash)
+	}
+	img, _, err := DecodeImage(r)
+	if err != nil {
+		return nil, err
+	}
+	return NewPerceptualHash(img, algo)
+}
+
+// ParsePerceptualHash parses a hash returned by PerceptualHash.String()
+func ParsePerceptualHash(s string) (*PerceptualHash, error) {
+	h := &PerceptualHash{}
+	if err := h.ScanString(s); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Distance returns the Hamming distance between two hashes, which is
+// the number of bits that are different. 0 means the images are
+// identical (or nearly), and the images are usually considered similar
+// below 10
+func (h *PerceptualHash) Distance(other *PerceptualHash) (int, error) {
+	if h.Algorithm != other.Algorithm {
+		return 0, errors.New(ErrMsgPerceptualHashMismatch)
+	}
+	return bits.OnesCount64(h.Hash ^ other.Hash), nil
+}
+
+// Bits returns the hash as a signed integer, to be stored in a BIGINT
+// column. The distance can then be computed in SQL with
+// bit_count(a # b) in Postgres, or BIT_COUNT(a ^ b) in MySQL
+func (h *PerceptualHash) Bits() int64 {
+	return int64(h.Hash)
+}
+
+// String implements the fmt.Stringer interface
+// https://golang.org/pkg/fmt/#Stringer
+func (h PerceptualHash) String() string {
+	return fmt.Sprintf("%s:%016x", h.Algorithm, h.Hash)
+}
+
+// ScanString parses a hash returned by PerceptualHash.String()
+func (h *PerceptualHash) ScanString(s string) error {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 || len(parts[1]) != 16 {
+		return errors.New(ErrMsgInvalidPerceptualHash)
+	}
+	algo := PerceptualHashAlgorithm(parts[0])
+	if _, found := perceptualHashers[algo]; !found {
+		return errors.New(ErrMsgUnsupportedPerceptualHash)
+	}
+	hash, err := strconv.ParseUint(parts[1], 16, 64)
+	if err != nil {
+		return errors.New(ErrMsgInvalidPerceptualHash)
+	}
+	h.Algorithm = algo
+	h.Hash = hash
+	return nil
+}
+
+// Value returns a value that the database can handle
+// https://golang.org/pkg/database/sql/driver/#Valuer
+func (h *PerceptualHash) Value() (driver.Value, error) {
+	if h == nil {
+		return nil, nil
+	}
+	return h.String(), nil
+}
+
+// Scan assigns a value from a database driver
+// https://golang.org/pkg/database/sql/#Scanner
+func (h *PerceptualHash) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return h.ScanString(v)
+	case []byte:
+		return h.ScanString(string(v))
+	}
+	return errors.New(ErrMsgInvalidPerceptualHash)
+}
+
+// MarshalJSON returns a valid json representation of the struct
+// https://golang.org/pkg/encoding/json/#Marshaler
+func (h PerceptualHash) MarshalJSON() ([]byte, error) {
+	return json.Marshal(h.String())
+}
+
+// UnmarshalJSON tries to parse a json data into a valid struct
+// https://golang.org/pkg/encoding/json/#Unmarshaler
+func (h *PerceptualHash) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return h.ScanString(s)
+}
+
+// averageHash computes the aHash of an image
+func averageHash(img image.Image) uint64 {
+	pixels := grayscalePixels(img, 8, 8)
+	var sum float64
+	for _, p := range pixels {
+		sum += p
+	}
+	return hashBits(pixels, sum/float64(len(pixels)))
+}
+
+// differenceHash computes the dHash of an image
+func differenceHash(img image.Image) uint64 {
+	pixels := grayscalePixels(img, 9, 8)
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if pixels[y*9+x] < pixels[y*9+x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// dctHash computes the pHash of an image
+func dctHash(img image.Image) uint64 {
+	const size, lowSize = 32, 8
+	pixels := grayscalePixels(img, size, size)
+
+	// 2D DCT-II, computed on the rows then on the columns. We only need
+	// the low frequencies
+	rows := make([]float64, size*lowSize)
+	for y := 0; y < size; y++ {
+		for u := 0; u < lowSize; u++ {
+			rows[y*lowSize+u] = dctCoefficient(func(x int) float64 { return pixels[y*size+x] }, u, size)
+		}
+	}
+	low := make([]float64, lowSize*lowSize)
+	for u := 0; u < lowSize; u++ {
+		for v := 0; v < lowSize; v++ {
+			low[v*lowSize+u] = dctCoefficient(func(y int) float64 { return rows[y*lowSize+u] }, v, size)
+		}
+	}
+
+	// The first coefficient is the average color, it is excluded from
+	// the median to not skew the result. There are 63 coefficients left,
+	// so the median is the middle one
+	sorted := make([]float64, len(low)-1)
+	copy(sorted, low[1:])
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+	return hashBits(low, median)
+}
+
+// dctCoefficient computes the k-th coefficient of the DCT-II of n values
+func dctCoefficient(value func(i int) float64, k, n int) float64 {
+	var sum float64
+	for i := 0; i < n; i++ {
+		sum += value(i) * math.Cos(math.Pi/float64(n)*(float64(i)+0.5)*float64(k))
+	}
+	return sum
+}
+
+// hashBits returns a hash where each bit is set if the matching value is
+// greater than the threshold
+func hashBits(values []float64, threshold float64) uint64 {
+	var hash uint64
+	for _, v := range values {
+		hash <<= 1
+		if v > threshold {
+			hash |= 1
+		}
+	}
+	return hash
+}
+
+// grayscalePixels resizes an image and returns the luminance of its
+// pixels, row by row
+func grayscalePixels(img image.Image, width, height int) []float64 {
+	resized := Resize(img, width, height, Bilinear)
+	pixels := make([]float64, 0, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := y*resized.Stride + x*4
+			r, g, b := float64(resized.Pix[i]), float64(resized.Pix[i+1]), float64(resized.Pix[i+2])
+			pixels = append(pixels, 0.299*r+0.587*g+0.114*b)
+		}
+	}
+	return pixels
+}
@@ -0,0 +1,229 @@
+package filetype_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math/bits"
+	"testing"
+
+	"github.com/Nivl/go-types/filetype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// perceptualHashAlgorithms contains all the supported algorithms
+var perceptualHashAlgorithms = []filetype.PerceptualHashAlgorithm{filetype.AHash, filetype.DHash, filetype.PHash}
+
+// photo returns a 128x96 image with smooth shapes, that looks like a
+// photo once compressed
+func photo() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 128, 96))
+	for y := 0; y < 96; y++ {
+		for x := 0; x < 128; x++ {
+			c := color.NRGBA{R: uint8(x * 2), G: uint8(255 - y*2), B: 128, A: 255}
+			if (x-40)*(x-40)+(y-50)*(y-50) < 600 {
+				c = color.NRGBA{R: 250, G: 240, B: 20, A: 255}
+			}
+			if x > 90 && y < 30 {
+				c = color.NRGBA{R: 10, G: 10, B: 60, A: 255}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestPerceptualHashSimilarImages(t *testing.T) {
+	original := photo()
+
+	compressed := &bytes.Buffer{}
+	require.NoError(t, jpeg.Encode(compressed, original, &jpeg.Options{Quality: 30}), "Encode() should have succeed")
+	reencoded, err := jpeg.Decode(compressed)
+	require.NoError(t, err, "Decode() should have succeed")
+
+	brighter := image.NewNRGBA(original.Rect)
+	for i := range original.Pix {
+		brighter.Pix[i] = original.Pix[i]
+		if i%4 != 3 && original.Pix[i] < 235 {
+			brighter.Pix[i] += 20
+		}
+	}
+
+	similar := map[string]image.Image{
+		"resized":    filetype.Resize(original, 50, 0, filetype.Lanczos),
+		"upscaled":   filetype.Resize(original, 300, 0, filetype.Bilinear),
+		"re-encoded": reencoded,
+		"brighter":   brighter,
+	}
+	different := map[string]image.Image{
+		"other image": testPattern(),
+		"cropped":     original.SubImage(image.Rect(64, 0, 128, 96)),
+	}
+
+	for _, algo := range perceptualHashAlgorithms {
+		algo := algo
+		t.Run(string(algo), func(t *testing.T) {
+			t.Parallel()
+
+			hash, err := filetype.NewPerceptualHash(original, algo)
+			require.NoError(t, err, "NewPerceptualHash() should have succeed")
+
+			for name, img := range similar {
+				other, err := filetype.NewPerceptualHash(img, algo)
+				require.NoError(t, err, "NewPerceptualHash() should have succeed")
+				distance, err := hash.Distance(other)
+				require.NoError(t, err, "Distance() should have succeed")
+				assert.True(t, distance <= 10, "the %s image should be similar, got a distance of %d", name, distance)
+			}
+			for name, img := range different {
+				other, err := filetype.NewPerceptualHash(img, algo)
+				require.NoError(t, err, "NewPerceptualHash() should have succeed")
+				distance, err := hash.Distance(other)
+				require.NoError(t, err, "Distance() should have succeed")
+				assert.True(t, distance > 10, "the %s image should be different, got a distance of %d", name, distance)
+			}
+		})
+	}
+}
+
+func TestPerceptualHashSolidImage(t *testing.T) {
+	img := solidImage(10, 10, color.White)
+	for _, algo := range []filetype.PerceptualHashAlgorithm{filetype.AHash, filetype.DHash} {
+		hash, err := filetype.NewPerceptualHash(img, algo)
+		require.NoError(t, err, "NewPerceptualHash() should have succeed")
+		assert.Equal(t, uint64(0), hash.Hash, "a solid image should have a hash of 0 with %s", algo)
+	}
+}
+
+func TestPerceptualHashDCTMedian(t *testing.T) {
+	hash, err := filetype.NewPerceptualHash(photo(), filetype.PHash)
+	require.NoError(t, err, "NewPerceptualHash() should have succeed")
+
+	// The first bit is the average color. The median of the 63 other
+	// coefficients is one of them, so 31 of them are above it
+	assert.Equal(t, 31, bits.OnesCount64(hash.Hash&^(1<<63)), "invalid number of bits above the median")
+}
+
+func TestNewPerceptualHashErrors(t *testing.T) {
+	hash, err := filetype.NewPerceptualHash(photo(), "md5")
+	require.Error(t, err, "NewPerceptualHash() should have failed")
+	assert.Equal(t, filetype.ErrMsgUnsupportedPerceptualHash, err.Error())
+	assert.Nil(t, hash)
+
+	hash, err = filetype.NewPerceptualHash(image.NewRGBA(image.Rectangle{}), filetype.PHash)
+	require.Error(t, err, "NewPerceptualHash() should have failed")
+	assert.Equal(t, filetype.ErrMsgInvalidImage, err.Error())
+	assert.Nil(t, hash)
+}
+
+func TestImagePerceptualHash(t *testing.T) {
+	// The orientation is applied before computing the hash
+	rotated := orientedPNG(t, photo(), 6)
+	expected, err := filetype.NewPerceptualHash(applyRotation(photo()), filetype.DHash)
+	require.NoError(t, err, "NewPerceptualHash() should have succeed")
+
+	hash, err := filetype.ImagePerceptualHash(bytes.NewReader(rotated), filetype.DHash)
+	require.NoError(t, err, "ImagePerceptualHash() should have succeed")
+	assert.Equal(t, expected, hash)
+
+	hash, err = filetype.ImagePerceptualHash(bytes.NewReader(readFixture(t, "black_pixel.pdf")), filetype.DHash)
+	require.Error(t, err, "ImagePerceptualHash() should have failed")
+	assert.Equal(t, filetype.ErrMsgUnsupportedImageFormat, err.Error())
+	assert.Nil(t, hash)
+
+	hash, err = filetype.ImagePerceptualHash(bytes.NewReader(rotated), "md5")
+	require.Error(t, err, "ImagePerceptualHash() should have failed")
+	assert.Equal(t, filetype.ErrMsgUnsupportedPerceptualHash, err.Error())
+	assert.Nil(t, hash)
+}
+
+// applyRotation rotates an image by 90 degrees clockwise
+func applyRotation(img *image.NRGBA) *image.NRGBA {
+	b := img.Bounds()
+	rotated := image.NewNRGBA(image.Rect(0, 0, b.Dy(), b.Dx()))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			rotated.Set(b.Dy()-1-y, x, img.At(x, y))
+		}
+	}
+	return rotated
+}
+
+func TestPerceptualHashDistance(t *testing.T) {
+	a := &filetype.PerceptualHash{Algorithm: filetype.DHash, Hash: 0xF0}
+	b := &filetype.PerceptualHash{Algorithm: filetype.DHash, Hash: 0x0F}
+	distance, err := a.Distance(b)
+	require.NoError(t, err, "Distance() should have succeed")
+	assert.Equal(t, 8, distance)
+
+	_, err = a.Distance(&filetype.PerceptualHash{Algorithm: filetype.AHash, Hash: 0xF0})
+	require.Error(t, err, "Distance() should have failed")
+	assert.Equal(t, filetype.ErrMsgPerceptualHashMismatch, err.Error())
+}
+
+func TestPerceptualHashEncoding(t *testing.T) {
+	hash := &filetype.PerceptualHash{Algorithm: filetype.PHash, Hash: 0x8f373714acfcf4d0}
+	assert.Equal(t, "phash:8f373714acfcf4d0", hash.String())
+	assert.Equal(t, int64(-8126966440625638192), hash.Bits())
+
+	parsed, err := filetype.ParsePerceptualHash(hash.String())
+	require.NoError(t, err, "ParsePerceptualHash() should have succeed")
+	assert.Equal(t, hash, parsed)
+
+	data, err := json.Marshal(hash)
+	require.NoError(t, err, "Marshal() should have succeed")
+	assert.Equal(t, `"phash:8f373714acfcf4d0"`, string(data))
+	unmarshaled := &filetype.PerceptualHash{}
+	require.NoError(t, json.Unmarshal(data, unmarshaled), "Unmarshal() should have succeed")
+	assert.Equal(t, hash, unmarshaled)
+
+	value, err := hash.Value()
+	require.NoError(t, err, "Value() should have succeed")
+	assert.Equal(t, "phash:8f373714acfcf4d0", value)
+	for _, src := range []interface{}{value, []byte("phash:8f373714acfcf4d0")} {
+		scanned := &filetype.PerceptualHash{}
+		require.NoError(t, scanned.Scan(src), "Scan() should have succeed")
+		assert.Equal(t, hash, scanned)
+	}
+
+	var nilHash *filetype.PerceptualHash
+	value, err = nilHash.Value()
+	require.NoError(t, err, "Value() should have succeed")
+	assert.Nil(t, value)
+}
+
+func TestParsePerceptualHashInvalid(t *testing.T) {
+	testCases := []struct {
+		description string
+		hash        string
+		expectedErr string
+	}{
+		{"empty", "", filetype.ErrMsgInvalidPerceptualHash},
+		{"no algorithm", "8f373714acfcf4d0", filetype.ErrMsgInvalidPerceptualHash},
+		{"unknown algorithm", "md5:8f373714acfcf4d0", filetype.ErrMsgUnsupportedPerceptualHash},
+		{"short hash", "phash:8f37", filetype.ErrMsgInvalidPerceptualHash},
+		{"invalid hex", "phash:8f373714acfcf4zz", filetype.ErrMsgInvalidPerceptualHash},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			hash, err := filetype.ParsePerceptualHash(tc.hash)
+			require.Error(t, err, "ParsePerceptualHash() should have failed")
+			assert.Equal(t, tc.expectedErr, err.Error())
+			assert.Nil(t, hash)
+		})
+	}
+}
+
+func TestPerceptualHashScanInvalid(t *testing.T) {
+	hash := &filetype.PerceptualHash{}
+	require.Error(t, hash.Scan(42), "Scan() should have failed")
+	require.NoError(t, hash.Scan(nil), "Scan() should have succeed")
+	require.Error(t, json.Unmarshal([]byte(`42`), hash), "Unmarshal() should have failed")
+}
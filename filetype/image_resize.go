@@ -0,0 +1,293 @@
+package filetype
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// ResampleFilter represents the algorithm used to compute the pixels of
+// a resized image
+type ResampleFilter string
+
+// List of all the supported filters
+const (
+	// NearestNeighbor is the fastest filter, but produces pixelated
+	// images
+	NearestNeighbor ResampleFilter = "nearest"
+
+	// Bilinear is a fast filter that produces smooth images
+	Bilinear ResampleFilter = "bilinear"
+
+	// Lanczos is the slowest filter, but produces the sharpest images.
+	// This is the default filter
+	Lanczos ResampleFilter = "lanczos"
+)
+
+// resampleKernel contains the convolution kernel of a filter
+type resampleKernel struct {
+	// support is the radius of the kernel when the image is upscaled
+	support float64
+	weight  func(x float64) float64
+}
+
+// resampleKernels contains the kernels of the filters, indexed by filter
+var resampleKernels = map[ResampleFilter]resampleKernel{
+	Bilinear: {support: 1, weight: func(x float64) float64 {
+		x = math.Abs(x)
+		if x < 1 {
+			return 1 - x
+		}
+		return 0
+	}},
+	Lanczos: {support: 3, weight: func(x float64) float64 {
+		x = math.Abs(x)
+		if x < 3 {
+			return sinc(x) * sinc(x/3)
+		}
+		return 0
+	}},
+}
+
+// resampleContribution contains the weights of the source pixels used
+// to compute a pixel of the resized image
+type resampleContribution struct {
+	start   int
+	weights []float64
+}
+
+// IsValid checks that the filter is supported
+func (f ResampleFilter) IsValid() bool {
+	_, found := resampleKernels[f]
+	return found || f == NearestNeighbor
+}
+
+// Resize returns a copy of the image resized to the provided dimensions.
+// If width or height is 0, the aspect ratio of the image is preserved.
+// Lanczos is used if the filter is empty or unknown.
+// The returned image starts at (0, 0)
+func Resize(img image.Image, width, height int, filter ResampleFilter) *image.RGBA {
+	b := img.Bounds()
+	if b.Empty() || width < 0 || height < 0 {
+		return image.NewRGBA(image.Rectangle{})
+	}
+	switch {
+	case width == 0 && height == 0:
+		width, height = b.Dx(), b.Dy()
+	case width == 0:
+		width = maxInt(1, int(math.Round(float64(height)*float64(b.Dx())/float64(b.Dy()))))
+	case height == 0:
+		height = maxInt(1, int(math.Round(float64(width)*float64(b.Dy())/float64(b.Dx()))))
+	}
+
+	src := toRGBA(img)
+	if filter == NearestNeighbor {
+		return resizeNearest(src, width, height)
+	}
+	kernel, found := resampleKernels[filter]
+	if !found {
+		kernel = resampleKernels[Lanczos]
+	}
+	return resample(src, width, height, kernel)
+}
+
+// Fit returns a copy of the image scaled down to fit in the provided
+// dimensions, preserving its aspect ratio. The image is not upscaled.
+// If width or height is 0, only the other dimension is used.
+// The returned image starts at (0, 0)
+func Fit(img image.Image, width, height int, filter ResampleFilter) *image.RGBA {
+	b := img.Bounds()
+	if b.Empty() || width < 0 || height < 0 || (width == 0 && height == 0) {
+		return image.NewRGBA(image.Rectangle{})
+	}
+	if width == 0 {
+		width = b.Dx()
+	}
+	if height == 0 {
+		height = b.Dy()
+	}
+	if b.Dx() <= width && b.Dy() <= height {
+		return Crop(img, b)
+	}
+
+	// We use the dimension that needs to be reduced the most
+	if float64(width)/float64(b.Dx()) < float64(height)/float64(b.Dy()) {
+		return Resize(img, width, 0, filter)
+	}
+	return Resize(img, 0, height, filter)
+}
+
+// Fill returns a copy of the image scaled and cropped to the provided
+// dimensions, preserving its aspect ratio. The image is scaled to cover
+// the whole area, and the parts that overflow are cropped around the
+// center.
+// The returned image starts at (0, 0)
+func Fill(img image.Image, width, height int, filter ResampleFilter) *image.RGBA {
+	b := img.Bounds()
+	if b.Empty() || width <= 0 || height <= 0 {
+		return image.NewRGBA(image.Rectangle{})
+	}
+
+	// We use the dimension that needs to be enlarged the most, and crop
+	// the other one
+	var resized *image.RGBA
+	if float64(width)/float64(b.Dx()) > float64(height)/float64(b.Dy()) {
+		resized = Resize(img, width, maxInt(height, int(math.Round(float64(width)*float64(b.Dy())/float64(b.Dx())))), filter)
+	} else {
+		resized = Resize(img, maxInt(width, int(math.Round(float64(height)*float64(b.Dx())/float64(b.Dy())))), height, filter)
+	}
+	x := (resized.Rect.Dx() - width) / 2
+	y := (resized.Rect.Dy() - height) / 2
+	return Crop(resized, image.Rect(x, y, x+width, y+height))
+}
+
+// Crop returns a copy of the provided area of the image. The area is
+// clipped to the bounds of the image.
+// The returned image starts at (0, 0)
+func Crop(img image.Image, area image.Rectangle) *image.RGBA {
+	area = area.Intersect(img.Bounds())
+	dst := image.NewRGBA(image.Rect(0, 0, area.Dx(), area.Dy()))
+	draw.Draw(dst, dst.Rect, img, area.Min, draw.Src)
+	return dst
+}
+
+// resizeNearest resizes an image by using the closest pixel of the
+// source image
+func resizeNearest(src *image.RGBA, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	scaleX := float64(src.Rect.Dx()) / float64(width)
+	scaleY := float64(src.Rect.Dy()) / float64(height)
+	for y := 0; y < height; y++ {
+		sy := int((float64(y) + 0.5) * scaleY)
+		for x := 0; x < width; x++ {
+			sx := int((float64(x) + 0.5) * scaleX)
+			si := sy*src.Stride + sx*4
+			di := y*dst.Stride + x*4
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+	return dst
+}
+
+// resample resizes an image using a convolution kernel. The image is
+// resized horizontally first, then vertically
+func resample(src *image.RGBA, width, height int, kernel resampleKernel) *image.RGBA {
+	srcW, srcH := src.Rect.Dx(), src.Rect.Dy()
+
+	// horizontal pass, the values are kept as float to not lose
+	// precision between the passes
+	tmp := make([]float64, width*srcH*4)
+	contributions := resampleContributions(srcW, width, kernel)
+	for y := 0; y < srcH; y++ {
+		for x, c := range contributions {
+			var sum [4]float64
+			for i, w := range c.weights {
+				si := y*src.Stride + (c.start+i)*4
+				for ch := 0; ch < 4; ch++ {
+					sum[ch] += w * float64(src.Pix[si+ch])
+				}
+			}
+			copy(tmp[(y*width+x)*4:], sum[:])
+		}
+	}
+
+	// vertical pass
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	contributions = resampleContributions(srcH, height, kernel)
+	for y, c := range contributions {
+		for x := 0; x < width; x++ {
+			var sum [4]float64
+			for i, w := range c.weights {
+				ti := ((c.start+i)*width + x) * 4
+				for ch := 0; ch < 4; ch++ {
+					sum[ch] += w * tmp[ti+ch]
+				}
+			}
+			// The colors are premultiplied, so they cannot be greater
+			// than the alpha
+			di := y*dst.Stride + x*4
+			alpha := clampUint8(sum[3])
+			for ch := 0; ch < 3; ch++ {
+				dst.Pix[di+ch] = minUint8(clampUint8(sum[ch]), alpha)
+			}
+			dst.Pix[di+3] = alpha
+		}
+	}
+	return dst
+}
+
+// resampleContributions computes the weights of the source pixels for
+// each pixel of the resized dimension
+func resampleContributions(srcLen, dstLen int, kernel resampleKernel) []resampleContribution {
+	scale := float64(srcLen) / float64(dstLen)
+	// When downscaling, the kernel is stretched to use all the source
+	// pixels
+	filterScale := math.Max(scale, 1)
+	support := kernel.support * filterScale
+
+	contributions := make([]resampleContribution, dstLen)
+	for i := range contributions {
+		center := (float64(i) + 0.5) * scale
+		start := maxInt(0, int(math.Floor(center-support)))
+		end := minInt(srcLen, int(math.Ceil(center+support)))
+
+		weights := make([]float64, 0, end-start)
+		var sum float64
+		for j := start; j < end; j++ {
+			w := kernel.weight((float64(j) + 0.5 - center) / filterScale)
+			weights = append(weights, w)
+			sum += w
+		}
+		if sum != 0 {
+			for j := range weights {
+				weights[j] /= sum
+			}
+		}
+		contributions[i] = resampleContribution{start: start, weights: weights}
+	}
+	return contributions
+}
+
+// sinc returns the normalized sinc function of x
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	x *= math.Pi
+	return math.Sin(x) / x
+}
+
+// clampUint8 rounds a value and clamps it between 0 and 255
+func clampUint8(v float64) uint8 {
+	switch {
+	case v <= 0:
+		return 0
+	case v >= 255:
+		return 255
+	}
+	return uint8(v + 0.5)
+}
+
+// minUint8 returns the smallest of the two provided values
+func minUint8(a, b uint8) uint8 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// minInt returns the smallest of the two provided values
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// maxInt returns the biggest of the two provided values
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
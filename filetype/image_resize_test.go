@@ -0,0 +1,190 @@
+package filetype_test
+
+import (
+	"bytes"
+	"flag"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"path"
+	"testing"
+
+	"github.com/Nivl/go-types/filetype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// updateGolden is used to regenerate the golden images of the fixtures
+// directory: go test ./filetype -run Golden -update-golden
+var updateGolden = flag.Bool("update-golden", false, "update the golden images of fixtures/golden")
+
+// testPattern returns a 64x48 image containing gradients, a checkerboard
+// and transparent pixels, which makes the differences between the
+// filters visible
+func testPattern() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 48))
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 64; x++ {
+			c := color.NRGBA{R: uint8(x * 4), G: uint8(y * 5), A: 255}
+			if (x/4+y/4)%2 == 0 {
+				c.B = 255
+			}
+			if x >= 48 && y >= 32 {
+				c.A = uint8((x - 48) * 16)
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// solidImage returns an image filled with the provided color
+func solidImage(width, height int, c color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+// assertGolden compares an image with a golden image of the fixtures
+// directory. A difference of 1 per channel is tolerated to allow
+// different rounding on different architectures
+func assertGolden(t *testing.T, name string, img image.Image) {
+	filepath := path.Join("fixtures", "golden", name)
+	if *updateGolden {
+		buf := &bytes.Buffer{}
+		require.NoError(t, png.Encode(buf, img), "Encode() should have succeed")
+		require.NoError(t, ioutil.WriteFile(filepath, buf.Bytes(), 0644), "WriteFile() should have succeed")
+	}
+
+	golden, err := png.Decode(bytes.NewReader(readFixture(t, path.Join("golden", name))))
+	require.NoError(t, err, "Decode() should have succeed")
+	require.Equal(t, golden.Bounds(), img.Bounds(), "the dimensions don't match the golden image")
+
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			expected := color.NRGBAModel.Convert(golden.At(x, y)).(color.NRGBA)
+			actual := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if !assert.InDeltaSlice(t,
+				[]int{int(expected.R), int(expected.G), int(expected.B), int(expected.A)},
+				[]int{int(actual.R), int(actual.G), int(actual.B), int(actual.A)},
+				1, "invalid pixel at (%d, %d)", x, y) {
+				return
+			}
+		}
+	}
+}
+
+func TestResizeGolden(t *testing.T) {
+	src := testPattern()
+
+	testCases := []struct {
+		golden string
+		img    image.Image
+	}{
+		{"downscale_nearest.png", filetype.Resize(src, 24, 18, filetype.NearestNeighbor)},
+		{"downscale_bilinear.png", filetype.Resize(src, 24, 18, filetype.Bilinear)},
+		{"downscale_lanczos.png", filetype.Resize(src, 24, 18, filetype.Lanczos)},
+		{"upscale_nearest.png", filetype.Resize(src, 96, 72, filetype.NearestNeighbor)},
+		{"upscale_bilinear.png", filetype.Resize(src, 96, 72, filetype.Bilinear)},
+		{"upscale_lanczos.png", filetype.Resize(src, 96, 72, filetype.Lanczos)},
+		{"fit_lanczos.png", filetype.Fit(src, 20, 20, filetype.Lanczos)},
+		{"fill_lanczos.png", filetype.Fill(src, 20, 20, filetype.Lanczos)},
+		{"crop.png", filetype.Crop(src, image.Rect(40, 24, 64, 48))},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.golden, func(t *testing.T) {
+			assertGolden(t, tc.golden, tc.img)
+		})
+	}
+}
+
+func TestResizeDimensions(t *testing.T) {
+	src := testPattern()
+	// A sub image doesn't start at (0, 0)
+	sub := src.SubImage(image.Rect(10, 10, 30, 20))
+
+	testCases := []struct {
+		description string
+		img         image.Image
+		expected    image.Rectangle
+	}{
+		{"resize", filetype.Resize(src, 10, 10, filetype.Bilinear), image.Rect(0, 0, 10, 10)},
+		{"resize without width", filetype.Resize(src, 0, 24, filetype.Bilinear), image.Rect(0, 0, 32, 24)},
+		{"resize without height", filetype.Resize(src, 16, 0, filetype.Bilinear), image.Rect(0, 0, 16, 12)},
+		{"resize without dimensions", filetype.Resize(src, 0, 0, filetype.Bilinear), image.Rect(0, 0, 64, 48)},
+		{"resize to a line", filetype.Resize(src, 1000, 0, filetype.Bilinear), image.Rect(0, 0, 1000, 750)},
+		{"resize with negative dimensions", filetype.Resize(src, -1, 10, filetype.Bilinear), image.Rectangle{}},
+		{"resize sub image", filetype.Resize(sub, 0, 5, filetype.Lanczos), image.Rect(0, 0, 10, 5)},
+		{"fit width", filetype.Fit(src, 32, 32, filetype.Lanczos), image.Rect(0, 0, 32, 24)},
+		{"fit height", filetype.Fit(src, 100, 12, filetype.Lanczos), image.Rect(0, 0, 16, 12)},
+		{"fit without height", filetype.Fit(src, 16, 0, filetype.Lanczos), image.Rect(0, 0, 16, 12)},
+		{"fit smaller image", filetype.Fit(src, 100, 100, filetype.Lanczos), image.Rect(0, 0, 64, 48)},
+		{"fit without dimensions", filetype.Fit(src, 0, 0, filetype.Lanczos), image.Rectangle{}},
+		{"fill", filetype.Fill(src, 30, 10, filetype.Lanczos), image.Rect(0, 0, 30, 10)},
+		{"fill upscale", filetype.Fill(src, 10, 100, filetype.Lanczos), image.Rect(0, 0, 10, 100)},
+		{"fill without height", filetype.Fill(src, 10, 0, filetype.Lanczos), image.Rectangle{}},
+		{"crop", filetype.Crop(src, image.Rect(10, 10, 20, 30)), image.Rect(0, 0, 10, 20)},
+		{"crop outside the image", filetype.Crop(src, image.Rect(60, 40, 100, 100)), image.Rect(0, 0, 4, 8)},
+		{"crop sub image", filetype.Crop(sub, image.Rect(0, 0, 15, 15)), image.Rect(0, 0, 5, 5)},
+		{"empty image", filetype.Resize(image.NewRGBA(image.Rectangle{}), 10, 10, filetype.Lanczos), image.Rectangle{}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, tc.img.Bounds())
+		})
+	}
+}
+
+func TestResizeSolidColor(t *testing.T) {
+	c := color.NRGBA{R: 200, G: 100, B: 50, A: 255}
+	src := solidImage(37, 23, c)
+	filters := []filetype.ResampleFilter{filetype.NearestNeighbor, filetype.Bilinear, filetype.Lanczos, ""}
+
+	for _, filter := range filters {
+		filter := filter
+		t.Run(string(filter), func(t *testing.T) {
+			t.Parallel()
+
+			for _, size := range []int{5, 37, 80} {
+				img := filetype.Resize(src, size, size, filter)
+				for y := 0; y < size; y++ {
+					for x := 0; x < size; x++ {
+						require.Equal(t, c, color.NRGBAModel.Convert(img.At(x, y)), "invalid pixel at (%d, %d) for size %d", x, y, size)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestResizeIdentity(t *testing.T) {
+	src := testPattern()
+	expected := filetype.Crop(src, src.Bounds())
+
+	for _, filter := range []filetype.ResampleFilter{filetype.NearestNeighbor, filetype.Bilinear, filetype.Lanczos} {
+		filter := filter
+		t.Run(string(filter), func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, expected, filetype.Resize(src, 64, 48, filter))
+		})
+	}
+}
+
+func TestResampleFilterIsValid(t *testing.T) {
+	assert.True(t, filetype.NearestNeighbor.IsValid())
+	assert.True(t, filetype.Bilinear.IsValid())
+	assert.True(t, filetype.Lanczos.IsValid())
+	assert.False(t, filetype.ResampleFilter("bicubic").IsValid())
+	assert.False(t, filetype.ResampleFilter("").IsValid())
+}
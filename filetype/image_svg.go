@@ -0,0 +1,156 @@
+package filetype
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// svgNamespace is the XML namespace of the SVG elements
+const svgNamespace = "http://www.w3.org/2000/svg"
+
+// IsSVG validates an SVG file.
+// The file must be a well-formed XML document with <svg> as root element.
+// Files that contain a DTD with entities are considered invalid, to
+// prevent XXE and billion laughs attacks.
+func IsSVG(r io.ReadSeeker) (bool, error) {
+	return validateHeader(r, parseSVG)
+}
+
+// parseSVG parses a whole SVG document and returns the size of the image
+// using the width and height attributes of the root element, or its
+// viewBox.
+// The size will be 0 if it's not set or if it's not set in pixels
+func parseSVG(s *section) (*imageHeader, error) {
+	r, err := s.reader()
+	if err != nil {
+		return nil, err
+	}
+
+	info := &imageHeader{}
+	err = walkSVG(r, func(tok xml.Token) error {
+		root, ok := tok.(xml.StartElement)
+		if !ok || info.frames > 0 {
+			return nil
+		}
+		info.frames = 1
+
+		var viewBox []string
+		for _, attr := range root.Attr {
+			var err error
+			switch attr.Name.Local {
+			case "width":
+				info.width, err = svgLength(attr.Value)
+			case "height":
+				info.height, err = svgLength(attr.Value)
+			case "viewBox":
+				viewBox = strings.Fields(strings.Replace(attr.Value, ",", " ", -1))
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if len(viewBox) == 4 {
+			var err error
+			if info.width == 0 {
+				if info.width, err = svgLength(viewBox[2]); err != nil {
+					return err
+				}
+			}
+			if info.height == 0 {
+				if info.height, err = svgLength(viewBox[3]); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if _, isSyntaxErr := err.(*xml.SyntaxError); isSyntaxErr {
+		return nil, errMalformed
+	}
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// svgLength returns the value in pixels of an SVG length, or 0 if the
+// length is invalid or uses a relative unit.
+// errMalformed is returned if the length is not a finite number, or is
+// too big to be the size of an image
+func svgLength(value string) (int, error) {
+	value = strings.TrimSuffix(strings.TrimSpace(value), "px")
+	length, err := strconv.ParseFloat(value, 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		return 0, nil
+	}
+	if err != nil || math.IsNaN(length) || math.IsInf(length, 0) || length > math.MaxInt32 {
+		return 0, errMalformed
+	}
+	if length < 0 {
+		return 0, nil
+	}
+	return int(math.Ceil(length)), nil
+}
+
+// walkSVG parses an SVG document and calls fn for each token of the
+// document. fn can be nil.
+// errMalformed or a *xml.SyntaxError is returned if the document is not
+// a valid SVG
+func walkSVG(r io.Reader, fn func(tok xml.Token) error) error {
+	d := xml.NewDecoder(r)
+	d.Strict = true
+	// We don't want the decoder to replace the entities by anything, we want
+	// it to fail instead
+	d.Entity = map[string]string{}
+
+	depth := 0
+	rootFound := false
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.Directive:
+			// The internal subset of a DTD is where the entities are declared
+			if bytes.Contains(t, []byte("ENTITY")) || bytes.IndexByte(t, '[') != -1 {
+				return errMalformed
+			}
+		case xml.StartElement:
+			if depth == 0 {
+				// only one root is allowed, and it has to be <svg>
+				if rootFound || t.Name.Local != "svg" || (t.Name.Space != "" && t.Name.Space != svgNamespace) {
+					return errMalformed
+				}
+				rootFound = true
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			if depth == 0 && len(bytes.TrimSpace(t)) > 0 {
+				return errMalformed
+			}
+		}
+
+		if fn != nil {
+			if err := fn(tok); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !rootFound {
+		return errMalformed
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package filetype_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Nivl/go-types/filetype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSVG(t *testing.T) {
+	testFileValidator(t, filetype.IsSVG, "black_pixel.svg")
+}
+
+func TestIsSVGContent(t *testing.T) {
+	// sugar
+	shouldBeValid := true
+
+	testCases := []struct {
+		description   string
+		content       string
+		shouldBeValid bool
+	}{
+		{"no namespace should work", `<svg width="1" height="1"></svg>`, shouldBeValid},
+		{"doctype without subset should work", `<!DOCTYPE svg PUBLIC "-//W3C//DTD SVG 1.1//EN" "http://www.w3.org/Graphics/SVG/1.1/DTD/svg11.dtd"><svg/>`, shouldBeValid},
+		{"comments should work", `<!-- comment --><svg/><!-- comment -->`, shouldBeValid},
+		{"html should fail", `<html><body></body></html>`, !shouldBeValid},
+		{"other namespace should fail", `<svg xmlns="http://www.w3.org/1999/xhtml"/>`, !shouldBeValid},
+		{"multiple roots should fail", `<svg/><svg/>`, !shouldBeValid},
+		{"text after the root should fail", `<svg/>text`, !shouldBeValid},
+		{"unclosed tag should fail", `<svg><rect></svg>`, !shouldBeValid},
+		{"empty document should fail", ``, !shouldBeValid},
+		{"XXE should fail", `<?xml version="1.0"?><!DOCTYPE svg [<!ENTITY xxe SYSTEM "file:///etc/passwd">]><svg>&xxe;</svg>`, !shouldBeValid},
+		{"billion laughs should fail", `<!DOCTYPE svg [<!ENTITY lol "lol"><!ENTITY lol2 "&lol;&lol;">]><svg>&lol2;</svg>`, !shouldBeValid},
+		{"unknown entities should fail", `<svg>&nbsp;</svg>`, !shouldBeValid},
+		{"huge width should fail", `<svg width="1e30" height="1"/>`, !shouldBeValid},
+		{"huge height should fail", `<svg width="1" height="1e30"/>`, !shouldBeValid},
+		{"huge viewBox should fail", `<svg viewBox="0 0 1e30 1e30"/>`, !shouldBeValid},
+		{"out of range width should fail", `<svg width="1e400"/>`, !shouldBeValid},
+		{"infinite width should fail", `<svg width="Inf"/>`, !shouldBeValid},
+		{"NaN width should fail", `<svg width="NaN"/>`, !shouldBeValid},
+		{"relative width should work", `<svg width="100%"/>`, shouldBeValid},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			isValid, err := filetype.IsSVG(bytes.NewReader([]byte(tc.content)))
+			assert.NoError(t, err, "IsSVG should not have failed")
+			assert.Equal(t, tc.shouldBeValid, isValid, "IsSVG did not return the expected value")
+		})
+	}
+}
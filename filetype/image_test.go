@@ -26,6 +26,11 @@ func TestIsImage(t *testing.T) {
 		{"gif should work", "black_pixel.gif", !shouldFail, "image/gif"},
 		{"png should work", "black_pixel.png", !shouldFail, "image/png"},
 		{"jpg should work", "black_pixel.jpg", !shouldFail, "image/jpeg"},
+		{"bmp should work", "black_pixel.bmp", !shouldFail, "image/bmp"},
+		{"ico should work", "black_pixel.ico", !shouldFail, "image/x-icon"},
+		{"svg should work", "black_pixel.svg", !shouldFail, "image/svg+xml"},
+		{"tiff should work", "black_pixel.tiff", !shouldFail, "image/tiff"},
+		{"webp should work", "black_pixel.webp", !shouldFail, "image/webp"},
 		{"pdf should fail", "black_pixel.pdf", shouldFail, ""},
 		{"LICENSE should fail", "LICENSE", shouldFail, ""},
 	}
@@ -58,7 +63,9 @@ func TestIsImageMimeFail(t *testing.T) {
 	defer mockCtrl.Finish()
 
 	reader := NewMockReadSeeker(mockCtrl)
+	reader.EXPECT().Seek(int64(0), io.SeekCurrent).Return(int64(0), nil)
 	reader.EXPECT().Read(gomock.Any()).Return(0, errors.New("read failed"))
+	reader.EXPECT().Seek(int64(0), io.SeekStart).Return(int64(0), nil)
 
 	isValid, mime, err := filetype.IsImage(reader)
 	assert.Error(t, err, "IsImage() should have failed")
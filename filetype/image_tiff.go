@@ -0,0 +1,269 @@
+package filetype
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// List of the TIFF tags we use
+const (
+	tiffTagImageWidth      = 256
+	tiffTagImageLength     = 257
+	tiffTagBitsPerSample   = 258
+	tiffTagPhotometric     = 262
+	tiffTagOrientation     = 274
+	tiffTagSamplesPerPixel = 277
+	tiffTagStripOffsets    = 273
+	tiffTagStripByteCounts = 279
+	tiffTagTileOffsets     = 324
+	tiffTagTileByteCounts  = 325
+)
+
+// tiffTypeSizes contains the size in bytes of each TIFF field type
+var tiffTypeSizes = map[uint16]int64{
+	1:  1, // BYTE
+	2:  1, // ASCII
+	3:  2, // SHORT
+	4:  4, // LONG
+	5:  8, // RATIONAL
+	6:  1, // SBYTE
+	7:  1, // UNDEFINED
+	8:  2, // SSHORT
+	9:  4, // SLONG
+	10: 8, // SRATIONAL
+	11: 4, // FLOAT
+	12: 8, // DOUBLE
+	13: 4, // IFD
+}
+
+// tiffEntry represents an entry of an Image File Directory
+type tiffEntry struct {
+	typ   uint16
+	count int64
+	// offset is the position of the value in the file
+	offset int64
+}
+
+// IsTIFF validates the structure of a TIFF file.
+// The image data are not decoded, use RegisterImageFormat() with
+// golang.org/x/image/tiff to fully decode the files instead.
+func IsTIFF(r io.ReadSeeker) (bool, error) {
+	return validateHeader(r, parseTIFF)
+}
+
+// parseTIFF parses the first Image File Directory of a TIFF file and makes
+// sure the image data are inside the file
+// https://www.adobe.io/open/standards/TIFF.html
+func parseTIFF(s *section) (*imageHeader, error) {
+	order, ifdOffset, err := readTIFFHeader(s)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := readTIFFDirectory(s, order, ifdOffset)
+	if err != nil {
+		return nil, err
+	}
+
+	width, err := tiffValue(s, order, entries[tiffTagImageWidth])
+	if err != nil {
+		return nil, err
+	}
+	height, err := tiffValue(s, order, entries[tiffTagImageLength])
+	if err != nil {
+		return nil, err
+	}
+	if width == 0 || height == 0 {
+		return nil, errMalformed
+	}
+
+	// The image is either stored in strips or in tiles
+	offsetsTag, countsTag := uint16(tiffTagStripOffsets), uint16(tiffTagStripByteCounts)
+	if _, ok := entries[offsetsTag]; !ok {
+		offsetsTag, countsTag = tiffTagTileOffsets, tiffTagTileByteCounts
+	}
+	offsets, err := tiffValues(s, order, entries[offsetsTag])
+	if err != nil {
+		return nil, err
+	}
+	counts, err := tiffValues(s, order, entries[countsTag])
+	if err != nil {
+		return nil, err
+	}
+	if len(offsets) == 0 || len(offsets) != len(counts) {
+		return nil, errMalformed
+	}
+	for i := range offsets {
+		if offsets[i]+counts[i] > s.size {
+			return nil, errTruncated
+		}
+	}
+
+	info := &imageHeader{
+		width:       int(width),
+		height:      int(height),
+		colorModel:  tiffColorModel(s, order, entries),
+		orientation: 1,
+	}
+	// Optional fields
+	if bitDepth, err := tiffValue(s, order, entries[tiffTagBitsPerSample]); err == nil {
+		info.bitDepth = int(bitDepth)
+	}
+	if orientation, err := tiffValue(s, order, entries[tiffTagOrientation]); err == nil {
+		info.orientation = int(orientation)
+	}
+	if info.frames, err = countTIFFDirectories(s, order, ifdOffset); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// readTIFFHeader parses the header of a TIFF file, and returns the byte
+// order of the file and the offset of its first directory
+func readTIFFHeader(s *section) (order binary.ByteOrder, ifdOffset int64, err error) {
+	header, err := s.read(0, 8)
+	if err != nil {
+		return nil, 0, err
+	}
+	switch {
+	case bytes.Equal(header[:4], []byte("II*\x00")):
+		order = binary.LittleEndian
+	case bytes.Equal(header[:4], []byte("MM\x00*")):
+		order = binary.BigEndian
+	default:
+		return nil, 0, errMalformed
+	}
+	return order, int64(order.Uint32(header[4:8])), nil
+}
+
+// tiffColorModel returns the color model of an image using its
+// PhotometricInterpretation
+func tiffColorModel(s *section, order binary.ByteOrder, entries map[uint16]*tiffEntry) string {
+	photometric, err := tiffValue(s, order, entries[tiffTagPhotometric])
+	if err != nil {
+		return ""
+	}
+	// Images with more than 3 samples per pixel have an alpha channel
+	samples, err := tiffValue(s, order, entries[tiffTagSamplesPerPixel])
+	if err != nil {
+		samples = 1
+	}
+
+	switch photometric {
+	case 0, 1:
+		if samples > 1 {
+			return ColorModelGrayAlpha
+		}
+		return ColorModelGray
+	case 2:
+		if samples > 3 {
+			return ColorModelRGBA
+		}
+		return ColorModelRGB
+	case 3:
+		return ColorModelPaletted
+	case 5:
+		return ColorModelCMYK
+	case 6:
+		return ColorModelYCbCr
+	}
+	return ""
+}
+
+// countTIFFDirectories returns the number of Image File Directories of a
+// file (one per page)
+func countTIFFDirectories(s *section, order binary.ByteOrder, offset int64) (int, error) {
+	visited := map[int64]bool{}
+	for offset != 0 {
+		// We don't want to loop forever on a malicious file
+		if visited[offset] {
+			return 0, errMalformed
+		}
+		visited[offset] = true
+
+		count, err := s.readUint16(offset, order)
+		if err != nil {
+			return 0, err
+		}
+		next, err := s.readUint32(offset+2+int64(count)*12, order)
+		if err != nil {
+			return 0, err
+		}
+		offset = int64(next)
+	}
+	return len(visited), nil
+}
+
+// readTIFFDirectory returns the entries of the Image File Directory
+// located at the given offset, indexed by tag
+func readTIFFDirectory(s *section, order binary.ByteOrder, offset int64) (map[uint16]*tiffEntry, error) {
+	if offset < 8 {
+		return nil, errMalformed
+	}
+	count, err := s.readUint16(offset, order)
+	if err != nil {
+		return nil, err
+	}
+	// Each entry is 12 bytes long, and the list is followed by the
+	// offset of the next IFD
+	data, err := s.read(offset+2, int64(count)*12+4)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[uint16]*tiffEntry, count)
+	for i := 0; i < int(count); i++ {
+		raw := data[i*12 : (i+1)*12]
+		entry := &tiffEntry{
+			typ:    order.Uint16(raw[2:4]),
+			count:  int64(order.Uint32(raw[4:8])),
+			offset: offset + 2 + int64(i)*12 + 8,
+		}
+		// Values that don't fit in 4 bytes are stored elsewhere in the file
+		if size, ok := tiffTypeSizes[entry.typ]; ok && size*entry.count > 4 {
+			entry.offset = int64(order.Uint32(raw[8:12]))
+			if entry.offset+size*entry.count > s.size {
+				return nil, errTruncated
+			}
+		}
+		entries[order.Uint16(raw[0:2])] = entry
+	}
+	return entries, nil
+}
+
+// tiffValue returns the first value of an entry
+func tiffValue(s *section, order binary.ByteOrder, entry *tiffEntry) (int64, error) {
+	values, err := tiffValues(s, order, entry)
+	if err != nil {
+		return 0, err
+	}
+	if len(values) == 0 {
+		return 0, errMalformed
+	}
+	return values[0], nil
+}
+
+// tiffValues returns all the values of a SHORT or LONG entry
+func tiffValues(s *section, order binary.ByteOrder, entry *tiffEntry) ([]int64, error) {
+	if entry == nil {
+		return nil, errMalformed
+	}
+	size, ok := tiffTypeSizes[entry.typ]
+	if !ok || (entry.typ != 3 && entry.typ != 4) {
+		return nil, errMalformed
+	}
+	data, err := s.read(entry.offset, size*entry.count)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]int64, entry.count)
+	for i := range values {
+		if size == 2 {
+			values[i] = int64(order.Uint16(data[i*2:]))
+		} else {
+			values[i] = int64(order.Uint32(data[i*4:]))
+		}
+	}
+	return values, nil
+}
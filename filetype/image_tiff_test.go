@@ -0,0 +1,11 @@
+package filetype_test
+
+import (
+	"testing"
+
+	"github.com/Nivl/go-types/filetype"
+)
+
+func TestIsTIFF(t *testing.T) {
+	testFileValidator(t, filetype.IsTIFF, "black_pixel.tiff")
+}
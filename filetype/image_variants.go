@@ -0,0 +1,200 @@
+package filetype
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+)
+
+// ErrMsgInvalidVariant represents the error message returned when a
+// variant is not valid
+var ErrMsgInvalidVariant = "invalid variant"
+
+// ResizeMode represents how an image is resized to the dimensions of
+// a variant
+type ResizeMode string
+
+// List of all the supported resize modes
+const (
+	// ResizeModeFit scales the image down to fit in the dimensions,
+	// preserving its aspect ratio. This is the default mode
+	ResizeModeFit ResizeMode = "fit"
+
+	// ResizeModeFill scales the image to cover the dimensions, and crops
+	// what overflows, preserving its aspect ratio
+	ResizeModeFill ResizeMode = "fill"
+
+	// ResizeModeResize scales the image to the exact dimensions, without
+	// preserving its aspect ratio
+	ResizeModeResize ResizeMode = "resize"
+)
+
+// Variant represents a resized version of an image, like a thumbnail
+type Variant struct {
+	// Name identifies the variant
+	Name string `json:"name" yaml:"name"`
+
+	// Width is the width of the variant, in pixels. 0 means the width is
+	// computed using the height (not supported by ResizeModeFill)
+	Width int `json:"width,omitempty" yaml:"width,omitempty"`
+
+	// Height is the height of the variant, in pixels. 0 means the height
+	// is computed using the width (not supported by ResizeModeFill)
+	Height int `json:"height,omitempty" yaml:"height,omitempty"`
+
+	// Mode is how the image is resized. Defaults to ResizeModeFit
+	Mode ResizeMode `json:"mode,omitempty" yaml:"mode,omitempty"`
+
+	// Filter is the algorithm used to resize the image. Defaults to
+	// Lanczos
+	Filter ResampleFilter `json:"filter,omitempty" yaml:"filter,omitempty"`
+
+	// Format is the mimetype of the variant (image/jpeg, image/png or
+	// image/gif). If empty, the format of the original image is kept, or
+	// PNG is used if the format of the original image cannot be encoded
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+
+	// Quality is the quality of the JPEG images, from 1 to 100.
+	// Defaults to jpeg.DefaultQuality
+	Quality int `json:"quality,omitempty" yaml:"quality,omitempty"`
+}
+
+// VariantImage contains a variant generated by GenerateVariants()
+type VariantImage struct {
+	// Variant is the variant that has been used to generate the image
+	Variant *Variant
+
+	// Image contains the information of the generated image
+	Image *ImageMetadata
+
+	// Data contains the encoded image
+	Data []byte
+}
+
+// LoadVariants parses a JSON encoded list of variants and makes sure
+// they are valid.
+// Unknown fields are rejected to catch typos.
+func LoadVariants(r io.Reader) ([]Variant, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	variants := []Variant{}
+	if err := dec.Decode(&variants); err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(variants))
+	for i := range variants {
+		if err := variants[i].Validate(); err != nil {
+			return nil, err
+		}
+		if names[variants[i].Name] {
+			return nil, fmt.Errorf("%s: duplicate name %q", ErrMsgInvalidVariant, variants[i].Name)
+		}
+		names[variants[i].Name] = true
+	}
+	return variants, nil
+}
+
+// Validate makes sure the variant can be generated
+func (v *Variant) Validate() error {
+	if v.Name == "" {
+		return fmt.Errorf("%s: missing name", ErrMsgInvalidVariant)
+	}
+	if v.Width < 0 || v.Height < 0 || (v.Width == 0 && v.Height == 0) {
+		return fmt.Errorf("%s: invalid dimensions for %q", ErrMsgInvalidVariant, v.Name)
+	}
+	switch v.Mode {
+	case "", ResizeModeFit, ResizeModeResize:
+	case ResizeModeFill:
+		if v.Width == 0 || v.Height == 0 {
+			return fmt.Errorf("%s: %q needs both a width and a height to be filled", ErrMsgInvalidVariant, v.Name)
+		}
+	default:
+		return fmt.Errorf("%s: invalid mode %q for %q", ErrMsgInvalidVariant, v.Mode, v.Name)
+	}
+	if v.Filter != "" && !v.Filter.IsValid() {
+		return fmt.Errorf("%s: invalid filter %q for %q", ErrMsgInvalidVariant, v.Filter, v.Name)
+	}
+	if v.Format != "" {
+		if _, found := imageEncoders[v.Format]; !found {
+			return fmt.Errorf("%s: %s %q for %q", ErrMsgInvalidVariant, ErrMsgUnsupportedOutputFormat, v.Format, v.Name)
+		}
+	}
+	if v.Quality < 0 || v.Quality > 100 {
+		return fmt.Errorf("%s: %s for %q", ErrMsgInvalidVariant, ErrMsgInvalidQuality, v.Name)
+	}
+	return nil
+}
+
+// Apply resizes an image using the mode and filter of the variant
+func (v *Variant) Apply(img image.Image) *image.RGBA {
+	filter := v.Filter
+	if filter == "" {
+		filter = Lanczos
+	}
+	switch v.Mode {
+	case ResizeModeFill:
+		return Fill(img, v.Width, v.Height, filter)
+	case ResizeModeResize:
+		return Resize(img, v.Width, v.Height, filter)
+	default:
+		return Fit(img, v.Width, v.Height, filter)
+	}
+}
+
+// GenerateVariants decodes an image once, and generates all the provided
+// variants. The EXIF orientation of the image is applied, and the
+// metadata are removed.
+// limits are checked before the image gets decoded, to protect against
+// decompression bombs. Can be nil.
+// The reader will be put back to its original position.
+func GenerateVariants(r io.ReadSeeker, variants []Variant, limits *ValidateOptions) ([]*VariantImage, error) {
+	for i := range variants {
+		if err := variants[i].Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if limits != nil {
+		res, err := limits.checkLimits(r, nil)
+		if err != nil {
+			return nil, err
+		}
+		if !res.Valid {
+			return nil, resultError(res)
+		}
+	}
+	img, mimeType, err := DecodeImage(r)
+	if err != nil {
+		return nil, err
+	}
+
+	images := make([]*VariantImage, 0, len(variants))
+	for i := range variants {
+		v := &variants[i]
+		format, encode, err := outputFormat(v.Format, mimeType)
+		if err != nil {
+			return nil, err
+		}
+		resized := v.Apply(img)
+		buf := &bytes.Buffer{}
+		if err := encode(buf, resized, &NormalizeOptions{Quality: v.Quality}); err != nil {
+			return nil, err
+		}
+		images = append(images, &VariantImage{
+			Variant: v,
+			Image: &ImageMetadata{
+				MimeType:    format,
+				Width:       resized.Rect.Dx(),
+				Height:      resized.Rect.Dy(),
+				ColorModel:  ColorModelRGBA,
+				FrameCount:  1,
+				Orientation: 1,
+			},
+			Data: buf.Bytes(),
+		})
+	}
+	return images, nil
+}
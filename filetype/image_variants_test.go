@@ -0,0 +1,144 @@
+package filetype_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Nivl/go-types/filetype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadVariants(t *testing.T) {
+	variants, err := filetype.LoadVariants(strings.NewReader(`[
+		{"name": "small", "width": 32, "height": 32, "mode": "fill", "format": "image/jpeg", "quality": 80},
+		{"name": "medium", "width": 128, "filter": "bilinear"}
+	]`))
+	require.NoError(t, err, "LoadVariants() should have succeed")
+	expected := []filetype.Variant{
+		{Name: "small", Width: 32, Height: 32, Mode: filetype.ResizeModeFill, Format: "image/jpeg", Quality: 80},
+		{Name: "medium", Width: 128, Filter: filetype.Bilinear},
+	}
+	assert.Equal(t, expected, variants)
+}
+
+func TestLoadVariantsInvalid(t *testing.T) {
+	testCases := []struct {
+		description string
+		spec        string
+	}{
+		{"invalid json", `[{"name": "small"`},
+		{"unknown field", `[{"name": "small", "width": 10, "size": 10}]`},
+		{"missing name", `[{"width": 10}]`},
+		{"duplicate name", `[{"name": "small", "width": 10}, {"name": "small", "width": 20}]`},
+		{"missing dimensions", `[{"name": "small"}]`},
+		{"negative dimensions", `[{"name": "small", "width": -1, "height": 10}]`},
+		{"fill without height", `[{"name": "small", "width": 10, "mode": "fill"}]`},
+		{"invalid mode", `[{"name": "small", "width": 10, "mode": "stretch"}]`},
+		{"invalid filter", `[{"name": "small", "width": 10, "filter": "bicubic"}]`},
+		{"invalid format", `[{"name": "small", "width": 10, "format": "image/bmp"}]`},
+		{"invalid quality", `[{"name": "small", "width": 10, "quality": 101}]`},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			variants, err := filetype.LoadVariants(strings.NewReader(tc.spec))
+			require.Error(t, err, "LoadVariants() should have failed")
+			assert.Nil(t, variants)
+		})
+	}
+}
+
+func TestGenerateVariants(t *testing.T) {
+	// 64x48 image, displayed as 48x64
+	content := orientedPNG(t, testPattern(), 6)
+	variants := []filetype.Variant{
+		{Name: "fit", Width: 32},
+		{Name: "fill", Width: 10, Height: 10, Mode: filetype.ResizeModeFill, Format: "image/jpeg"},
+		{Name: "resize", Width: 10, Height: 20, Mode: filetype.ResizeModeResize, Filter: filetype.NearestNeighbor, Format: "image/gif"},
+	}
+
+	r := bytes.NewReader(content)
+	images, err := filetype.GenerateVariants(r, variants, &filetype.ValidateOptions{MaxPixels: 10000})
+	require.NoError(t, err, "GenerateVariants() should have succeed")
+	require.Len(t, images, 3)
+	assert.Equal(t, int64(len(content)), int64(r.Len()), "the reader should have been put back to its original position")
+
+	expected := []*filetype.ImageMetadata{
+		{MimeType: "image/png", Width: 32, Height: 43, ColorModel: filetype.ColorModelRGBA, FrameCount: 1, Orientation: 1},
+		{MimeType: "image/jpeg", Width: 10, Height: 10, ColorModel: filetype.ColorModelRGBA, FrameCount: 1, Orientation: 1},
+		{MimeType: "image/gif", Width: 10, Height: 20, ColorModel: filetype.ColorModelRGBA, FrameCount: 1, Orientation: 1},
+	}
+	for i, img := range images {
+		assert.Equal(t, &variants[i], img.Variant)
+		assert.Equal(t, expected[i], img.Image)
+
+		info, err := filetype.ImageInfo(bytes.NewReader(img.Data))
+		require.NoError(t, err, "ImageInfo() should have succeed")
+		assert.Equal(t, expected[i].MimeType, info.MimeType)
+		assert.Equal(t, expected[i].Width, info.Width)
+		assert.Equal(t, expected[i].Height, info.Height)
+	}
+}
+
+func TestGenerateVariantsErrors(t *testing.T) {
+	testCases := []struct {
+		description string
+		content     []byte
+		variants    []filetype.Variant
+		limits      *filetype.ValidateOptions
+		expectedErr string
+	}{
+		{
+			"invalid variant",
+			readFixture(t, "black_pixel.png"),
+			[]filetype.Variant{{Name: "small"}},
+			nil,
+			filetype.ErrMsgInvalidVariant + `: invalid dimensions for "small"`,
+		},
+		{
+			"limits exceeded",
+			pngBomb(),
+			[]filetype.Variant{{Name: "small", Width: 10}},
+			&filetype.ValidateOptions{MaxPixels: 1000},
+			filetype.ErrMsgImageTooLarge,
+		},
+		{
+			"limits on a corrupted image",
+			readFixture(t, "black_pixel.png")[:20],
+			[]filetype.Variant{{Name: "small", Width: 10}},
+			&filetype.ValidateOptions{MaxPixels: 1000},
+			filetype.ErrMsgInvalidImage,
+		},
+		{
+			"limits on an unsupported image",
+			readFixture(t, "black_pixel.pdf"),
+			[]filetype.Variant{{Name: "small", Width: 10}},
+			&filetype.ValidateOptions{MaxPixels: 1000},
+			filetype.ErrMsgUnsupportedImageFormat,
+		},
+		{
+			"not an image",
+			readFixture(t, "black_pixel.pdf"),
+			[]filetype.Variant{{Name: "small", Width: 10}},
+			nil,
+			filetype.ErrMsgUnsupportedImageFormat,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			images, err := filetype.GenerateVariants(bytes.NewReader(tc.content), tc.variants, tc.limits)
+			require.Error(t, err, "GenerateVariants() should have failed")
+			assert.Equal(t, tc.expectedErr, err.Error())
+			assert.Nil(t, images)
+		})
+	}
+}
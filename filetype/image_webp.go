@@ -0,0 +1,129 @@
+package filetype
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// IsWebP validates the structure of a WebP file.
+// The image data are not decoded, use RegisterImageFormat() with
+// golang.org/x/image/webp to fully decode the files instead.
+func IsWebP(r io.ReadSeeker) (bool, error) {
+	return validateHeader(r, parseWebP)
+}
+
+// parseWebP parses the RIFF container of a WebP file
+// https://developers.google.com/speed/webp/docs/riff_container
+func parseWebP(s *section) (*imageHeader, error) {
+	header, err := s.read(0, 12)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(header[:4], []byte("RIFF")) || !bytes.Equal(header[8:], []byte("WEBP")) {
+		return nil, errMalformed
+	}
+	// The RIFF size doesn't include the first 8 bytes
+	end := int64(binary.LittleEndian.Uint32(header[4:8])) + 8
+	if end > s.size {
+		return nil, errTruncated
+	}
+
+	var info *imageHeader
+	hasImageData := false
+	frames, orientation := 0, 0
+	for offset := int64(12); offset < end; {
+		chunk, err := s.read(offset, 8)
+		if err != nil {
+			return nil, err
+		}
+		fourCC := string(chunk[:4])
+		dataStart := offset + 8
+		dataSize := int64(binary.LittleEndian.Uint32(chunk[4:]))
+		if dataStart+dataSize > end {
+			return nil, errTruncated
+		}
+
+		switch fourCC {
+		case "VP8 ", "VP8L":
+			hasImageData = true
+		case "ANMF":
+			// animated images have their frames in ANMF chunks
+			hasImageData = true
+			frames++
+		case "EXIF":
+			exif, err := s.read(dataStart, dataSize)
+			if err != nil {
+				return nil, err
+			}
+			orientation = exifOrientation(bytes.TrimPrefix(exif, []byte("Exif\x00\x00")))
+		}
+
+		// The first chunk contains the size of the image
+		if offset == 12 {
+			data, err := s.read(dataStart, minInt64(dataSize, 10))
+			if err != nil {
+				return nil, err
+			}
+			if info, err = parseWebPFirstChunk(fourCC, data); err != nil {
+				return nil, err
+			}
+		}
+
+		// chunks are padded to an even size
+		offset = dataStart + dataSize + dataSize%2
+	}
+
+	if info == nil || !hasImageData {
+		return nil, errMalformed
+	}
+	info.frames = frames
+	info.orientation = orientation
+	return info, nil
+}
+
+// parseWebPFirstChunk extracts the size of the image from the first chunk
+// of a WebP file
+func parseWebPFirstChunk(fourCC string, data []byte) (*imageHeader, error) {
+	info := &imageHeader{bitDepth: 8}
+	switch fourCC {
+	case "VP8 ":
+		// https://tools.ietf.org/html/rfc6386#section-9.1
+		// 3 bytes of frame tag, 3 bytes of start code, then 2x2 bytes of size
+		if len(data) < 10 || data[0]&0x01 != 0 || !bytes.Equal(data[3:6], []byte{0x9d, 0x01, 0x2a}) {
+			return nil, errMalformed
+		}
+		info.width = int(binary.LittleEndian.Uint16(data[6:8]) & 0x3fff)
+		info.height = int(binary.LittleEndian.Uint16(data[8:10]) & 0x3fff)
+		info.colorModel = ColorModelYCbCr
+	case "VP8L":
+		// https://developers.google.com/speed/webp/docs/webp_lossless_bitstream_specification#2_riff_header
+		// 1 byte of signature, then 14 bits of width-1, 14 bits of height-1,
+		// 1 bit for the alpha and 3 bits of version
+		if len(data) < 5 || data[0] != 0x2f || data[4]>>5 != 0 {
+			return nil, errMalformed
+		}
+		bits := binary.LittleEndian.Uint32(data[1:5])
+		info.width = int(bits&0x3fff) + 1
+		info.height = int((bits>>14)&0x3fff) + 1
+		info.colorModel = ColorModelRGBA
+	case "VP8X":
+		// 1 byte of flags, 3 reserved bytes, then 2x3 bytes of canvas size - 1
+		if len(data) < 10 {
+			return nil, errMalformed
+		}
+		info.width = int(uint32(data[4])|uint32(data[5])<<8|uint32(data[6])<<16) + 1
+		info.height = int(uint32(data[7])|uint32(data[8])<<8|uint32(data[9])<<16) + 1
+		info.colorModel = ColorModelRGB
+		if data[0]&0x10 != 0 {
+			info.colorModel = ColorModelRGBA
+		}
+	default:
+		return nil, errMalformed
+	}
+
+	if info.width == 0 || info.height == 0 {
+		return nil, errMalformed
+	}
+	return info, nil
+}
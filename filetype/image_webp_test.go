@@ -0,0 +1,11 @@
+package filetype_test
+
+import (
+	"testing"
+
+	"github.com/Nivl/go-types/filetype"
+)
+
+func TestIsWebP(t *testing.T) {
+	testFileValidator(t, filetype.IsWebP, "black_pixel.webp")
+}
@@ -0,0 +1,165 @@
+package filetype
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/Nivl/go-types/octets"
+)
+
+// DefaultMaxInspectedImageBytes is the default maximum size of an image
+// analyzed by Inspect()
+const DefaultMaxInspectedImageBytes = octets.Size(32 * octets.MiB)
+
+// InspectOptions contains the options used by Inspect()
+type InspectOptions struct {
+	// Hashes contains the algorithms used to hash the file.
+	// Defaults to SHA256
+	Hashes []HashAlgorithm `json:"hashes,omitempty"`
+
+	// Validate contains the limits the images must respect.
+	// Can be nil
+	Validate *ValidateOptions `json:"validate,omitempty"`
+
+	// MaxImageBytes is the maximum size of an image that can be analyzed.
+	// Images are kept in memory to be analyzed, bigger images are reported
+	// as too large.
+	// Defaults to DefaultMaxInspectedImageBytes
+	MaxImageBytes octets.Size `json:"max_image_bytes,omitempty"`
+}
+
+// FileReport contains all the information gathered by Inspect()
+type FileReport struct {
+	// Size is the size of the file, in bytes
+	Size int64 `json:"size"`
+
+	// MimeType is the detected type of the file. Empty if the file is empty
+	MimeType string `json:"mime_type"`
+
+	// Digests contains the digests of the file
+	Digests Digests `json:"digests"`
+
+	// Image contains the information of the image. nil if the file is
+	// not an image, or if its header could not be parsed
+	Image *ImageMetadata `json:"image,omitempty"`
+
+	// Validation contains the result of the validation of the image.
+	// nil if the file is not an image
+	Validation *ValidationResult `json:"validation,omitempty"`
+}
+
+// Inspect reads a file once and returns its type, size, digests and,
+// for images, their information and the result of their validation.
+// The reader doesn't need to be seekable, which makes Inspect usable on
+// streams (http request body, multipart parts, etc.). Images are kept
+// in memory to be analyzed. opts can be nil.
+func Inspect(r io.Reader, opts *InspectOptions) (*FileReport, error) {
+	if opts == nil {
+		opts = &InspectOptions{}
+	}
+	algos := opts.Hashes
+	if len(algos) == 0 {
+		algos = []HashAlgorithm{SHA256}
+	}
+	h, err := newHasher(algos)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &FileReport{}
+	header, err := readHeader(r)
+	if err != nil && err != errEmptyFile {
+		return nil, err
+	}
+	if len(header) > 0 {
+		report.MimeType = detectContentType(header)
+	}
+
+	counter := &countingWriter{}
+	writers := []io.Writer{h, counter}
+
+	var img *boundedBuffer
+	if _, isImage := LookupImageFormat(report.MimeType); isImage {
+		maxBytes := opts.MaxImageBytes
+		if maxBytes <= 0 {
+			maxBytes = DefaultMaxInspectedImageBytes
+		}
+		img = &boundedBuffer{max: maxBytes.Bytes()}
+		writers = append(writers, img)
+	}
+
+	w := io.MultiWriter(writers...)
+	if _, err = w.Write(header); err != nil {
+		return nil, err
+	}
+	if _, err = io.Copy(w, r); err != nil {
+		return nil, err
+	}
+	report.Size = counter.n
+	report.Digests = h.digests()
+
+	if img != nil {
+		if err = inspectImage(report, img, opts.Validate); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// inspectImage fills the report with the information and the validation
+// result of an image
+func inspectImage(report *FileReport, img *boundedBuffer, opts *ValidateOptions) error {
+	if img.overflowed {
+		report.Validation = newInvalidResult(report.MimeType, ReasonTooLarge, errors.New(ErrMsgImageTooLarge), -1)
+		return nil
+	}
+
+	r := bytes.NewReader(img.buf.Bytes())
+	// An image we cannot parse will be reported as invalid by the
+	// validation
+	if info, err := ImageInfo(r); err == nil {
+		report.Image = info
+	}
+	res, err := CheckImageWithOptions(r, opts)
+	if err != nil {
+		return err
+	}
+	report.Validation = res
+	return nil
+}
+
+// countingWriter is a writer that counts the number of bytes written
+type countingWriter struct {
+	n int64
+}
+
+// Write implements the io.Writer interface
+// https://golang.org/pkg/io/#Writer
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// boundedBuffer is a writer that keeps the data in memory until it
+// reaches its max size. Once the max size is reached, the data are
+// dropped and the writes are ignored
+type boundedBuffer struct {
+	buf        bytes.Buffer
+	max        int64
+	overflowed bool
+}
+
+// Write implements the io.Writer interface
+// https://golang.org/pkg/io/#Writer
+func (w *boundedBuffer) Write(p []byte) (int, error) {
+	if w.overflowed {
+		return len(p), nil
+	}
+	if int64(w.buf.Len()+len(p)) > w.max {
+		w.overflowed = true
+		w.buf = bytes.Buffer{}
+		return len(p), nil
+	}
+	return w.buf.Write(p)
+}
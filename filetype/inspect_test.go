@@ -0,0 +1,152 @@
+package filetype_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"testing/iotest"
+
+	"github.com/Nivl/go-types/filetype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspect(t *testing.T) {
+	testCases := []struct {
+		description string
+		filename    string
+		expected    string
+		isImage     bool
+	}{
+		{"png", "black_pixel.png", "image/png", true},
+		{"jpg", "black_pixel.jpg", "image/jpeg", true},
+		{"webp", "black_pixel.webp", "image/webp", true},
+		{"pdf", "black_pixel.pdf", "application/pdf", false},
+		{"text file with no ext", "LICENSE", "text/plain; charset=utf-8", false},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			content := readFixture(t, tc.filename)
+			expectedDigests, err := filetype.Hash(bytes.NewReader(content), filetype.SHA256)
+			require.NoError(t, err, "Hash() should have succeed")
+
+			// OneByteReader makes sure we work with non-seekable readers that
+			// don't return everything at once
+			report, err := filetype.Inspect(iotest.OneByteReader(bytes.NewReader(content)), nil)
+			require.NoError(t, err, "Inspect() should have succeed")
+			assert.Equal(t, int64(len(content)), report.Size, "invalid size")
+			assert.Equal(t, tc.expected, report.MimeType, "invalid mimetype")
+			assert.Equal(t, expectedDigests, report.Digests, "invalid digests")
+
+			if !tc.isImage {
+				assert.Nil(t, report.Image, "non-images should not have image info")
+				assert.Nil(t, report.Validation, "non-images should not be validated")
+				return
+			}
+			require.NotNil(t, report.Image, "Image should have been set")
+			assert.Equal(t, 1, report.Image.Width, "invalid width")
+			assert.Equal(t, 1, report.Image.Height, "invalid height")
+			require.NotNil(t, report.Validation, "Validation should have been set")
+			assert.True(t, report.Validation.Valid, "the image should be valid")
+			assert.Equal(t, tc.expected, report.Validation.MimeType, "invalid validated mimetype")
+		})
+	}
+}
+
+func TestInspectOptions(t *testing.T) {
+	content := readFixture(t, "black_pixel.png")
+
+	t.Run("custom hashes", func(t *testing.T) {
+		t.Parallel()
+
+		opts := &filetype.InspectOptions{Hashes: []filetype.HashAlgorithm{filetype.MD5, filetype.CRC32C}}
+		report, err := filetype.Inspect(bytes.NewReader(content), opts)
+		require.NoError(t, err, "Inspect() should have succeed")
+		assert.Len(t, report.Digests, 2, "invalid number of digests")
+		assert.Contains(t, report.Digests, filetype.MD5, "MD5 should have been computed")
+		assert.Contains(t, report.Digests, filetype.CRC32C, "CRC32C should have been computed")
+	})
+
+	t.Run("unsupported hash", func(t *testing.T) {
+		t.Parallel()
+
+		opts := &filetype.InspectOptions{Hashes: []filetype.HashAlgorithm{"nope"}}
+		report, err := filetype.Inspect(bytes.NewReader(content), opts)
+		require.Error(t, err, "Inspect() should have failed")
+		assert.Equal(t, filetype.ErrMsgUnsupportedHashAlgorithm, err.Error(), "invalid error")
+		assert.Nil(t, report, "Inspect() should not have returned a report")
+	})
+
+	t.Run("image limits", func(t *testing.T) {
+		t.Parallel()
+
+		opts := &filetype.InspectOptions{Validate: &filetype.ValidateOptions{MaxBytes: 10}}
+		report, err := filetype.Inspect(bytes.NewReader(content), opts)
+		require.NoError(t, err, "Inspect() should have succeed")
+		require.NotNil(t, report.Validation, "Validation should have been set")
+		assert.False(t, report.Validation.Valid, "the image should be invalid")
+		assert.Equal(t, filetype.ReasonTooLarge, report.Validation.Reason, "invalid reason")
+	})
+
+	t.Run("image too large to be inspected", func(t *testing.T) {
+		t.Parallel()
+
+		opts := &filetype.InspectOptions{MaxImageBytes: 10}
+		report, err := filetype.Inspect(bytes.NewReader(content), opts)
+		require.NoError(t, err, "Inspect() should have succeed")
+		assert.Equal(t, int64(len(content)), report.Size, "the whole file should have been read")
+		assert.Nil(t, report.Image, "the image should not have been parsed")
+		require.NotNil(t, report.Validation, "Validation should have been set")
+		assert.False(t, report.Validation.Valid, "the image should be invalid")
+		assert.Equal(t, filetype.ReasonTooLarge, report.Validation.Reason, "invalid reason")
+	})
+}
+
+func TestInspectOptionsJSON(t *testing.T) {
+	opts := &filetype.InspectOptions{}
+	require.NoError(t, json.Unmarshal([]byte(`{"max_image_bytes": "32MiB"}`), opts), "Unmarshal() should have succeed")
+	assert.Equal(t, filetype.DefaultMaxInspectedImageBytes, opts.MaxImageBytes)
+}
+
+func TestInspectInvalidImage(t *testing.T) {
+	t.Parallel()
+
+	content := readFixture(t, "black_pixel.png")
+	report, err := filetype.Inspect(bytes.NewReader(content[:len(content)-20]), nil)
+	require.NoError(t, err, "Inspect() should have succeed")
+	assert.Equal(t, "image/png", report.MimeType, "invalid mimetype")
+	require.NotNil(t, report.Validation, "Validation should have been set")
+	assert.False(t, report.Validation.Valid, "the image should be invalid")
+	assert.Equal(t, filetype.ReasonTruncated, report.Validation.Reason, "invalid reason")
+}
+
+func TestInspectEmptyFile(t *testing.T) {
+	t.Parallel()
+
+	report, err := filetype.Inspect(bytes.NewReader([]byte{}), nil)
+	require.NoError(t, err, "Inspect() should have succeed")
+	assert.Equal(t, int64(0), report.Size, "invalid size")
+	assert.Empty(t, report.MimeType, "empty files should not have a mimetype")
+	assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", report.Digests[filetype.SHA256].Hex(), "invalid digest")
+	assert.Nil(t, report.Validation, "empty files should not be validated")
+}
+
+func TestInspectReadFail(t *testing.T) {
+	t.Parallel()
+
+	content := readFixture(t, "black_pixel.png")
+	r := iotest.TimeoutReader(iotest.HalfReader(bytes.NewReader(content)))
+	report, err := filetype.Inspect(r, nil)
+	require.Error(t, err, "Inspect() should have failed")
+	assert.Equal(t, iotest.ErrTimeout, err, "invalid error")
+	assert.Nil(t, report, "Inspect() should not have returned a report")
+
+	report, err = filetype.Inspect(&failingReader{err: errors.New("read failed")}, nil)
+	require.Error(t, err, "Inspect() should have failed")
+	assert.Nil(t, report, "Inspect() should not have returned a report")
+}
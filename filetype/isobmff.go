@@ -0,0 +1,77 @@
+package filetype
+
+import (
+	"encoding/binary"
+)
+
+// box represents an ISO Base Media File Format box (also called atom)
+type box struct {
+	typ string
+	// start is the offset of the first byte of the box, header included
+	start int64
+	// dataStart is the offset of the first byte of the content of the box
+	dataStart int64
+	// end is the offset of the first byte after the box
+	end int64
+}
+
+// readBoxes returns all the boxes located between start and end.
+// The boxes are not parsed recursively.
+// https://www.iso.org/standard/68960.html (section 4.2)
+func readBoxes(s *section, start, end int64) ([]*box, error) {
+	boxes := []*box{}
+	for offset := start; offset < end; {
+		header, err := s.read(offset, 8)
+		if err != nil {
+			return nil, err
+		}
+		b := &box{
+			typ:       string(header[4:8]),
+			start:     offset,
+			dataStart: offset + 8,
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		switch size {
+		case 0:
+			// the box extends to the end of its parent
+			size = end - offset
+		case 1:
+			// the size is stored on 64 bits, right after the type
+			largeSize, err := s.read(offset+8, 8)
+			if err != nil {
+				return nil, err
+			}
+			size = int64(binary.BigEndian.Uint64(largeSize))
+			b.dataStart += 8
+		}
+		b.end = offset + size
+		if size < b.dataStart-offset || b.end < offset {
+			return nil, errMalformed
+		}
+		if b.end > end {
+			return nil, errTruncated
+		}
+
+		boxes = append(boxes, b)
+		offset = b.end
+	}
+	return boxes, nil
+}
+
+// findBox returns the first box of the given type, or nil
+func findBox(boxes []*box, typ string) *box {
+	for _, b := range boxes {
+		if b.typ == typ {
+			return b
+		}
+	}
+	return nil
+}
+
+// readChildBoxes returns the boxes contained by the given box.
+// skip is the number of bytes to skip before the first child (4 for the
+// full boxes that have a version and flags)
+func readChildBoxes(s *section, parent *box, skip int64) ([]*box, error) {
+	return readBoxes(s, parent.dataStart+skip, parent.end)
+}
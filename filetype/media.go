@@ -0,0 +1,141 @@
+package filetype
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrMsgUnsupportedMediaFormat represents the error message returned
+// for an unsupported audio or video type
+var ErrMsgUnsupportedMediaFormat = "unsupported media format"
+
+// ErrMsgInvalidMedia represents the error message returned when an audio
+// or video file cannot be parsed
+var ErrMsgInvalidMedia = "invalid media"
+
+// MediaInfo contains the information extracted from the headers of an
+// audio or video file. The media are not decoded, so the values are the
+// ones declared by the file
+type MediaInfo struct {
+	// MimeType is the type of the file. MP4 files that don't contain any
+	// video are reported as audio/mp4
+	MimeType string `json:"mime_type"`
+
+	// Duration is the duration of the media, 0 if unknown (ex. a live
+	// stream). Encoded in nanoseconds in JSON
+	Duration time.Duration `json:"duration"`
+
+	// Width is the width of the video, in pixels. 0 if the file doesn't
+	// contain any video
+	Width int `json:"width,omitempty"`
+
+	// Height is the height of the video, in pixels. 0 if the file
+	// doesn't contain any video
+	Height int `json:"height,omitempty"`
+
+	// Bitrate is the average bitrate of the file, in bits per second.
+	// When the file doesn't declare it, it is computed using the size
+	// of the file and its duration
+	Bitrate int64 `json:"bitrate,omitempty"`
+
+	// VideoCodec is the codec of the first video track (h264, h265, vp9,
+	// av1, theora, etc.). Empty if the file doesn't contain any video
+	VideoCodec string `json:"video_codec,omitempty"`
+
+	// AudioCodec is the codec of the first audio track (aac, opus,
+	// vorbis, mp3, flac, pcm, etc.). Empty if the file doesn't contain
+	// any audio
+	AudioCodec string `json:"audio_codec,omitempty"`
+
+	// SampleRate is the sample rate of the first audio track, in Hz
+	SampleRate int `json:"sample_rate,omitempty"`
+
+	// Channels is the number of channels of the first audio track
+	Channels int `json:"channels,omitempty"`
+}
+
+// mediaParser represents a function that parses the headers of an audio
+// or video file
+type mediaParser func(s *section) (*MediaInfo, error)
+
+// mediaParsers contains the parsers of all the supported media formats,
+// indexed by the mimetype returned by MimeType()
+var mediaParsers = map[string]mediaParser{
+	"video/mp4":        parseMP4,
+	"video/quicktime":  parseMP4,
+	"audio/mp4":        parseMP4,
+	"video/webm":       parseMatroska,
+	"video/x-matroska": parseMatroska,
+	"application/ogg":  parseOgg,
+	"audio/ogg":        parseOgg,
+	"video/ogg":        parseOgg,
+	"audio/wave":       parseWAV,
+	"audio/mpeg":       parseMP3,
+	"audio/flac":       parseFLAC,
+}
+
+// InspectMedia returns the information of an audio or video file
+// (MP4, MOV, WebM, Matroska, Ogg, WAV, MP3 and FLAC) by parsing its
+// headers.
+// The reader will be put back to its original position.
+func InspectMedia(r io.ReadSeeker) (*MediaInfo, error) {
+	mimeType, err := MimeType(r)
+	if err != nil {
+		return nil, err
+	}
+	parse, found := mediaParsers[baseMediaType(mimeType)]
+	if !found {
+		return nil, errors.New(ErrMsgUnsupportedMediaFormat)
+	}
+
+	var info *MediaInfo
+	res, err := checkStructure(r, func(s *section) (err error) {
+		info, err = parse(s)
+		if err != nil {
+			return err
+		}
+		if info.MimeType == "" {
+			info.MimeType = baseMediaType(mimeType)
+		}
+		if info.Bitrate == 0 && info.Duration > 0 {
+			info.Bitrate = averageBitrate(s.size, info.Duration)
+		}
+		return nil
+	})
+	switch {
+	case err != nil:
+		return nil, err
+	case !res.Valid:
+		return nil, errors.New(ErrMsgInvalidMedia)
+	}
+	return info, nil
+}
+
+// durationOf returns the duration of a number of units of the provided
+// scale (ex. 48000 samples at 48000Hz is 1 second).
+// 0 is returned if the scale is 0
+func durationOf(units, scale uint64) time.Duration {
+	if scale == 0 {
+		return 0
+	}
+	secs := units / scale
+	rest := units % scale
+	return time.Duration(secs)*time.Second + time.Duration(rest*uint64(time.Second)/scale)
+}
+
+// averageBitrate returns the bitrate, in bits per second, of a file of
+// the given size and duration
+func averageBitrate(size int64, d time.Duration) int64 {
+	return int64(float64(size) * 8 / d.Seconds())
+}
+
+// codecName returns the name of a codec using a table of known
+// identifiers, or the identifier in lower case if the codec is unknown
+func codecName(names map[string]string, id string) string {
+	if name, found := names[id]; found {
+		return name
+	}
+	return strings.ToLower(strings.TrimSpace(id))
+}
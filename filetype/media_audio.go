@@ -0,0 +1,310 @@
+package filetype
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// wavCodecs contains the names of the codecs used in WAV files, indexed
+// by format tag
+var wavCodecs = map[uint16]string{
+	0x0001: "pcm",
+	0x0002: "adpcm_ms",
+	0x0003: "pcm_float",
+	0x0006: "alaw",
+	0x0007: "mulaw",
+	0x0011: "adpcm_ima",
+	0x0050: "mp2",
+	0x0055: "mp3",
+}
+
+// wavFormatExtensible is the format tag of the WAV files that store
+// their real format tag at the beginning of a GUID
+const wavFormatExtensible = 0xFFFE
+
+// mp3Bitrates contains the bitrates of the MPEG audio layer III frames,
+// in kbit/s, indexed by MPEG version (1, then 2 and 2.5) and bitrate
+// index. 0 means the index is invalid
+var mp3Bitrates = [2][16]int{
+	{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},
+	{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+}
+
+// mp3SampleRates contains the sample rates of MPEG 1 audio files, indexed
+// by sample rate index. The rates are divided by 2 for MPEG 2, and by 4
+// for MPEG 2.5
+var mp3SampleRates = [3]int{44100, 48000, 32000}
+
+// mp3MaxFrameSearch is the number of bytes we look into to find the first
+// frame of an MP3 file, after the ID3 tag
+const mp3MaxFrameSearch = 64 << 10
+
+// mp3Frame contains the data of the header of an MPEG audio layer III
+// frame
+type mp3Frame struct {
+	// mpeg1 is true for MPEG 1 files, false for MPEG 2 and 2.5
+	mpeg1      bool
+	bitrate    int
+	sampleRate int
+	channels   int
+	// size is the size of the frame, header included
+	size int
+}
+
+// samples returns the number of samples per channel in the frame
+func (f *mp3Frame) samples() uint64 {
+	if f.mpeg1 {
+		return 1152
+	}
+	return 576
+}
+
+// parseWAV parses the fmt chunk of a WAV file, and uses the size of its
+// data chunk to compute the duration
+func parseWAV(s *section) (*MediaInfo, error) {
+	header, err := s.read(0, 12)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(header, []byte("RIFF")) || !bytes.Equal(header[8:], []byte("WAVE")) {
+		return nil, errMalformed
+	}
+
+	info := &MediaInfo{}
+	var byteRate uint32
+	for offset := int64(12); ; {
+		chunk, err := s.read(offset, 8)
+		if err != nil {
+			return nil, err
+		}
+		size := int64(binary.LittleEndian.Uint32(chunk[4:]))
+		dataStart := offset + 8
+		switch string(chunk[:4]) {
+		case "fmt ":
+			fmtChunk, err := s.read(dataStart, minInt64(size, 26))
+			if err != nil {
+				return nil, err
+			}
+			if len(fmtChunk) < 16 {
+				return nil, errMalformed
+			}
+			tag := binary.LittleEndian.Uint16(fmtChunk)
+			if tag == wavFormatExtensible && len(fmtChunk) >= 26 {
+				tag = binary.LittleEndian.Uint16(fmtChunk[24:])
+			}
+			info.AudioCodec = wavCodecs[tag]
+			if info.AudioCodec == "" {
+				info.AudioCodec = fmt.Sprintf("0x%04x", tag)
+			}
+			info.Channels = int(binary.LittleEndian.Uint16(fmtChunk[2:]))
+			info.SampleRate = int(binary.LittleEndian.Uint32(fmtChunk[4:]))
+			byteRate = binary.LittleEndian.Uint32(fmtChunk[8:])
+		case "data":
+			if info.AudioCodec == "" {
+				return nil, errMalformed
+			}
+			// The size can be wrong for files that are being recorded,
+			// or files bigger than 4GB
+			size = minInt64(size, s.size-dataStart)
+			info.Duration = durationOf(uint64(size), uint64(byteRate))
+			info.Bitrate = int64(byteRate) * 8
+			return info, nil
+		}
+		// The chunks are aligned on 2 bytes
+		offset = dataStart + size + size%2
+	}
+}
+
+// parseMP3 parses the first frame of an MP3 file. The duration is
+// computed using the Xing or VBRI header of variable bitrate files, or
+// using the bitrate of the first frame for constant bitrate files
+func parseMP3(s *section) (*MediaInfo, error) {
+	start, err := skipID3v2(s)
+	if err != nil {
+		return nil, err
+	}
+	// The end of the audio data, without the ID3v1 tag
+	end := s.size
+	if end-start >= 128 {
+		tag, err := s.read(end-128, 3)
+		if err != nil {
+			return nil, err
+		}
+		if string(tag) == "TAG" {
+			end -= 128
+		}
+	}
+
+	// The ID3 tag can be followed by padding, so we look for the first
+	// valid frame
+	data, err := s.read(start, minInt64(end-start, mp3MaxFrameSearch))
+	if err != nil {
+		return nil, err
+	}
+	var frame *mp3Frame
+	for i := 0; i+4 <= len(data); i++ {
+		if frame = parseMP3FrameHeader(data[i:]); frame != nil {
+			start += int64(i)
+			data = data[i:]
+			break
+		}
+	}
+	if frame == nil {
+		return nil, errMalformed
+	}
+
+	info := &MediaInfo{
+		AudioCodec: "mp3",
+		SampleRate: frame.sampleRate,
+		Channels:   frame.channels,
+	}
+	if frames, ok := mp3VBRFrameCount(data, frame); ok {
+		info.Duration = durationOf(uint64(frames)*frame.samples(), uint64(frame.sampleRate))
+		return info, nil
+	}
+	info.Bitrate = int64(frame.bitrate)
+	info.Duration = durationOf(uint64(end-start)*8, uint64(frame.bitrate))
+	return info, nil
+}
+
+// skipID3v2 returns the offset of the first byte after the ID3v2 tag,
+// or 0 if the file has no ID3v2 tag
+// https://id3.org/id3v2.4.0-structure
+func skipID3v2(s *section) (int64, error) {
+	header, err := s.read(0, 10)
+	if err == errTruncated {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if !bytes.HasPrefix(header, []byte("ID3")) {
+		return 0, nil
+	}
+	// The size is a "syncsafe" integer: 4 bytes of 7 bits
+	size := int64(header[6])<<21 | int64(header[7])<<14 | int64(header[8])<<7 | int64(header[9])
+	size += 10
+	if header[5]&0x10 != 0 {
+		// the tag has a footer
+		size += 10
+	}
+	if size > s.size {
+		return 0, errTruncated
+	}
+	return size, nil
+}
+
+// parseMP3FrameHeader parses the header of an MPEG audio layer III frame
+// located at the beginning of data. nil is returned if data doesn't
+// start with a valid header
+// http://www.mp3-tech.org/programmer/frame_header.html
+func parseMP3FrameHeader(data []byte) *mp3Frame {
+	if len(data) < 4 || data[0] != 0xFF || data[1]&0xE0 != 0xE0 {
+		return nil
+	}
+	version := (data[1] >> 3) & 0x03
+	layer := (data[1] >> 1) & 0x03
+	bitrateIndex := data[2] >> 4
+	sampleRateIndex := (data[2] >> 2) & 0x03
+	// version 1 is reserved, and layer 1 is layer III
+	if version == 1 || layer != 1 || sampleRateIndex == 3 || data[3]&0x03 == 2 {
+		return nil
+	}
+
+	frame := &mp3Frame{mpeg1: version == 3, sampleRate: mp3SampleRates[sampleRateIndex], channels: 2}
+	table := 1
+	switch version {
+	case 3:
+		table = 0
+	case 2:
+		frame.sampleRate /= 2
+	case 0:
+		frame.sampleRate /= 4
+	}
+	frame.bitrate = mp3Bitrates[table][bitrateIndex] * 1000
+	if frame.bitrate == 0 {
+		return nil
+	}
+	if data[3]>>6 == 3 {
+		frame.channels = 1
+	}
+
+	padding := int(data[2]>>1) & 0x01
+	if frame.mpeg1 {
+		frame.size = 144*frame.bitrate/frame.sampleRate + padding
+	} else {
+		frame.size = 72*frame.bitrate/frame.sampleRate + padding
+	}
+	return frame
+}
+
+// mp3VBRFrameCount returns the number of frames declared in the Xing
+// (or Info) header, or in the VBRI header of the first frame.
+// data must start with the first frame
+func mp3VBRFrameCount(data []byte, frame *mp3Frame) (uint32, bool) {
+	// The Xing header is located after the side information, whose size
+	// depends on the version and the number of channels
+	sideInfo := 17
+	switch {
+	case frame.mpeg1 && frame.channels == 2:
+		sideInfo = 32
+	case !frame.mpeg1 && frame.channels == 1:
+		sideInfo = 9
+	}
+	if xing := data[minInt(4+sideInfo, len(data)):]; len(xing) >= 12 &&
+		(bytes.HasPrefix(xing, []byte("Xing")) || bytes.HasPrefix(xing, []byte("Info"))) {
+		// The frame count is the first optional field
+		if binary.BigEndian.Uint32(xing[4:])&0x01 == 0 {
+			return 0, false
+		}
+		return binary.BigEndian.Uint32(xing[8:]), true
+	}
+
+	// The VBRI header is always located 32 bytes after the frame header
+	if vbri := data[minInt(36, len(data)):]; len(vbri) >= 18 && bytes.HasPrefix(vbri, []byte("VBRI")) {
+		return binary.BigEndian.Uint32(vbri[14:]), true
+	}
+	return 0, false
+}
+
+// parseFLAC parses the STREAMINFO block of a FLAC file
+// https://xiph.org/flac/format.html
+func parseFLAC(s *section) (*MediaInfo, error) {
+	header, err := s.read(0, 8)
+	if err != nil {
+		return nil, err
+	}
+	// STREAMINFO is always the first block, and is 34 bytes long
+	if !bytes.HasPrefix(header, []byte("fLaC")) || header[4]&0x7F != 0 ||
+		header[5] != 0 || header[6] != 0 || header[7] != 34 {
+		return nil, errMalformed
+	}
+	streamInfo, err := s.read(8, 34)
+	if err != nil {
+		return nil, err
+	}
+	rate, channels, samples := parseFLACStreamInfo(streamInfo)
+	if rate == 0 {
+		return nil, errMalformed
+	}
+	return &MediaInfo{
+		AudioCodec: "flac",
+		SampleRate: rate,
+		Channels:   channels,
+		Duration:   durationOf(samples, uint64(rate)),
+	}, nil
+}
+
+// parseFLACStreamInfo returns the sample rate, the number of channels
+// and the total number of samples (0 if unknown) stored in a FLAC
+// STREAMINFO block
+func parseFLACStreamInfo(data []byte) (rate, channels int, samples uint64) {
+	// The sample rate is stored on 20 bits, followed by the number of
+	// channels minus 1 on 3 bits, the bits per sample minus 1 on 5 bits,
+	// and the number of samples on 36 bits
+	rate = int(data[10])<<12 | int(data[11])<<4 | int(data[12])>>4
+	channels = int(data[12]>>1)&0x07 + 1
+	samples = uint64(data[13]&0x0F)<<32 | uint64(binary.BigEndian.Uint32(data[14:]))
+	return rate, channels, samples
+}
@@ -0,0 +1,242 @@
+package filetype_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/Nivl/go-types/filetype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// riffChunk returns a RIFF chunk, padded to an even size
+func riffChunk(id string, data []byte) []byte {
+	header := make([]byte, 8)
+	copy(header, id)
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(data)))
+	chunk := append(header, data...)
+	if len(data)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+// wavFile returns a WAV file containing the provided chunks
+func wavFile(chunks ...[]byte) []byte {
+	content := bytes.Join(chunks, nil)
+	header := make([]byte, 12)
+	copy(header, "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(4+len(content)))
+	copy(header[8:], "WAVE")
+	return append(header, content...)
+}
+
+// wavFormat returns the content of a fmt chunk
+func wavFormat(tag uint16, channels, sampleRate, bitsPerSample int) []byte {
+	data := make([]byte, 16)
+	binary.LittleEndian.PutUint16(data, tag)
+	binary.LittleEndian.PutUint16(data[2:], uint16(channels))
+	binary.LittleEndian.PutUint32(data[4:], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(data[8:], uint32(sampleRate*channels*bitsPerSample/8))
+	binary.LittleEndian.PutUint16(data[12:], uint16(channels*bitsPerSample/8))
+	binary.LittleEndian.PutUint16(data[14:], uint16(bitsPerSample))
+	return data
+}
+
+// id3Tag returns an empty ID3v2 tag, followed by padding
+func id3Tag(padding int) []byte {
+	return append([]byte{'I', 'D', '3', 4, 0, 0, 0, 0, 0, byte(padding)}, make([]byte, padding)...)
+}
+
+// mp3File returns an MP3 file of the given size, made of 128kbit/s
+// stereo frames at 44.1kHz. firstFrame is added to the first frame
+func mp3File(size int, firstFrame []byte) []byte {
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+	content := make([]byte, 0, size+len(frame))
+	for len(content) < size {
+		content = append(content, frame...)
+	}
+	copy(content[4:], firstFrame)
+	return content[:size]
+}
+
+// flacFile returns a FLAC file containing a STREAMINFO block
+func flacFile(sampleRate, channels int, samples uint64) []byte {
+	streamInfo := make([]byte, 34)
+	streamInfo[10] = byte(sampleRate >> 12)
+	streamInfo[11] = byte(sampleRate >> 4)
+	// 16 bits per sample
+	streamInfo[12] = byte(sampleRate<<4) | byte(channels-1)<<1
+	streamInfo[13] = 15<<4 | byte(samples>>32)
+	binary.BigEndian.PutUint32(streamInfo[14:], uint32(samples))
+	return bytes.Join([][]byte{[]byte("fLaC"), {0x80, 0, 0, 34}, streamInfo, make([]byte, 100)}, nil)
+}
+
+func TestInspectMediaAudio(t *testing.T) {
+	extensible := append(wavFormat(0xFFFE, 2, 48000, 32), make([]byte, 24)...)
+	binary.LittleEndian.PutUint16(extensible[24:], 3)
+
+	// Xing header of an MPEG 1 stereo file, containing the number of
+	// frames
+	xing := append(make([]byte, 32), []byte("Xing\x00\x00\x00\x01\x00\x00\x00\x64")...)
+	// VBRI header, containing the number of frames
+	vbri := append(make([]byte, 32), []byte("VBRI\x00\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x64")...)
+	vbrDuration := 100 * 1152 * time.Second / 44100
+
+	testCases := []struct {
+		description string
+		content     []byte
+		expected    *filetype.MediaInfo
+	}{
+		{
+			"wav",
+			wavFile(
+				riffChunk("LIST", []byte("odd")),
+				riffChunk("fmt ", wavFormat(1, 1, 8000, 8)),
+				riffChunk("data", make([]byte, 20000)),
+			),
+			&filetype.MediaInfo{
+				MimeType:   "audio/wave",
+				Duration:   2500 * time.Millisecond,
+				Bitrate:    64000,
+				AudioCodec: "pcm",
+				SampleRate: 8000,
+				Channels:   1,
+			},
+		},
+		{
+			"wav extensible",
+			wavFile(
+				riffChunk("fmt ", extensible),
+				riffChunk("data", make([]byte, 960000)),
+			),
+			&filetype.MediaInfo{
+				MimeType:   "audio/wave",
+				Duration:   2500 * time.Millisecond,
+				Bitrate:    3072000,
+				AudioCodec: "pcm_float",
+				SampleRate: 48000,
+				Channels:   2,
+			},
+		},
+		{
+			"wav with an unknown codec",
+			wavFile(
+				riffChunk("fmt ", wavFormat(0x1234, 1, 8000, 8)),
+				riffChunk("data", make([]byte, 20000)),
+			),
+			&filetype.MediaInfo{
+				MimeType:   "audio/wave",
+				Duration:   2500 * time.Millisecond,
+				Bitrate:    64000,
+				AudioCodec: "0x1234",
+				SampleRate: 8000,
+				Channels:   1,
+			},
+		},
+		{
+			"mp3 without ID3",
+			mp3File(40000, nil),
+			&filetype.MediaInfo{
+				MimeType:   "audio/mpeg",
+				Duration:   2500 * time.Millisecond,
+				Bitrate:    128000,
+				AudioCodec: "mp3",
+				SampleRate: 44100,
+				Channels:   2,
+			},
+		},
+		{
+			"mp3 with ID3v2 and ID3v1",
+			bytes.Join([][]byte{id3Tag(20), mp3File(40000, nil), []byte("TAG"), make([]byte, 125)}, nil),
+			&filetype.MediaInfo{
+				MimeType:   "audio/mpeg",
+				Duration:   2500 * time.Millisecond,
+				Bitrate:    128000,
+				AudioCodec: "mp3",
+				SampleRate: 44100,
+				Channels:   2,
+			},
+		},
+		{
+			"mp3 with a Xing header",
+			bytes.Join([][]byte{id3Tag(0), mp3File(20000, xing)}, nil),
+			&filetype.MediaInfo{
+				MimeType:   "audio/mpeg",
+				Duration:   vbrDuration,
+				Bitrate:    int64(float64(10+20000) * 8 / vbrDuration.Seconds()),
+				AudioCodec: "mp3",
+				SampleRate: 44100,
+				Channels:   2,
+			},
+		},
+		{
+			"mp3 with a VBRI header",
+			bytes.Join([][]byte{id3Tag(0), mp3File(20000, vbri)}, nil),
+			&filetype.MediaInfo{
+				MimeType:   "audio/mpeg",
+				Duration:   vbrDuration,
+				Bitrate:    int64(float64(10+20000) * 8 / vbrDuration.Seconds()),
+				AudioCodec: "mp3",
+				SampleRate: 44100,
+				Channels:   2,
+			},
+		},
+		{
+			"flac",
+			flacFile(44100, 2, 110250),
+			&filetype.MediaInfo{
+				MimeType:   "audio/flac",
+				Duration:   2500 * time.Millisecond,
+				Bitrate:    int64(142 * 8 * 2 / 5),
+				AudioCodec: "flac",
+				SampleRate: 44100,
+				Channels:   2,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			mime, err := filetype.MimeType(bytes.NewReader(tc.content))
+			require.NoError(t, err, "MimeType() should have succeed")
+			assert.Equal(t, tc.expected.MimeType, mime, "invalid mimetype")
+
+			info, err := filetype.InspectMedia(bytes.NewReader(tc.content))
+			require.NoError(t, err, "InspectMedia() should have succeed")
+			assert.Equal(t, tc.expected, info)
+		})
+	}
+}
+
+func TestInspectMediaInvalidAudio(t *testing.T) {
+	testCases := []struct {
+		description string
+		content     []byte
+	}{
+		{"wav without data", wavFile(riffChunk("fmt ", wavFormat(1, 1, 8000, 8)))},
+		{"wav with data before fmt", wavFile(riffChunk("data", make([]byte, 10)), riffChunk("fmt ", wavFormat(1, 1, 8000, 8)))},
+		{"wav with a small fmt", wavFile(riffChunk("fmt ", make([]byte, 8)), riffChunk("data", make([]byte, 10)))},
+		{"mp3 without frames", append(id3Tag(0), make([]byte, 1000)...)},
+		{"mp3 with a truncated ID3 tag", id3Tag(100)[:50]},
+		{"flac without streaminfo", []byte("fLaC\x84\x00\x00\x00")},
+		{"flac with an invalid sample rate", flacFile(0, 2, 0)},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := filetype.InspectMedia(bytes.NewReader(tc.content))
+			require.Error(t, err, "InspectMedia() should have failed")
+			assert.Equal(t, filetype.ErrMsgInvalidMedia, err.Error())
+		})
+	}
+}
@@ -0,0 +1,371 @@
+package filetype
+
+import (
+	"encoding/binary"
+	"math"
+	"math/bits"
+	"strings"
+	"time"
+)
+
+// List of the EBML elements we need to parse a Matroska file
+// https://www.matroska.org/technical/elements.html
+const (
+	ebmlIDHeader            = 0x1A45DFA3
+	ebmlIDDocType           = 0x4282
+	ebmlIDSegment           = 0x18538067
+	ebmlIDInfo              = 0x1549A966
+	ebmlIDTimestampScale    = 0x2AD7B1
+	ebmlIDDuration          = 0x4489
+	ebmlIDTracks            = 0x1654AE6B
+	ebmlIDTrackEntry        = 0xAE
+	ebmlIDTrackType         = 0x83
+	ebmlIDCodecID           = 0x86
+	ebmlIDVideo             = 0xE0
+	ebmlIDPixelWidth        = 0xB0
+	ebmlIDPixelHeight       = 0xBA
+	ebmlIDAudio             = 0xE1
+	ebmlIDSamplingFrequency = 0xB5
+	ebmlIDChannels          = 0x9F
+	ebmlIDCluster           = 0x1F43B675
+)
+
+// matroskaDocTypes contains the mimetypes of the supported Matroska
+// document types
+var matroskaDocTypes = map[string]string{
+	"webm":     "video/webm",
+	"matroska": "video/x-matroska",
+}
+
+// matroskaCodecs contains the names of the codecs used in Matroska
+// files, indexed by codec ID
+var matroskaCodecs = map[string]string{
+	"V_VP8":            "vp8",
+	"V_VP9":            "vp9",
+	"V_AV1":            "av1",
+	"V_MPEG4/ISO/AVC":  "h264",
+	"V_MPEGH/ISO/HEVC": "h265",
+	"V_MPEG4/ISO/ASP":  "mpeg4",
+	"V_THEORA":         "theora",
+	"V_PRORES":         "prores",
+	"V_MJPEG":          "mjpeg",
+	"A_OPUS":           "opus",
+	"A_VORBIS":         "vorbis",
+	"A_AAC":            "aac",
+	"A_AAC/MPEG2/LC":   "aac",
+	"A_AAC/MPEG4/LC":   "aac",
+	"A_FLAC":           "flac",
+	"A_MPEG/L3":        "mp3",
+	"A_AC3":            "ac3",
+	"A_EAC3":           "eac3",
+	"A_PCM/INT/LIT":    "pcm",
+	"A_PCM/INT/BIG":    "pcm",
+	"A_PCM/FLOAT/IEEE": "pcm_float",
+	"V_UNCOMPRESSED":   "rawvideo",
+}
+
+// ebmlElement represents an element of an EBML document
+type ebmlElement struct {
+	id uint64
+	// dataStart is the offset of the first byte of the content of the
+	// element
+	dataStart int64
+	// end is the offset of the first byte after the element
+	end int64
+}
+
+// parseMatroska parses the EBML header, the segment information and the
+// tracks of a Matroska or WebM file.
+// The clusters are not parsed, so the tracks must be located before the
+// first cluster, which is the case for most files
+func parseMatroska(s *section) (*MediaInfo, error) {
+	header, err := readEBMLElement(s, 0, s.size)
+	if err != nil {
+		return nil, err
+	}
+	if header.id != ebmlIDHeader {
+		return nil, errMalformed
+	}
+	info := &MediaInfo{}
+	err = walkEBML(s, header.dataStart, header.end, func(el *ebmlElement) (bool, error) {
+		if el.id == ebmlIDDocType {
+			docType, err := readEBMLString(s, el)
+			if err != nil {
+				return false, err
+			}
+			info.MimeType = matroskaDocTypes[docType]
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if info.MimeType == "" {
+		return nil, errMalformed
+	}
+
+	segment, err := readEBMLElement(s, header.end, s.size)
+	if err != nil {
+		return nil, err
+	}
+	if segment.id != ebmlIDSegment {
+		return nil, errMalformed
+	}
+
+	foundTracks := false
+	err = walkEBML(s, segment.dataStart, segment.end, func(el *ebmlElement) (bool, error) {
+		switch el.id {
+		case ebmlIDInfo:
+			return false, parseMatroskaInfo(s, el, info)
+		case ebmlIDTracks:
+			foundTracks = true
+			return false, walkEBML(s, el.dataStart, el.end, func(track *ebmlElement) (bool, error) {
+				if track.id != ebmlIDTrackEntry {
+					return false, nil
+				}
+				return false, parseMatroskaTrack(s, track, info)
+			})
+		case ebmlIDCluster:
+			// The clusters contain the media. Their size can be unknown
+			// for live streams, so we cannot go past them
+			return true, nil
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !foundTracks {
+		return nil, errMalformed
+	}
+	return info, nil
+}
+
+// parseMatroskaInfo parses the Info element of a segment, which contains
+// the duration of the file
+func parseMatroskaInfo(s *section, el *ebmlElement, info *MediaInfo) error {
+	// The duration is a float, expressed in TimestampScale nanoseconds
+	scale := uint64(1000000)
+	var duration float64
+	err := walkEBML(s, el.dataStart, el.end, func(child *ebmlElement) (bool, error) {
+		var err error
+		switch child.id {
+		case ebmlIDTimestampScale:
+			scale, err = readEBMLUint(s, child)
+		case ebmlIDDuration:
+			duration, err = readEBMLFloat(s, child)
+		}
+		return false, err
+	})
+	if err != nil {
+		return err
+	}
+	ns := duration * float64(scale)
+	if ns < 0 || ns >= math.MaxInt64 || math.IsNaN(ns) {
+		return errMalformed
+	}
+	info.Duration = time.Duration(ns)
+	return nil
+}
+
+// parseMatroskaTrack parses a TrackEntry element and fills the info
+// with its data if it's the first audio or video track
+func parseMatroskaTrack(s *section, el *ebmlElement, info *MediaInfo) error {
+	var trackType uint64
+	var codecID string
+	var video, audio *ebmlElement
+	err := walkEBML(s, el.dataStart, el.end, func(child *ebmlElement) (bool, error) {
+		var err error
+		switch child.id {
+		case ebmlIDTrackType:
+			trackType, err = readEBMLUint(s, child)
+		case ebmlIDCodecID:
+			codecID, err = readEBMLString(s, child)
+		case ebmlIDVideo:
+			video = child
+		case ebmlIDAudio:
+			audio = child
+		}
+		return false, err
+	})
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case trackType == 1 && info.VideoCodec == "":
+		info.VideoCodec = matroskaCodecName(codecID)
+		if video == nil {
+			return errMalformed
+		}
+		return walkEBML(s, video.dataStart, video.end, func(child *ebmlElement) (bool, error) {
+			var err error
+			var v uint64
+			switch child.id {
+			case ebmlIDPixelWidth:
+				v, err = readEBMLUint(s, child)
+				info.Width = int(v)
+			case ebmlIDPixelHeight:
+				v, err = readEBMLUint(s, child)
+				info.Height = int(v)
+			}
+			return false, err
+		})
+	case trackType == 2 && info.AudioCodec == "":
+		info.AudioCodec = matroskaCodecName(codecID)
+		// Default values defined by the specifications
+		info.SampleRate = 8000
+		info.Channels = 1
+		if audio == nil {
+			return nil
+		}
+		return walkEBML(s, audio.dataStart, audio.end, func(child *ebmlElement) (bool, error) {
+			var err error
+			switch child.id {
+			case ebmlIDSamplingFrequency:
+				var rate float64
+				rate, err = readEBMLFloat(s, child)
+				info.SampleRate = int(rate)
+			case ebmlIDChannels:
+				var channels uint64
+				channels, err = readEBMLUint(s, child)
+				info.Channels = int(channels)
+			}
+			return false, err
+		})
+	}
+	return nil
+}
+
+// matroskaCodecName returns the name of a codec using its Matroska
+// codec ID. Unknown codecs are returned without their type prefix
+func matroskaCodecName(codecID string) string {
+	if name, found := matroskaCodecs[codecID]; found {
+		return name
+	}
+	if len(codecID) > 2 && codecID[1] == '_' {
+		codecID = codecID[2:]
+	}
+	return codecName(nil, codecID)
+}
+
+// walkEBML calls fn for each element located between start and end.
+// The elements are not walked recursively. The walk stops if fn
+// returns true or an error
+func walkEBML(s *section, start, end int64, fn func(el *ebmlElement) (stop bool, err error)) error {
+	for offset := start; offset < end; {
+		el, err := readEBMLElement(s, offset, end)
+		if err != nil {
+			return err
+		}
+		stop, err := fn(el)
+		if stop || err != nil {
+			return err
+		}
+		offset = el.end
+	}
+	return nil
+}
+
+// readEBMLElement reads the header of the element located at the given
+// offset. Elements of unknown size extend to the end of their parent
+// https://www.rfc-editor.org/rfc/rfc8794 (section 4)
+func readEBMLElement(s *section, offset, end int64) (*ebmlElement, error) {
+	header, err := s.read(offset, minInt64(12, end-offset))
+	if err != nil {
+		return nil, err
+	}
+	id, idLen := ebmlVint(header, true)
+	if idLen == 0 || idLen > 4 {
+		return nil, errMalformed
+	}
+	size, sizeLen := ebmlVint(header[idLen:], false)
+	if sizeLen == 0 {
+		return nil, errMalformed
+	}
+
+	el := &ebmlElement{id: id, dataStart: offset + int64(idLen+sizeLen)}
+	switch {
+	case size == 1<<(7*uint(sizeLen))-1:
+		// all the bits are set, the size is unknown
+		el.end = end
+	case size > uint64(end-el.dataStart):
+		return nil, errTruncated
+	default:
+		el.end = el.dataStart + int64(size)
+	}
+	return el, nil
+}
+
+// ebmlVint decodes the variable size integer located at the beginning
+// of data, and returns its value and its length. The length marker is
+// kept in the value if keepMarker is true (used by the element IDs).
+// The returned length is 0 if the integer is invalid or truncated
+func ebmlVint(data []byte, keepMarker bool) (uint64, int) {
+	if len(data) == 0 || data[0] == 0 {
+		return 0, 0
+	}
+	length := bits.LeadingZeros8(data[0]) + 1
+	if length > len(data) {
+		return 0, 0
+	}
+	value := uint64(data[0])
+	if !keepMarker {
+		value &= 0xFF >> uint(length)
+	}
+	for _, b := range data[1:length] {
+		value = value<<8 | uint64(b)
+	}
+	return value, length
+}
+
+// readEBMLUint returns the content of an unsigned integer element
+func readEBMLUint(s *section, el *ebmlElement) (uint64, error) {
+	size := el.end - el.dataStart
+	if size > 8 {
+		return 0, errMalformed
+	}
+	data, err := s.read(el.dataStart, size)
+	if err != nil {
+		return 0, err
+	}
+	var v uint64
+	for _, b := range data {
+		v = v<<8 | uint64(b)
+	}
+	return v, nil
+}
+
+// readEBMLFloat returns the content of a float element
+func readEBMLFloat(s *section, el *ebmlElement) (float64, error) {
+	size := el.end - el.dataStart
+	if size == 0 {
+		return 0, nil
+	}
+	if size != 4 && size != 8 {
+		return 0, errMalformed
+	}
+	data, err := s.read(el.dataStart, size)
+	if err != nil {
+		return 0, err
+	}
+	if size == 4 {
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data))), nil
+	}
+	return math.Float64frombits(binary.BigEndian.Uint64(data)), nil
+}
+
+// readEBMLString returns the content of a string element, without its
+// trailing null bytes
+func readEBMLString(s *section, el *ebmlElement) (string, error) {
+	// None of the strings we read are supposed to be long
+	size := el.end - el.dataStart
+	if size > 256 {
+		return "", errMalformed
+	}
+	data, err := s.read(el.dataStart, size)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\x00"), nil
+}
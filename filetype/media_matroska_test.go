@@ -0,0 +1,190 @@
+package filetype_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Nivl/go-types/filetype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ebmlElement returns an EBML element. The size is always stored on 8
+// bytes, unless unknownSize is used
+func ebmlElement(id uint32, content ...[]byte) []byte {
+	data := bytes.Join(content, nil)
+	idBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(idBytes, id)
+	for len(idBytes) > 1 && idBytes[0] == 0 {
+		idBytes = idBytes[1:]
+	}
+	size := make([]byte, 8)
+	binary.BigEndian.PutUint64(size, uint64(len(data)))
+	size[0] = 0x01
+	return bytes.Join([][]byte{idBytes, size, data}, nil)
+}
+
+// ebmlUnknownSizeElement returns an EBML element of unknown size
+func ebmlUnknownSizeElement(id uint32, content ...[]byte) []byte {
+	el := ebmlElement(id, content...)
+	// The element IDs we use are 4 bytes long
+	copy(el[4:12], []byte{0x01, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	return el
+}
+
+// ebmlUint returns an EBML element containing an unsigned integer
+func ebmlUint(id uint32, v uint64) []byte {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, v)
+	return ebmlElement(id, data)
+}
+
+// ebmlFloat returns an EBML element containing a float
+func ebmlFloat(id uint32, v float64) []byte {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, math.Float64bits(v))
+	return ebmlElement(id, data)
+}
+
+// matroskaFile returns a Matroska file of the given document type, with
+// a duration of 2.5 seconds, containing the provided tracks and a cluster
+func matroskaFile(docType string, tracks ...[]byte) []byte {
+	return bytes.Join([][]byte{
+		ebmlElement(0x1A45DFA3,
+			ebmlUint(0x4286, 1), // EBMLVersion
+			ebmlElement(0x4282, []byte(docType)),
+		),
+		ebmlUnknownSizeElement(0x18538067,
+			ebmlElement(0x1549A966,
+				ebmlUint(0x2AD7B1, 1000000),
+				ebmlFloat(0x4489, 2500),
+			),
+			ebmlElement(0x1654AE6B, tracks...),
+			ebmlUnknownSizeElement(0x1F43B675, []byte{0xE7, 0x81, 0x00}),
+		),
+	}, nil)
+}
+
+// matroskaVideoTrack returns a video track of the given codec and
+// dimensions
+func matroskaVideoTrack(codecID string, width, height uint64) []byte {
+	return ebmlElement(0xAE,
+		ebmlUint(0x83, 1),
+		ebmlElement(0x86, []byte(codecID)),
+		ebmlElement(0xE0,
+			ebmlUint(0xB0, width),
+			ebmlUint(0xBA, height),
+		),
+	)
+}
+
+// matroskaAudioTrack returns an audio track of the given codec, sample
+// rate and number of channels
+func matroskaAudioTrack(codecID string, sampleRate float64, channels uint64) []byte {
+	return ebmlElement(0xAE,
+		ebmlUint(0x83, 2),
+		ebmlElement(0x86, []byte(codecID)),
+		ebmlElement(0xE1,
+			ebmlFloat(0xB5, sampleRate),
+			ebmlUint(0x9F, channels),
+		),
+	)
+}
+
+func TestInspectMediaMatroska(t *testing.T) {
+	testCases := []struct {
+		description string
+		content     []byte
+		expected    *filetype.MediaInfo
+	}{
+		{
+			"webm",
+			matroskaFile("webm", matroskaVideoTrack("V_VP9", 640, 360), matroskaAudioTrack("A_OPUS", 48000, 2)),
+			&filetype.MediaInfo{
+				MimeType:   "video/webm",
+				Width:      640,
+				Height:     360,
+				VideoCodec: "vp9",
+				AudioCodec: "opus",
+				SampleRate: 48000,
+				Channels:   2,
+			},
+		},
+		{
+			"mkv",
+			matroskaFile("matroska", matroskaVideoTrack("V_MPEG4/ISO/AVC", 1920, 1080)),
+			&filetype.MediaInfo{
+				MimeType:   "video/x-matroska",
+				Width:      1920,
+				Height:     1080,
+				VideoCodec: "h264",
+			},
+		},
+		{
+			"audio only",
+			matroskaFile("webm", matroskaAudioTrack("A_VORBIS", 44100, 1)),
+			&filetype.MediaInfo{
+				MimeType:   "video/webm",
+				AudioCodec: "vorbis",
+				SampleRate: 44100,
+				Channels:   1,
+			},
+		},
+		{
+			"unknown codec",
+			matroskaFile("matroska", matroskaVideoTrack("V_SOMETHING", 10, 10)),
+			&filetype.MediaInfo{
+				MimeType:   "video/x-matroska",
+				Width:      10,
+				Height:     10,
+				VideoCodec: "something",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			mime, err := filetype.MimeType(bytes.NewReader(tc.content))
+			require.NoError(t, err, "MimeType() should have succeed")
+			assert.Equal(t, tc.expected.MimeType, mime, "invalid mimetype")
+
+			info, err := filetype.InspectMedia(bytes.NewReader(tc.content))
+			require.NoError(t, err, "InspectMedia() should have succeed")
+			// The duration and the bitrate are the same for all the files
+			tc.expected.Duration = 2500 * time.Millisecond
+			tc.expected.Bitrate = int64(len(tc.content)) * 8 * 2 / 5
+			assert.Equal(t, tc.expected, info)
+		})
+	}
+}
+
+func TestInspectMediaInvalidMatroska(t *testing.T) {
+	webm := matroskaFile("webm", matroskaVideoTrack("V_VP9", 640, 360))
+
+	testCases := []struct {
+		description string
+		content     []byte
+	}{
+		{"truncated file", webm[:len(webm)-40]},
+		{"no tracks", bytes.Replace(webm, []byte{0x16, 0x54, 0xAE, 0x6B}, []byte{0x16, 0x54, 0xAE, 0x6C}, 1)},
+		{"video track without video element", matroskaFile("webm", ebmlElement(0xAE, ebmlUint(0x83, 1)))},
+		{"unknown doctype", bytes.Replace(webm, []byte("webm"), []byte("abcd"), 1)},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := filetype.InspectMedia(bytes.NewReader(tc.content))
+			require.Error(t, err, "InspectMedia() should have failed")
+			assert.Equal(t, filetype.ErrMsgInvalidMedia, err.Error())
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package filetype
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// mp4Codecs contains the names of the codecs used in MP4 and QuickTime
+// files, indexed by the type of their sample entry
+var mp4Codecs = map[string]string{
+	"avc1": "h264",
+	"avc3": "h264",
+	"hvc1": "h265",
+	"hev1": "h265",
+	"av01": "av1",
+	"vp08": "vp8",
+	"vp09": "vp9",
+	"mp4v": "mpeg4",
+	"apch": "prores",
+	"apcn": "prores",
+	"apcs": "prores",
+	"apco": "prores",
+	"ap4h": "prores",
+	"jpeg": "mjpeg",
+	"mp4a": "aac",
+	"Opus": "opus",
+	"fLaC": "flac",
+	".mp3": "mp3",
+	"ac-3": "ac3",
+	"ec-3": "eac3",
+	"alac": "alac",
+	"samr": "amr_nb",
+	"sawb": "amr_wb",
+	"lpcm": "pcm",
+	"sowt": "pcm",
+	"twos": "pcm",
+}
+
+// parseMP4 parses the movie header and the tracks of an MP4 or a
+// QuickTime file
+// https://www.iso.org/standard/68960.html
+func parseMP4(s *section) (*MediaInfo, error) {
+	boxes, err := readBoxes(s, 0, s.size)
+	if err != nil {
+		return nil, err
+	}
+	if len(boxes) == 0 || boxes[0].typ != "ftyp" {
+		return nil, errMalformed
+	}
+	moov := findBox(boxes, "moov")
+	if moov == nil {
+		return nil, errMalformed
+	}
+	moovBoxes, err := readChildBoxes(s, moov, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &MediaInfo{}
+	mvhd := findBox(moovBoxes, "mvhd")
+	if mvhd == nil {
+		return nil, errMalformed
+	}
+	if info.Duration, err = parseMP4Duration(s, mvhd); err != nil {
+		return nil, err
+	}
+
+	hasVideo := false
+	for _, trak := range moovBoxes {
+		if trak.typ != "trak" {
+			continue
+		}
+		handler, err := parseMP4Track(s, trak, info)
+		if err != nil {
+			return nil, err
+		}
+		hasVideo = hasVideo || handler == "vide"
+	}
+
+	ftyp, err := s.read(0, minInt64(boxes[0].end, sniffLen))
+	if err != nil {
+		return nil, err
+	}
+	info.MimeType = sniffFtyp(ftyp)
+	if info.MimeType == "" {
+		info.MimeType = "video/mp4"
+	}
+	if info.MimeType == "video/mp4" && !hasVideo {
+		info.MimeType = "audio/mp4"
+	}
+	return info, nil
+}
+
+// parseMP4Track parses a trak box and fills the info with the data of
+// the track if it's the first audio or video track. The handler type of
+// the track is returned ("vide", "soun", etc.)
+func parseMP4Track(s *section, trak *box, info *MediaInfo) (string, error) {
+	trakBoxes, err := readChildBoxes(s, trak, 0)
+	if err != nil {
+		return "", err
+	}
+	mdia := findBox(trakBoxes, "mdia")
+	if mdia == nil {
+		return "", errMalformed
+	}
+	mdiaBoxes, err := readChildBoxes(s, mdia, 0)
+	if err != nil {
+		return "", err
+	}
+
+	// The hdlr box is a full box containing 4 bytes of pre_defined,
+	// followed by the handler type
+	hdlr := findBox(mdiaBoxes, "hdlr")
+	if hdlr == nil {
+		return "", errMalformed
+	}
+	handler, err := s.read(hdlr.dataStart+8, 4)
+	if err != nil {
+		return "", err
+	}
+	switch string(handler) {
+	case "vide":
+		if info.VideoCodec != "" {
+			return "vide", nil
+		}
+	case "soun":
+		if info.AudioCodec != "" {
+			return "soun", nil
+		}
+	default:
+		return string(handler), nil
+	}
+
+	// The sample descriptions are in mdia/minf/stbl/stsd
+	var stsd *box
+	if minf := findBox(mdiaBoxes, "minf"); minf != nil {
+		minfBoxes, err := readChildBoxes(s, minf, 0)
+		if err != nil {
+			return "", err
+		}
+		if stbl := findBox(minfBoxes, "stbl"); stbl != nil {
+			stblBoxes, err := readChildBoxes(s, stbl, 0)
+			if err != nil {
+				return "", err
+			}
+			stsd = findBox(stblBoxes, "stsd")
+		}
+	}
+	if stsd == nil {
+		return "", errMalformed
+	}
+	// stsd is a full box that contains the number of entries on 4 bytes,
+	// followed by the entries
+	entries, err := readBoxes(s, stsd.dataStart+8, stsd.end)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 0 {
+		return "", errMalformed
+	}
+	entry := entries[0]
+
+	if string(handler) == "vide" {
+		info.VideoCodec = codecName(mp4Codecs, entry.typ)
+		// The dimensions are stored in 16.16 fixed-point numbers in the
+		// last 8 bytes of the tkhd box, which contains at least 84 bytes
+		tkhd := findBox(trakBoxes, "tkhd")
+		if tkhd == nil || tkhd.end-tkhd.dataStart < 84 {
+			return "", errMalformed
+		}
+		size, err := s.read(tkhd.end-8, 8)
+		if err != nil {
+			return "", err
+		}
+		info.Width = int(binary.BigEndian.Uint32(size[:4]) >> 16)
+		info.Height = int(binary.BigEndian.Uint32(size[4:]) >> 16)
+		return "vide", nil
+	}
+
+	info.AudioCodec = codecName(mp4Codecs, entry.typ)
+	// An audio sample entry contains 8 bytes of common fields, 8
+	// reserved bytes, the number of channels on 2 bytes, the sample size
+	// on 2 bytes, 4 reserved bytes, and the sample rate in a 16.16
+	// fixed-point number
+	audio, err := s.read(entry.dataStart, 28)
+	if err != nil {
+		return "", err
+	}
+	info.Channels = int(binary.BigEndian.Uint16(audio[16:]))
+	info.SampleRate = int(binary.BigEndian.Uint32(audio[24:]) >> 16)
+	return "soun", nil
+}
+
+// parseMP4Duration returns the duration stored in a mvhd box
+func parseMP4Duration(s *section, mvhd *box) (time.Duration, error) {
+	version, err := s.read(mvhd.dataStart, 1)
+	if err != nil {
+		return 0, err
+	}
+	// The version 1 uses 64 bits for the creation time, the modification
+	// time and the duration
+	var timescale, duration uint64
+	switch version[0] {
+	case 0:
+		data, err := s.read(mvhd.dataStart+12, 8)
+		if err != nil {
+			return 0, err
+		}
+		timescale = uint64(binary.BigEndian.Uint32(data))
+		duration = uint64(binary.BigEndian.Uint32(data[4:]))
+		if duration == 0xffffffff {
+			duration = 0
+		}
+	case 1:
+		data, err := s.read(mvhd.dataStart+20, 12)
+		if err != nil {
+			return 0, err
+		}
+		timescale = uint64(binary.BigEndian.Uint32(data))
+		duration = binary.BigEndian.Uint64(data[4:])
+		if duration == 0xffffffffffffffff {
+			duration = 0
+		}
+	default:
+		return 0, errMalformed
+	}
+	return durationOf(duration, timescale), nil
+}
@@ -0,0 +1,163 @@
+package filetype_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/Nivl/go-types/filetype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mp4File returns an MP4 file of the given brand, with a duration of
+// 2.5 seconds, containing the provided tracks
+func mp4File(brand string, tracks ...[]byte) []byte {
+	fullBoxHeader := []byte{0, 0, 0, 0}
+	mvhd := make([]byte, 96)
+	binary.BigEndian.PutUint32(mvhd[12:], 1000) // timescale
+	binary.BigEndian.PutUint32(mvhd[16:], 2500) // duration
+	return bytes.Join([][]byte{
+		isoBox("ftyp", []byte(brand), []byte{0, 0, 0, 0}, []byte(brand), []byte("isom")),
+		isoBox("moov", isoBox("mvhd", mvhd), bytes.Join(tracks, nil)),
+		isoBox("mdat", fullBoxHeader),
+	}, nil)
+}
+
+// mp4VideoTrack returns a video track of the given codec and dimensions
+func mp4VideoTrack(codec string, width, height int) []byte {
+	tkhd := make([]byte, 84)
+	binary.BigEndian.PutUint32(tkhd[76:], uint32(width<<16))
+	binary.BigEndian.PutUint32(tkhd[80:], uint32(height<<16))
+	return mp4Track("vide", isoBox("tkhd", tkhd), isoBox(codec, make([]byte, 78)))
+}
+
+// mp4AudioTrack returns an audio track of the given codec, sample rate
+// and number of channels
+func mp4AudioTrack(codec string, sampleRate, channels int) []byte {
+	entry := make([]byte, 28)
+	binary.BigEndian.PutUint16(entry[16:], uint16(channels))
+	binary.BigEndian.PutUint32(entry[24:], uint32(sampleRate<<16))
+	return mp4Track("soun", isoBox("tkhd", make([]byte, 84)), isoBox(codec, entry))
+}
+
+// mp4Track returns a track of the given handler type, containing a single
+// sample entry
+func mp4Track(handler string, tkhd, entry []byte) []byte {
+	fullBoxHeader := []byte{0, 0, 0, 0}
+	return isoBox("trak",
+		tkhd,
+		isoBox("mdia",
+			isoBox("hdlr", fullBoxHeader, []byte{0, 0, 0, 0}, []byte(handler), make([]byte, 13)),
+			isoBox("minf",
+				isoBox("stbl",
+					isoBox("stsd", fullBoxHeader, []byte{0, 0, 0, 1}, entry),
+				),
+			),
+		),
+	)
+}
+
+func TestInspectMediaMP4(t *testing.T) {
+	testCases := []struct {
+		description string
+		content     []byte
+		expected    *filetype.MediaInfo
+	}{
+		{
+			"mp4 with audio and video",
+			mp4File("mp42", mp4VideoTrack("avc1", 1280, 720), mp4AudioTrack("mp4a", 44100, 2)),
+			&filetype.MediaInfo{
+				MimeType:   "video/mp4",
+				Width:      1280,
+				Height:     720,
+				VideoCodec: "h264",
+				AudioCodec: "aac",
+				SampleRate: 44100,
+				Channels:   2,
+			},
+		},
+		{
+			"mov",
+			mp4File("qt  ", mp4VideoTrack("apcn", 1920, 1080)),
+			&filetype.MediaInfo{
+				MimeType:   "video/quicktime",
+				Width:      1920,
+				Height:     1080,
+				VideoCodec: "prores",
+			},
+		},
+		{
+			"mp4 without video",
+			mp4File("isom", mp4AudioTrack("Opus", 48000, 1)),
+			&filetype.MediaInfo{
+				MimeType:   "audio/mp4",
+				AudioCodec: "opus",
+				SampleRate: 48000,
+				Channels:   1,
+			},
+		},
+		{
+			"m4a",
+			mp4File("M4A ", mp4AudioTrack("mp4a", 44100, 2)),
+			&filetype.MediaInfo{
+				MimeType:   "audio/mp4",
+				AudioCodec: "aac",
+				SampleRate: 44100,
+				Channels:   2,
+			},
+		},
+		{
+			"unknown codec",
+			mp4File("mp42", mp4VideoTrack("abcd", 10, 10)),
+			&filetype.MediaInfo{
+				MimeType:   "video/mp4",
+				Width:      10,
+				Height:     10,
+				VideoCodec: "abcd",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			info, err := filetype.InspectMedia(bytes.NewReader(tc.content))
+			require.NoError(t, err, "InspectMedia() should have succeed")
+			// The duration and the bitrate are the same for all the files
+			tc.expected.Duration = 2500 * time.Millisecond
+			tc.expected.Bitrate = int64(len(tc.content)) * 8 * 2 / 5
+			assert.Equal(t, tc.expected, info)
+		})
+	}
+}
+
+func TestInspectMediaInvalidMP4(t *testing.T) {
+	mp4 := mp4File("mp42", mp4VideoTrack("avc1", 1280, 720))
+	// moov is right after the ftyp box, which is 24 bytes long
+	moovEnd := 24 + int(binary.BigEndian.Uint32(mp4[24:]))
+
+	testCases := []struct {
+		description string
+		content     []byte
+	}{
+		{"truncated file", mp4[:moovEnd-1]},
+		{"no moov", mp4File("mp42")[:24]},
+		{"video track without sample entry", mp4File("mp42", mp4Track("vide", isoBox("tkhd", make([]byte, 84)), nil))},
+		{"tkhd too small", mp4File("mp42", mp4Track("vide", isoBox("tkhd", make([]byte, 4)), isoBox("avc1", make([]byte, 78))))},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := filetype.InspectMedia(bytes.NewReader(tc.content))
+			require.Error(t, err, "InspectMedia() should have failed")
+			assert.Equal(t, filetype.ErrMsgInvalidMedia, err.Error())
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package filetype
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+)
+
+// oggPageHeaderSize is the size of the header of an Ogg page, without
+// its segment table
+const oggPageHeaderSize = 27
+
+// oggMaxPageSize is the maximum size of an Ogg page: the header, 255
+// segments in the segment table, and 255 segments of 255 bytes
+const oggMaxPageSize = oggPageHeaderSize + 255 + 255*255
+
+// oggStream contains the information of a logical stream of an Ogg file
+type oggStream struct {
+	serial uint32
+	// granuleRate is the number of granules per second, used to compute
+	// the duration of the stream
+	granuleRate uint64
+	// granuleOffset is the number of granules to remove from the last
+	// granule position to get the duration (Opus pre-skip)
+	granuleOffset uint64
+	// granuleShift is the number of bits used to store the number of
+	// frames since the last keyframe (Theora)
+	granuleShift uint
+	// frameDuration is the numerator of the duration of a frame, the
+	// denominator being granuleRate (Theora)
+	frameDuration uint64
+}
+
+// parseOgg parses the first page of each logical stream of an Ogg file
+// to find their codec, and the last page of the first audio stream (or
+// of the video stream if there is no audio) to find the duration
+// https://www.rfc-editor.org/rfc/rfc3533
+func parseOgg(s *section) (*MediaInfo, error) {
+	info := &MediaInfo{MimeType: "application/ogg"}
+	var audio, video *oggStream
+
+	// Each logical stream starts with a "beginning of stream" page, and
+	// all of them are located at the beginning of the file
+	for offset := int64(0); offset < s.size; {
+		header, err := s.read(offset, oggPageHeaderSize)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.HasPrefix(header, []byte("OggS\x00")) {
+			return nil, errMalformed
+		}
+		if header[5]&0x02 == 0 {
+			if offset == 0 {
+				return nil, errMalformed
+			}
+			break
+		}
+		segments, err := s.read(offset+oggPageHeaderSize, int64(header[26]))
+		if err != nil {
+			return nil, err
+		}
+		var bodySize int64
+		for _, size := range segments {
+			bodySize += int64(size)
+		}
+		body, err := s.read(offset+oggPageHeaderSize+int64(len(segments)), minInt64(bodySize, 64))
+		if err != nil {
+			return nil, err
+		}
+
+		stream := &oggStream{serial: binary.LittleEndian.Uint32(header[14:])}
+		switch parseOggStream(body, stream, info) {
+		case "audio":
+			if audio == nil {
+				audio = stream
+			}
+		case "video":
+			if video == nil {
+				video = stream
+			}
+		}
+		offset += oggPageHeaderSize + int64(len(segments)) + bodySize
+	}
+
+	switch {
+	case video != nil:
+		info.MimeType = "video/ogg"
+	case audio != nil:
+		info.MimeType = "audio/ogg"
+	}
+	stream := audio
+	if stream == nil {
+		stream = video
+	}
+	if stream != nil {
+		granule, err := lastOggGranule(s, stream.serial)
+		if err != nil {
+			return nil, err
+		}
+		info.Duration = oggDuration(stream, granule)
+	}
+	return info, nil
+}
+
+// parseOggStream identifies the codec of a logical stream using its first
+// packet, and fills the info with the data of the stream if it's the first
+// audio or video stream. "audio" or "video" is returned if the codec is
+// known
+func parseOggStream(packet []byte, stream *oggStream, info *MediaInfo) string {
+	var codec string
+	var rate, channels int
+	switch {
+	case bytes.HasPrefix(packet, []byte("OpusHead")) && len(packet) >= 19:
+		// The granule position is always expressed at 48kHz, whatever
+		// the sample rate of the original audio
+		codec, rate, channels = "opus", 48000, int(packet[9])
+		stream.granuleOffset = uint64(binary.LittleEndian.Uint16(packet[10:]))
+	case bytes.HasPrefix(packet, []byte("\x01vorbis")) && len(packet) >= 16:
+		codec = "vorbis"
+		channels = int(packet[11])
+		rate = int(binary.LittleEndian.Uint32(packet[12:]))
+	case bytes.HasPrefix(packet, []byte("\x7fFLAC")) && len(packet) >= 51:
+		// The STREAMINFO block is located after a 9 bytes header, the fLaC
+		// signature and the header of the block
+		codec = "flac"
+		rate, channels, _ = parseFLACStreamInfo(packet[17:])
+	case bytes.HasPrefix(packet, []byte("Speex   ")) && len(packet) >= 52:
+		codec = "speex"
+		rate = int(binary.LittleEndian.Uint32(packet[36:]))
+		channels = int(binary.LittleEndian.Uint32(packet[48:]))
+	case bytes.HasPrefix(packet, []byte("\x80theora")) && len(packet) >= 42:
+		// The frame rate is a fraction, and the granule position contains
+		// the number of the last keyframe and the number of frames since
+		// the keyframe
+		stream.granuleRate = uint64(binary.BigEndian.Uint32(packet[22:]))
+		stream.frameDuration = uint64(binary.BigEndian.Uint32(packet[26:]))
+		stream.granuleShift = uint(binary.BigEndian.Uint16(packet[40:])>>5) & 0x1f
+		if info.VideoCodec == "" {
+			info.VideoCodec = "theora"
+			info.Width = int(packet[14])<<16 | int(packet[15])<<8 | int(packet[16])
+			info.Height = int(packet[17])<<16 | int(packet[18])<<8 | int(packet[19])
+		}
+		return "video"
+	default:
+		return ""
+	}
+
+	stream.granuleRate = uint64(rate)
+	stream.frameDuration = 1
+	if info.AudioCodec == "" {
+		info.AudioCodec = codec
+		info.SampleRate = rate
+		info.Channels = channels
+	}
+	return "audio"
+}
+
+// lastOggGranule returns the granule position of the last page of a
+// logical stream, or 0 if it cannot be found. Only the end of the file
+// is read
+func lastOggGranule(s *section, serial uint32) (uint64, error) {
+	start := s.size - minInt64(s.size, 2*oggMaxPageSize)
+	data, err := s.read(start, s.size-start)
+	if err != nil {
+		return 0, err
+	}
+	for end := len(data); end > 0; {
+		i := bytes.LastIndex(data[:end], []byte("OggS\x00"))
+		if i == -1 {
+			break
+		}
+		end = i
+		if i+oggPageHeaderSize > len(data) || binary.LittleEndian.Uint32(data[i+14:]) != serial {
+			continue
+		}
+		// A granule position of -1 means no packet ends on the page
+		if granule := binary.LittleEndian.Uint64(data[i+6:]); granule != 0xffffffffffffffff {
+			return granule, nil
+		}
+	}
+	return 0, nil
+}
+
+// oggDuration returns the duration of a logical stream, using the
+// granule position of its last page
+func oggDuration(stream *oggStream, granule uint64) time.Duration {
+	if stream.granuleShift > 0 {
+		granule = granule>>stream.granuleShift + granule&(1<<stream.granuleShift-1)
+	}
+	if granule < stream.granuleOffset {
+		return 0
+	}
+	granule -= stream.granuleOffset
+	return durationOf(granule*stream.frameDuration, stream.granuleRate)
+}
@@ -0,0 +1,190 @@
+package filetype_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/Nivl/go-types/filetype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// oggPage returns an Ogg page of the given logical stream containing a
+// single packet. The checksum is not computed
+func oggPage(headerType byte, granule uint64, serial uint32, packet []byte) []byte {
+	header := make([]byte, 27)
+	copy(header, "OggS")
+	header[5] = headerType
+	binary.LittleEndian.PutUint64(header[6:], granule)
+	binary.LittleEndian.PutUint32(header[14:], serial)
+
+	segments := []byte{}
+	size := len(packet)
+	for ; size >= 255; size -= 255 {
+		segments = append(segments, 255)
+	}
+	segments = append(segments, byte(size))
+	header[26] = byte(len(segments))
+	return bytes.Join([][]byte{header, segments, packet}, nil)
+}
+
+// opusHead returns the identification header of an Opus stream
+func opusHead(channels byte, preSkip uint16) []byte {
+	packet := make([]byte, 19)
+	copy(packet, "OpusHead")
+	packet[8] = 1
+	packet[9] = channels
+	binary.LittleEndian.PutUint16(packet[10:], preSkip)
+	binary.LittleEndian.PutUint32(packet[12:], 44100)
+	return packet
+}
+
+// vorbisHeader returns the identification header of a Vorbis stream
+func vorbisHeader(channels byte, rate uint32) []byte {
+	packet := make([]byte, 30)
+	copy(packet, "\x01vorbis")
+	packet[11] = channels
+	binary.LittleEndian.PutUint32(packet[12:], rate)
+	return packet
+}
+
+// theoraHeader returns the identification header of a Theora stream
+// of 10 fps, using 6 bits for the frames since the last keyframe
+func theoraHeader(width, height int) []byte {
+	packet := make([]byte, 42)
+	copy(packet, "\x80theora")
+	packet[7], packet[8], packet[9] = 3, 2, 1
+	packet[14], packet[15], packet[16] = byte(width>>16), byte(width>>8), byte(width)
+	packet[17], packet[18], packet[19] = byte(height>>16), byte(height>>8), byte(height)
+	binary.BigEndian.PutUint32(packet[22:], 10)
+	binary.BigEndian.PutUint32(packet[26:], 1)
+	binary.BigEndian.PutUint16(packet[40:], 6<<5)
+	return packet
+}
+
+func TestInspectMediaOgg(t *testing.T) {
+	testCases := []struct {
+		description string
+		content     []byte
+		expected    *filetype.MediaInfo
+	}{
+		{
+			"opus",
+			bytes.Join([][]byte{
+				oggPage(0x02, 0, 1, opusHead(2, 312)),
+				oggPage(0x00, 0, 1, []byte("OpusTags")),
+				oggPage(0x00, 48000, 1, make([]byte, 100)),
+				oggPage(0x04, 120312, 1, make([]byte, 100)),
+			}, nil),
+			&filetype.MediaInfo{
+				MimeType:   "audio/ogg",
+				AudioCodec: "opus",
+				SampleRate: 48000,
+				Channels:   2,
+			},
+		},
+		{
+			"vorbis",
+			bytes.Join([][]byte{
+				oggPage(0x02, 0, 1, vorbisHeader(1, 44100)),
+				oggPage(0x00, 44100, 1, make([]byte, 300)),
+				oggPage(0x04, 110250, 1, make([]byte, 300)),
+			}, nil),
+			&filetype.MediaInfo{
+				MimeType:   "audio/ogg",
+				AudioCodec: "vorbis",
+				SampleRate: 44100,
+				Channels:   1,
+			},
+		},
+		{
+			"theora and vorbis",
+			bytes.Join([][]byte{
+				oggPage(0x02, 0, 1, theoraHeader(320, 240)),
+				oggPage(0x02, 0, 2, vorbisHeader(2, 48000)),
+				oggPage(0x00, 0, 1, make([]byte, 10)),
+				// 25 frames, stored as keyframe 24 and 1 frame since the keyframe
+				oggPage(0x04, 24<<6|1, 1, make([]byte, 10)),
+				oggPage(0x04, 120000, 2, make([]byte, 10)),
+			}, nil),
+			&filetype.MediaInfo{
+				MimeType:   "video/ogg",
+				Width:      320,
+				Height:     240,
+				VideoCodec: "theora",
+				AudioCodec: "vorbis",
+				SampleRate: 48000,
+				Channels:   2,
+			},
+		},
+		{
+			"theora",
+			bytes.Join([][]byte{
+				oggPage(0x02, 0, 1, theoraHeader(320, 240)),
+				oggPage(0x04, 24<<6|1, 1, make([]byte, 10)),
+			}, nil),
+			&filetype.MediaInfo{
+				MimeType:   "video/ogg",
+				Width:      320,
+				Height:     240,
+				VideoCodec: "theora",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			mime, err := filetype.MimeType(bytes.NewReader(tc.content))
+			require.NoError(t, err, "MimeType() should have succeed")
+			assert.Equal(t, tc.expected.MimeType, mime, "invalid mimetype")
+
+			info, err := filetype.InspectMedia(bytes.NewReader(tc.content))
+			require.NoError(t, err, "InspectMedia() should have succeed")
+			// The duration and the bitrate are the same for all the files
+			tc.expected.Duration = 2500 * time.Millisecond
+			tc.expected.Bitrate = int64(len(tc.content)) * 8 * 2 / 5
+			assert.Equal(t, tc.expected, info)
+		})
+	}
+}
+
+func TestInspectMediaOggUnknownCodec(t *testing.T) {
+	content := oggPage(0x02, 0, 1, []byte("unknown codec"))
+
+	mime, err := filetype.MimeType(bytes.NewReader(content))
+	require.NoError(t, err, "MimeType() should have succeed")
+	assert.Equal(t, "application/ogg", mime, "invalid mimetype")
+
+	info, err := filetype.InspectMedia(bytes.NewReader(content))
+	require.NoError(t, err, "InspectMedia() should have succeed")
+	assert.Equal(t, &filetype.MediaInfo{MimeType: "application/ogg"}, info)
+}
+
+func TestInspectMediaInvalidOgg(t *testing.T) {
+	opus := oggPage(0x02, 0, 1, opusHead(2, 312))
+
+	testCases := []struct {
+		description string
+		content     []byte
+	}{
+		{"truncated file", opus[:len(opus)-1]},
+		{"first page is not a beginning of stream", oggPage(0x00, 0, 1, opusHead(2, 312))},
+		{"garbage after the first page", append(opus, []byte("garbage that is not an ogg page")...)},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := filetype.InspectMedia(bytes.NewReader(tc.content))
+			require.Error(t, err, "InspectMedia() should have failed")
+			assert.Equal(t, filetype.ErrMsgInvalidMedia, err.Error())
+		})
+	}
+}
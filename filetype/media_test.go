@@ -0,0 +1,67 @@
+package filetype_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/Nivl/go-types/filetype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspectMediaUnsupported(t *testing.T) {
+	testCases := []struct {
+		description string
+		content     []byte
+	}{
+		{"png", readFixture(t, "black_pixel.png")},
+		{"pdf", pdfFile(1, "")},
+		{"heic", heifFile("heic", "pict")},
+		{"text", []byte("hello world")},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			info, err := filetype.InspectMedia(bytes.NewReader(tc.content))
+			require.Error(t, err, "InspectMedia() should have failed")
+			assert.Equal(t, filetype.ErrMsgUnsupportedMediaFormat, err.Error())
+			assert.Nil(t, info)
+		})
+	}
+}
+
+func TestInspectMediaReaderPosition(t *testing.T) {
+	content := append([]byte("prefix"), flacFile(44100, 2, 110250)...)
+	r := bytes.NewReader(content)
+	_, err := r.Seek(6, io.SeekStart)
+	require.NoError(t, err, "Seek() should have succeed")
+
+	info, err := filetype.InspectMedia(r)
+	require.NoError(t, err, "InspectMedia() should have succeed")
+	assert.Equal(t, "audio/flac", info.MimeType)
+
+	pos, err := r.Seek(0, io.SeekCurrent)
+	require.NoError(t, err, "Seek() should have succeed")
+	assert.Equal(t, int64(6), pos, "the reader should have been put back to its original position")
+}
+
+func TestInspectMediaEmptyFile(t *testing.T) {
+	_, err := filetype.InspectMedia(bytes.NewReader(nil))
+	require.Error(t, err, "InspectMedia() should have failed")
+	assert.Equal(t, filetype.ErrMsgEmptyFile, err.Error())
+}
+
+func TestMediaInfoJSON(t *testing.T) {
+	info, err := filetype.InspectMedia(bytes.NewReader(flacFile(44100, 2, 110250)))
+	require.NoError(t, err, "InspectMedia() should have succeed")
+
+	data, err := json.Marshal(info)
+	require.NoError(t, err, "json.Marshal() should have succeed")
+	expected := `{"mime_type":"audio/flac","duration":2500000000,"bitrate":454,"audio_codec":"flac","sample_rate":44100,"channels":2}`
+	assert.JSONEq(t, expected, string(data))
+}
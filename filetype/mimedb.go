@@ -0,0 +1,320 @@
+package filetype
+
+import (
+	"strings"
+)
+
+// mimeEntry contains the information we have about a mimetype
+type mimeEntry struct {
+	mimeType string
+	// extensions contains the extensions used by the type. The first one
+	// is the canonical extension
+	extensions []string
+	// dangerous is true for the types that can be executed, or rendered
+	// with scripts, by the OS or a browser
+	dangerous bool
+}
+
+// mimeDB contains the mimetypes and extensions known by the package.
+// It is embedded to not depend on the mime.types file of the OS, which
+// varies from one system to another.
+// When several types use the same extension, the first one is used by
+// MimeForExtension().
+// The names used are the ones returned by MimeType() when the type can be
+// detected, other names of the same type are listed in mimeAliases.
+var mimeDB = []mimeEntry{
+	// Images
+	{mimeType: "image/png", extensions: []string{".png"}},
+	{mimeType: "image/jpeg", extensions: []string{".jpg", ".jpeg", ".jpe", ".jfif"}},
+	{mimeType: "image/gif", extensions: []string{".gif"}},
+	{mimeType: "image/webp", extensions: []string{".webp"}},
+	{mimeType: "image/bmp", extensions: []string{".bmp", ".dib"}},
+	{mimeType: "image/tiff", extensions: []string{".tiff", ".tif"}},
+	{mimeType: "image/x-icon", extensions: []string{".ico", ".cur"}},
+	{mimeType: "image/heic", extensions: []string{".heic"}},
+	{mimeType: "image/heic-sequence", extensions: []string{".heics"}},
+	{mimeType: "image/heif", extensions: []string{".heif", ".hif"}},
+	{mimeType: "image/heif-sequence", extensions: []string{".heifs"}},
+	{mimeType: "image/avif", extensions: []string{".avif"}},
+	{mimeType: "image/svg+xml", extensions: []string{".svg", ".svgz"}},
+
+	// Audio
+	{mimeType: "audio/mpeg", extensions: []string{".mp3", ".mpga"}},
+	{mimeType: "audio/wave", extensions: []string{".wav"}},
+	{mimeType: "audio/aiff", extensions: []string{".aiff", ".aif", ".aifc"}},
+	{mimeType: "audio/basic", extensions: []string{".au", ".snd"}},
+	{mimeType: "audio/midi", extensions: []string{".mid", ".midi"}},
+	{mimeType: "audio/flac", extensions: []string{".flac"}},
+	{mimeType: "audio/ogg", extensions: []string{".ogg", ".oga", ".opus", ".spx"}},
+	{mimeType: "audio/mp4", extensions: []string{".m4a"}},
+	{mimeType: "audio/aac", extensions: []string{".aac"}},
+
+	// Video
+	{mimeType: "video/mp4", extensions: []string{".mp4", ".m4v"}},
+	{mimeType: "video/quicktime", extensions: []string{".mov", ".qt"}},
+	{mimeType: "video/webm", extensions: []string{".webm"}},
+	{mimeType: "video/x-matroska", extensions: []string{".mkv"}},
+	{mimeType: "video/avi", extensions: []string{".avi"}},
+	{mimeType: "video/ogg", extensions: []string{".ogv"}},
+	{mimeType: "video/mpeg", extensions: []string{".mpeg", ".mpg"}},
+	{mimeType: "application/ogg", extensions: []string{".ogx"}},
+
+	// Documents
+	{mimeType: "application/pdf", extensions: []string{".pdf"}},
+	{mimeType: "application/postscript", extensions: []string{".ps", ".eps", ".ai"}},
+	{mimeType: "application/rtf", extensions: []string{".rtf"}},
+	{mimeType: "application/msword", extensions: []string{".doc", ".dot"}},
+	{mimeType: "application/vnd.ms-excel", extensions: []string{".xls", ".xlt"}},
+	{mimeType: "application/vnd.ms-powerpoint", extensions: []string{".ppt", ".pot", ".pps"}},
+	{mimeType: "application/vnd.openxmlformats-officedocument.wordprocessingml.document", extensions: []string{".docx"}},
+	{mimeType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", extensions: []string{".xlsx"}},
+	{mimeType: "application/vnd.openxmlformats-officedocument.presentationml.presentation", extensions: []string{".pptx"}},
+	{mimeType: "application/vnd.ms-word.document.macroenabled.12", extensions: []string{".docm"}, dangerous: true},
+	{mimeType: "application/vnd.ms-excel.sheet.macroenabled.12", extensions: []string{".xlsm"}, dangerous: true},
+	{mimeType: "application/vnd.ms-powerpoint.presentation.macroenabled.12", extensions: []string{".pptm"}, dangerous: true},
+	{mimeType: "application/vnd.oasis.opendocument.text", extensions: []string{".odt"}},
+	{mimeType: "application/vnd.oasis.opendocument.spreadsheet", extensions: []string{".ods"}},
+	{mimeType: "application/vnd.oasis.opendocument.presentation", extensions: []string{".odp"}},
+	{mimeType: "application/epub+zip", extensions: []string{".epub"}},
+	{mimeType: "application/x-ole-storage", extensions: []string{".ole"}},
+
+	// Text
+	{mimeType: "text/plain", extensions: []string{".txt", ".text", ".log", ".md", ".conf", ".ini"}},
+	{mimeType: "text/csv", extensions: []string{".csv"}},
+	{mimeType: "text/tab-separated-values", extensions: []string{".tsv"}},
+	{mimeType: "text/markdown", extensions: []string{".markdown"}},
+	{mimeType: "text/calendar", extensions: []string{".ics"}},
+	{mimeType: "text/vcard", extensions: []string{".vcf"}},
+	{mimeType: "text/css", extensions: []string{".css"}},
+	{mimeType: "text/xml", extensions: []string{".xml", ".xsl"}},
+	{mimeType: "application/json", extensions: []string{".json", ".map"}},
+	{mimeType: "application/yaml", extensions: []string{".yaml", ".yml"}},
+	{mimeType: "text/html", extensions: []string{".html", ".htm", ".shtml"}, dangerous: true},
+	{mimeType: "application/xhtml+xml", extensions: []string{".xhtml", ".xht"}, dangerous: true},
+
+	// Archives
+	{mimeType: "application/zip", extensions: []string{".zip"}},
+	{mimeType: "application/x-gzip", extensions: []string{".gz", ".tgz"}},
+	{mimeType: "application/x-tar", extensions: []string{".tar"}},
+	{mimeType: "application/x-bzip2", extensions: []string{".bz2", ".tbz2"}},
+	{mimeType: "application/x-xz", extensions: []string{".xz", ".txz"}},
+	{mimeType: "application/zstd", extensions: []string{".zst"}},
+	{mimeType: "application/x-7z-compressed", extensions: []string{".7z"}},
+	{mimeType: "application/x-rar-compressed", extensions: []string{".rar"}},
+
+	// Fonts
+	{mimeType: "font/ttf", extensions: []string{".ttf"}},
+	{mimeType: "font/otf", extensions: []string{".otf"}},
+	{mimeType: "font/collection", extensions: []string{".ttc"}},
+	{mimeType: "font/woff", extensions: []string{".woff"}},
+	{mimeType: "font/woff2", extensions: []string{".woff2"}},
+	{mimeType: "application/vnd.ms-fontobject", extensions: []string{".eot"}},
+
+	// Executables and scripts
+	{mimeType: "application/vnd.microsoft.portable-executable", extensions: []string{".exe", ".dll", ".sys", ".scr", ".cpl", ".ocx", ".com"}, dangerous: true},
+	{mimeType: "application/x-msi", extensions: []string{".msi", ".msp"}, dangerous: true},
+	{mimeType: "application/x-executable", extensions: []string{".elf", ".bin", ".so"}, dangerous: true},
+	{mimeType: "application/x-mach-binary", extensions: []string{".dylib"}, dangerous: true},
+	{mimeType: "application/x-apple-diskimage", extensions: []string{".dmg"}, dangerous: true},
+	{mimeType: "application/vnd.android.package-archive", extensions: []string{".apk"}, dangerous: true},
+	{mimeType: "application/java-archive", extensions: []string{".jar", ".war", ".ear"}, dangerous: true},
+	{mimeType: "application/x-ms-shortcut", extensions: []string{".lnk"}, dangerous: true},
+	{mimeType: "application/hta", extensions: []string{".hta"}, dangerous: true},
+	{mimeType: "application/wasm", extensions: []string{".wasm"}, dangerous: true},
+	{mimeType: "text/javascript", extensions: []string{".js", ".mjs", ".cjs", ".jse"}, dangerous: true},
+	{mimeType: "text/vbscript", extensions: []string{".vbs", ".vbe", ".wsf"}, dangerous: true},
+	{mimeType: "text/x-shellscript", extensions: []string{".sh", ".bash", ".zsh", ".csh", ".ksh"}, dangerous: true},
+	{mimeType: "text/x-python", extensions: []string{".py", ".pyw"}, dangerous: true},
+	{mimeType: "text/x-perl", extensions: []string{".pl"}, dangerous: true},
+	{mimeType: "application/x-msdos-program", extensions: []string{".bat", ".cmd"}, dangerous: true},
+	{mimeType: "application/x-powershell", extensions: []string{".ps1", ".psm1"}, dangerous: true},
+	{mimeType: "application/x-php", extensions: []string{".php", ".phtml", ".phar"}, dangerous: true},
+	{mimeType: "application/x-ms-application", extensions: []string{".application"}, dangerous: true},
+	{mimeType: "application/vnd.ms-cab-compressed", extensions: []string{".cab"}, dangerous: true},
+	{mimeType: "application/x-ms-regedit", extensions: []string{".reg"}, dangerous: true},
+}
+
+// mimeAliases contains the other names used for the types of mimeDB
+var mimeAliases = map[string]string{
+	"image/jpg":                          "image/jpeg",
+	"image/pjpeg":                        "image/jpeg",
+	"image/x-png":                        "image/png",
+	"image/x-ms-bmp":                     "image/bmp",
+	"image/vnd.microsoft.icon":           "image/x-icon",
+	"audio/mp3":                          "audio/mpeg",
+	"audio/wav":                          "audio/wave",
+	"audio/x-wav":                        "audio/wave",
+	"audio/vnd.wave":                     "audio/wave",
+	"audio/x-aiff":                       "audio/aiff",
+	"audio/x-flac":                       "audio/flac",
+	"audio/x-m4a":                        "audio/mp4",
+	"video/x-msvideo":                    "video/avi",
+	"video/msvideo":                      "video/avi",
+	"application/gzip":                   "application/x-gzip",
+	"application/x-zip-compressed":       "application/zip",
+	"application/vnd.rar":                "application/x-rar-compressed",
+	"application/x-bzip":                 "application/x-bzip2",
+	"application/xml":                    "text/xml",
+	"text/json":                          "application/json",
+	"application/x-yaml":                 "application/yaml",
+	"text/yaml":                          "application/yaml",
+	"application/javascript":             "text/javascript",
+	"application/x-javascript":           "text/javascript",
+	"application/ecmascript":             "text/javascript",
+	"application/x-sh":                   "text/x-shellscript",
+	"application/x-shellscript":          "text/x-shellscript",
+	"application/x-msdownload":           "application/vnd.microsoft.portable-executable",
+	"application/x-dosexec":              "application/vnd.microsoft.portable-executable",
+	"application/x-ms-installer":         "application/x-msi",
+	"application/x-sharedlib":            "application/x-executable",
+	"application/x-elf":                  "application/x-executable",
+	"application/x-font-ttf":             "font/ttf",
+	"application/font-woff":              "font/woff",
+	"application/x-matroska":             "video/x-matroska",
+	"application/vnd.ms-word":            "application/msword",
+	"application/x-httpd-php":            "application/x-php",
+	"application/vnd.ms-htmlhelp":        "application/hta",
+	"application/x-java-archive":         "application/java-archive",
+	"application/x-apple-diskimage-data": "application/x-apple-diskimage",
+}
+
+// mimeContainers contains the types that are used as a container by other
+// types, and that are returned by MimeType() for those types.
+// Ex. A .docx file is a zip file, and will be detected as such.
+var mimeContainers = map[string][]string{
+	"application/zip": {
+		"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		"application/vnd.openxmlformats-officedocument.presentationml.presentation",
+		"application/vnd.ms-word.document.macroenabled.12",
+		"application/vnd.ms-excel.sheet.macroenabled.12",
+		"application/vnd.ms-powerpoint.presentation.macroenabled.12",
+		"application/vnd.oasis.opendocument.text",
+		"application/vnd.oasis.opendocument.spreadsheet",
+		"application/vnd.oasis.opendocument.presentation",
+		"application/epub+zip",
+		"application/java-archive",
+		"application/vnd.android.package-archive",
+	},
+	"application/x-ole-storage": {
+		"application/msword",
+		"application/vnd.ms-excel",
+		"application/vnd.ms-powerpoint",
+		"application/x-msi",
+		// encrypted OOXML documents
+		"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		"application/vnd.openxmlformats-officedocument.presentationml.presentation",
+		"application/vnd.ms-word.document.macroenabled.12",
+		"application/vnd.ms-excel.sheet.macroenabled.12",
+		"application/vnd.ms-powerpoint.presentation.macroenabled.12",
+	},
+	"application/ogg": {"audio/ogg", "video/ogg"},
+	"video/mp4":       {"audio/mp4"},
+	"text/xml":        {"image/svg+xml", "application/xhtml+xml"},
+	"application/x-gzip": {
+		"image/svg+xml", // .svgz
+	},
+}
+
+// mimeIndex contains the entries of mimeDB indexed by mimetype
+var mimeIndex = indexMimeDB()
+
+// extensionIndex contains the entries of mimeDB indexed by extension
+var extensionIndex = indexExtensions()
+
+// indexMimeDB returns the entries of mimeDB indexed by mimetype
+func indexMimeDB() map[string]*mimeEntry {
+	index := make(map[string]*mimeEntry, len(mimeDB))
+	for i := range mimeDB {
+		index[mimeDB[i].mimeType] = &mimeDB[i]
+	}
+	return index
+}
+
+// indexExtensions returns the entries of mimeDB indexed by extension
+func indexExtensions() map[string]*mimeEntry {
+	index := make(map[string]*mimeEntry, len(mimeDB))
+	for i := range mimeDB {
+		for _, ext := range mimeDB[i].extensions {
+			if _, exists := index[ext]; !exists {
+				index[ext] = &mimeDB[i]
+			}
+		}
+	}
+	return index
+}
+
+// canonicalMimeType returns the name used by mimeDB for the provided type.
+// The parameters of the type are removed
+func canonicalMimeType(mimeType string) string {
+	mimeType = baseMediaType(mimeType)
+	if canonical, ok := mimeAliases[mimeType]; ok {
+		return canonical
+	}
+	return mimeType
+}
+
+// normalizeExtension returns the extension in lower case with a leading dot
+func normalizeExtension(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if ext != "" && ext[0] != '.' {
+		ext = "." + ext
+	}
+	return ext
+}
+
+// ExtensionsFor returns the extensions used by the provided mimetype.
+// The first extension is the canonical one. nil is returned if the type is
+// unknown.
+func ExtensionsFor(mimeType string) []string {
+	entry, ok := mimeIndex[canonicalMimeType(mimeType)]
+	if !ok {
+		return nil
+	}
+	exts := make([]string, len(entry.extensions))
+	copy(exts, entry.extensions)
+	return exts
+}
+
+// MimeForExtension returns the mimetype of the provided extension.
+// The extension is case insensitive, and the leading dot is optional.
+// An empty string is returned if the extension is unknown.
+// Unlike mime.TypeByExtension(), the result doesn't depend on the
+// configuration of the OS.
+func MimeForExtension(ext string) string {
+	entry, ok := extensionIndex[normalizeExtension(ext)]
+	if !ok {
+		return ""
+	}
+	return entry.mimeType
+}
+
+// IsDangerousType returns true if the provided mimetype can be executed,
+// or rendered with scripts, by the OS or a browser (executables, scripts,
+// HTML, etc.)
+func IsDangerousType(mimeType string) bool {
+	entry, ok := mimeIndex[canonicalMimeType(mimeType)]
+	return ok && entry.dangerous
+}
+
+// isCompatibleType checks if a file detected as detectedType can be of
+// type expectedType.
+// Both types must be canonical.
+func isCompatibleType(detectedType, expectedType string) bool {
+	if detectedType == expectedType {
+		return true
+	}
+	// We cannot sniff the exact type of most text formats
+	if detectedType == "text/plain" {
+		return strings.HasPrefix(expectedType, "text/") ||
+			expectedType == "application/json" ||
+			expectedType == "application/yaml"
+	}
+	for _, t := range mimeContainers[detectedType] {
+		if t == expectedType {
+			return true
+		}
+	}
+	return false
+}
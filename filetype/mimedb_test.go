@@ -0,0 +1,90 @@
+package filetype_test
+
+import (
+	"testing"
+
+	"github.com/Nivl/go-types/filetype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtensionsFor(t *testing.T) {
+	testCases := []struct {
+		mimeType string
+		expected []string
+	}{
+		{"image/png", []string{".png"}},
+		{"image/jpeg", []string{".jpg", ".jpeg", ".jpe", ".jfif"}},
+		{"IMAGE/JPEG", []string{".jpg", ".jpeg", ".jpe", ".jfif"}},
+		{"image/jpg", []string{".jpg", ".jpeg", ".jpe", ".jfif"}},
+		{"text/plain; charset=utf-8", []string{".txt", ".text", ".log", ".md", ".conf", ".ini"}},
+		{"application/gzip", []string{".gz", ".tgz"}},
+		{"application/x-unknown", nil},
+		{"", nil},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.mimeType, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, filetype.ExtensionsFor(tc.mimeType), "invalid extensions")
+		})
+	}
+}
+
+func TestExtensionsForReturnsACopy(t *testing.T) {
+	t.Parallel()
+
+	exts := filetype.ExtensionsFor("image/png")
+	exts[0] = ".nope"
+	assert.Equal(t, []string{".png"}, filetype.ExtensionsFor("image/png"), "the database should not be modifiable")
+}
+
+func TestMimeForExtension(t *testing.T) {
+	testCases := []struct {
+		ext      string
+		expected string
+	}{
+		{".png", "image/png"},
+		{"png", "image/png"},
+		{".PNG", "image/png"},
+		{".jpeg", "image/jpeg"},
+		{".svg", "image/svg+xml"},
+		{".exe", "application/vnd.microsoft.portable-executable"},
+		{".docx", "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		{".nope", ""},
+		{"", ""},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.ext, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, filetype.MimeForExtension(tc.ext), "invalid mimetype")
+		})
+	}
+}
+
+func TestIsDangerousType(t *testing.T) {
+	testCases := []struct {
+		mimeType string
+		expected bool
+	}{
+		{"image/png", false},
+		{"application/pdf", false},
+		{"text/plain; charset=utf-8", false},
+		{"text/html; charset=utf-8", true},
+		{"application/x-msdownload", true},
+		{"application/vnd.microsoft.portable-executable", true},
+		{"application/javascript", true},
+		{"text/x-shellscript", true},
+		{"application/x-unknown", false},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.mimeType, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, filetype.IsDangerousType(tc.mimeType))
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package filetype
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// compoundExtensions contains the extensions made of two known extensions
+// that are legitimately used together
+var compoundExtensions = map[string]bool{
+	".tar.gz":  true,
+	".tar.bz2": true,
+	".tar.xz":  true,
+	".tar.zst": true,
+}
+
+// CheckName makes sure the name of a file is consistent with its content.
+// It flags the files which extension doesn't match their content, the files
+// having a double extension (invoice.pdf.exe), and the files that are of a
+// dangerous type (see IsDangerousType()), whether it's according to their
+// content or their extension.
+// The reader will be put back to its original position. Use Peek() and
+// CheckNameForType() for readers that cannot be seeked.
+// An error is only returned if the file could not be read.
+func CheckName(filename string, r io.ReadSeeker) ([]Violation, error) {
+	mimeType, err := MimeType(r)
+	if err != nil && err != errEmptyFile {
+		return nil, err
+	}
+	return CheckNameForType(filename, mimeType), nil
+}
+
+// CheckNameForType makes sure the name of a file is consistent with its
+// detected mimetype.
+// See CheckName() for the list of checks.
+func CheckNameForType(filename, mimeType string) []Violation {
+	violations := checkExtension(filename, mimeType)
+	if v := checkDangerousType(filename, mimeType); v != nil {
+		violations = append(violations, *v)
+	}
+	return violations
+}
+
+// checkDangerousType makes sure a file is not of a dangerous type,
+// according to its content or its extension
+func checkDangerousType(filename, mimeType string) *Violation {
+	dangerousType := canonicalMimeType(mimeType)
+	if !IsDangerousType(dangerousType) {
+		dangerousType = MimeForExtension(fileExtension(filename))
+	}
+	if !IsDangerousType(dangerousType) {
+		return nil
+	}
+	return &Violation{
+		Code:    ViolationDangerousType,
+		Message: fmt.Sprintf("files of type %q are potentially dangerous", dangerousType),
+	}
+}
+
+// checkExtension makes sure the extension of a file matches its mimetype,
+// and that the file doesn't have a double extension
+func checkExtension(filename, mimeType string) []Violation {
+	violations := []Violation{}
+
+	detectedType := canonicalMimeType(mimeType)
+	expectedType := MimeForExtension(fileExtension(filename))
+	if expectedType == "" || !isCompatibleType(detectedType, expectedType) {
+		violations = append(violations, Violation{
+			Code:    ViolationExtensionMismatch,
+			Message: fmt.Sprintf("the extension of %q doesn't match its content (%s)", filename, detectedType),
+		})
+	}
+
+	if hasDoubleExtension(filename) {
+		violations = append(violations, Violation{
+			Code:    ViolationDoubleExtension,
+			Message: fmt.Sprintf("%q has more than one extension", filename),
+		})
+	}
+	return violations
+}
+
+// baseName returns the name of a file without its directory and without
+// the trailing dots and spaces (which are ignored by Windows)
+func baseName(filename string) string {
+	if i := strings.LastIndexAny(filename, `/\`); i >= 0 {
+		filename = filename[i+1:]
+	}
+	return strings.TrimRight(filename, ". ")
+}
+
+// fileExtension returns the extension of a file, in lower case.
+// Hidden files with no extension (.bashrc) have no extension
+func fileExtension(filename string) string {
+	name := baseName(filename)
+	i := strings.LastIndexByte(name, '.')
+	if i <= 0 {
+		return ""
+	}
+	return strings.ToLower(name[i:])
+}
+
+// hasDoubleExtension checks if a file has 2 known extensions that are not
+// expected to be used together (invoice.pdf.exe)
+func hasDoubleExtension(filename string) bool {
+	name := baseName(filename)
+	ext := fileExtension(name)
+	if ext == "" {
+		return false
+	}
+	prevExt := fileExtension(name[:len(name)-len(ext)])
+	if prevExt == "" || compoundExtensions[prevExt+ext] {
+		return false
+	}
+	return MimeForExtension(prevExt) != ""
+}
@@ -0,0 +1,96 @@
+package filetype_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Nivl/go-types/filetype"
+	gomock "github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckName(t *testing.T) {
+	png := readFixture(t, "black_pixel.png")
+	html := []byte("<!DOCTYPE html><html><body>hello</body></html>")
+
+	testCases := []struct {
+		description string
+		filename    string
+		content     []byte
+		expected    []filetype.ViolationCode
+	}{
+		{"valid png", "pixel.png", png, nil},
+		{"upper case extension", "PIXEL.PNG", png, nil},
+		{"with a directory", `C:\Users\me\pixel.png`, png, nil},
+		{"dots in the name", "pixel.2019.01.png", png, nil},
+		{"pdf", "pixel.pdf", readFixture(t, "black_pixel.pdf"), nil},
+		{"text file", "license.txt", readFixture(t, "LICENSE"), nil},
+		{"json file", "data.json", []byte(`{"a": 1}`), nil},
+		{"compound extension", "archive.tar.gz", []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00"), nil},
+		{"wrong extension", "pixel.jpg", png, []filetype.ViolationCode{filetype.ViolationExtensionMismatch}},
+		{"unknown extension", "pixel.nope", png, []filetype.ViolationCode{filetype.ViolationExtensionMismatch}},
+		{"no extension", "pixel", png, []filetype.ViolationCode{filetype.ViolationExtensionMismatch}},
+		{
+			"html renamed as png",
+			"pixel.png", html,
+			[]filetype.ViolationCode{filetype.ViolationExtensionMismatch, filetype.ViolationDangerousType},
+		},
+		{"html", "page.html", html, []filetype.ViolationCode{filetype.ViolationDangerousType}},
+		{"script", "run.sh", []byte("#!/bin/sh\necho hello\n"), []filetype.ViolationCode{filetype.ViolationDangerousType}},
+		{
+			"double extension",
+			"invoice.pdf.exe", readFixture(t, "black_pixel.pdf"),
+			[]filetype.ViolationCode{filetype.ViolationExtensionMismatch, filetype.ViolationDoubleExtension, filetype.ViolationDangerousType},
+		},
+		{
+			"double extension with a trailing dot",
+			"pixel.jpg.png.", png,
+			[]filetype.ViolationCode{filetype.ViolationDoubleExtension},
+		},
+		{
+			"executable",
+			"setup.png", peFile(),
+			[]filetype.ViolationCode{filetype.ViolationExtensionMismatch, filetype.ViolationDangerousType},
+		},
+		{"empty file", "empty.txt", []byte{}, []filetype.ViolationCode{filetype.ViolationExtensionMismatch}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			violations, err := filetype.CheckName(tc.filename, bytes.NewReader(tc.content))
+			require.NoError(t, err, "CheckName() should have succeed")
+
+			codes := make([]filetype.ViolationCode, 0, len(violations))
+			for _, v := range violations {
+				assert.NotEmpty(t, v.Message, "violations should have a message")
+				codes = append(codes, v.Code)
+			}
+			assert.ElementsMatch(t, tc.expected, codes, "invalid violations")
+		})
+	}
+}
+
+func TestCheckNameReadFail(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	reader := NewMockReadSeeker(mockCtrl)
+	reader.EXPECT().Seek(int64(0), gomock.Any()).Return(int64(0), assert.AnError)
+
+	violations, err := filetype.CheckName("pixel.png", reader)
+	require.Error(t, err, "CheckName() should have failed")
+	assert.Nil(t, violations, "CheckName() should not have returned violations")
+}
+
+// peFile returns the header of a Windows executable
+func peFile() []byte {
+	data := make([]byte, 0x100)
+	copy(data, "MZ")
+	data[0x3c] = 0x80
+	copy(data[0x80:], "PE\x00\x00")
+	return data
+}
@@ -0,0 +1,24 @@
+package filetype
+
+import (
+	"bytes"
+	"io"
+)
+
+// Peek returns the mimetype of the data contained in a reader, as well as a
+// new reader that will return the exact same data as the original reader
+// would have.
+// This is useful for streams that cannot be seeked (http request body,
+// multipart parts, etc.), as the returned reader can be used in place of the
+// original one once the type has been validated.
+// An error is returned if the reader is empty.
+func Peek(r io.Reader) (mimeType string, replay io.Reader, err error) {
+	buff, err := readHeader(r)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// we put back the bytes we consumed in front of the original reader
+	replay = io.MultiReader(bytes.NewReader(buff), r)
+	return detectContentType(buff), replay, nil
+}
@@ -0,0 +1,69 @@
+package filetype_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"path"
+	"testing"
+	"testing/iotest"
+
+	"github.com/Nivl/go-types/filetype"
+	gomock "github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeek(t *testing.T) {
+	testCases := []struct {
+		description string
+		filename    string
+		expected    string
+	}{
+		{"png", "black_pixel.png", "image/png"},
+		{"jpg", "black_pixel.jpg", "image/jpeg"},
+		{"gif", "black_pixel.gif", "image/gif"},
+		{"pdf", "black_pixel.pdf", "application/pdf"},
+		{"text file with no ext", "LICENSE", "text/plain; charset=utf-8"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			content, err := ioutil.ReadFile(path.Join("fixtures", tc.filename))
+			require.NoError(t, err, "ReadFile() should have succeed")
+
+			// OneByteReader makes sure we don't rely on a single Read()
+			mime, r, err := filetype.Peek(iotest.OneByteReader(bytes.NewReader(content)))
+			require.NoError(t, err, "Peek() should have succeed")
+			assert.Equal(t, tc.expected, mime, "invalid mimetype")
+
+			replayed, err := ioutil.ReadAll(r)
+			require.NoError(t, err, "ReadAll() should have succeed")
+			assert.Equal(t, content, replayed, "the returned reader should contain the whole file")
+		})
+	}
+}
+
+func TestPeekEmptyReader(t *testing.T) {
+	mime, r, err := filetype.Peek(bytes.NewReader([]byte{}))
+	require.Error(t, err, "Peek() should have failed")
+	assert.Equal(t, filetype.ErrMsgEmptyFile, err.Error(), "invalid error")
+	assert.Empty(t, mime, "Peek() should have not returned a mime")
+	assert.Nil(t, r, "Peek() should have not returned a reader")
+}
+
+func TestPeekReaderFail(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	reader := NewMockReadSeeker(mockCtrl)
+	reader.EXPECT().Read(gomock.Any()).Return(0, errors.New("read failed"))
+
+	mime, r, err := filetype.Peek(reader)
+	assert.Error(t, err, "Peek() should have failed")
+	assert.Empty(t, mime, "Peek() should have not returned a mime")
+	assert.Nil(t, r, "Peek() should have not returned a reader")
+}
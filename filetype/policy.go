@@ -0,0 +1,241 @@
+package filetype
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Nivl/go-types/octets"
+)
+
+// ErrMsgInvalidPolicy represents the error message returned when a policy
+// is not valid
+var ErrMsgInvalidPolicy = "invalid policy"
+
+// ViolationCode represents the reason why a file doesn't respect a policy
+type ViolationCode string
+
+// List of all the violations a file can have
+const (
+	// ViolationTypeNotAllowed means the type of the file is not allowed
+	ViolationTypeNotAllowed ViolationCode = "type_not_allowed"
+
+	// ViolationExtensionMismatch means the extension of the file doesn't
+	// match its content
+	ViolationExtensionMismatch ViolationCode = "extension_mismatch"
+
+	// ViolationDoubleExtension means the name of the file contains more
+	// than one extension (invoice.pdf.exe)
+	ViolationDoubleExtension ViolationCode = "double_extension"
+
+	// ViolationDangerousType means the file can be executed, or rendered
+	// with scripts, by the OS or a browser
+	ViolationDangerousType ViolationCode = "dangerous_type"
+
+	// ViolationFileTooSmall means the file is smaller than the minimum size
+	ViolationFileTooSmall ViolationCode = "file_too_small"
+
+	// ViolationFileTooLarge means the file is bigger than the maximum size
+	ViolationFileTooLarge ViolationCode = "file_too_large"
+
+	// ViolationImageTooLarge means the image exceeds the dimension limits
+	ViolationImageTooLarge ViolationCode = "image_too_large"
+
+	// ViolationInvalidImage means the image is not valid
+	ViolationInvalidImage ViolationCode = "invalid_image"
+)
+
+// Violation represents a rule of a policy that a file doesn't respect
+type Violation struct {
+	// Code identifies the violated rule
+	Code ViolationCode `json:"code"`
+
+	// Message is a human readable description of the violation
+	Message string `json:"message"`
+}
+
+// Error implements the error interface
+// https://golang.org/pkg/builtin/#error
+func (v Violation) Error() string {
+	return v.Message
+}
+
+// Policy contains the rules a file must respect to be accepted.
+// A zero value means the rule is not enforced.
+//
+// Example of a policy accepting PNG and JPEG images up to 5MiB and
+// 4096x4096 pixels:
+//
+//	{
+//	  "allowed_types": ["image/png", "image/jpeg"],
+//	  "check_extension": true,
+//	  "max_size": "5MiB",
+//	  "image": {"max_width": 4096, "max_height": 4096}
+//	}
+//
+// The sizes can be numbers of bytes, or strings using the units of the
+// octets package ("5MiB", "1.5 GB").
+type Policy struct {
+	// AllowedTypes contains the mimetypes that are allowed.
+	// A whole category can be allowed using a wildcard ("image/*").
+	// All types are allowed if empty
+	AllowedTypes []string `json:"allowed_types,omitempty" yaml:"allowed_types,omitempty"`
+
+	// CheckExtension makes sure the extension of the filename matches the
+	// content of the file, and that the file doesn't have a double
+	// extension
+	CheckExtension bool `json:"check_extension,omitempty" yaml:"check_extension,omitempty"`
+
+	// BlockDangerousTypes rejects the files that can be executed, or
+	// rendered with scripts, by the OS or a browser, based on their content
+	// or their extension (see IsDangerousType())
+	BlockDangerousTypes bool `json:"block_dangerous_types,omitempty" yaml:"block_dangerous_types,omitempty"`
+
+	// MinSize is the minimum size of the file
+	MinSize octets.Size `json:"min_size,omitempty" yaml:"min_size,omitempty"`
+
+	// MaxSize is the maximum size of the file
+	MaxSize octets.Size `json:"max_size,omitempty" yaml:"max_size,omitempty"`
+
+	// Image contains the limits images must respect. Images are always
+	// validated, even when Image is nil
+	Image *ValidateOptions `json:"image,omitempty" yaml:"image,omitempty"`
+}
+
+// LoadPolicy parses a JSON encoded policy and makes sure it is valid.
+// Unknown fields are rejected to catch typos.
+//
+// To keep this package free of dependencies, YAML is not decoded by
+// LoadPolicy. The fields of Policy have yaml tags, and octets.Size
+// implements encoding.TextUnmarshaler, so a YAML policy can be decoded
+// using gopkg.in/yaml.v3 before calling Validate():
+//
+//	p := &filetype.Policy{}
+//	dec := yaml.NewDecoder(r)
+//	dec.KnownFields(true)
+//	if err := dec.Decode(p); err != nil {
+//		return err
+//	}
+//	if err := p.Validate(); err != nil {
+//		return err
+//	}
+func LoadPolicy(r io.Reader) (*Policy, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	p := &Policy{}
+	if err := dec.Decode(p); err != nil {
+		return nil, err
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Validate makes sure the rules of a policy are consistent
+func (p *Policy) Validate() error {
+	if p.MinSize < 0 || p.MaxSize < 0 || (p.MaxSize > 0 && p.MinSize > p.MaxSize) {
+		return fmt.Errorf("%s: invalid size range", ErrMsgInvalidPolicy)
+	}
+	for _, t := range p.AllowedTypes {
+		if !strings.Contains(t, "/") {
+			return fmt.Errorf("%s: invalid type %q", ErrMsgInvalidPolicy, t)
+		}
+	}
+	return nil
+}
+
+// Evaluate reads a file and returns all the rules of the policy it
+// doesn't respect. filename is the name of the file provided by the user,
+// and is only used to check the extension. The reader doesn't need to
+// be seekable.
+// An error is only returned if the file could not be read.
+func (p *Policy) Evaluate(r io.Reader, filename string) ([]Violation, error) {
+	report, err := Inspect(r, &InspectOptions{Validate: p.Image})
+	if err != nil {
+		return nil, err
+	}
+	return p.EvaluateReport(report, filename), nil
+}
+
+// EvaluateReport returns all the rules of the policy that a file
+// inspected by Inspect() doesn't respect. filename is the name of the
+// file provided by the user, and is only used to check the extension.
+// The image limits of the policy are not checked if they were not provided
+// to Inspect().
+func (p *Policy) EvaluateReport(report *FileReport, filename string) []Violation {
+	violations := []Violation{}
+
+	if p.MinSize > 0 && report.Size < p.MinSize.Bytes() {
+		violations = append(violations, Violation{
+			Code:    ViolationFileTooSmall,
+			Message: fmt.Sprintf("the file must be at least %s", p.MinSize),
+		})
+	}
+	if p.MaxSize > 0 && report.Size > p.MaxSize.Bytes() {
+		violations = append(violations, Violation{
+			Code:    ViolationFileTooLarge,
+			Message: fmt.Sprintf("the file must be at most %s", p.MaxSize),
+		})
+	}
+
+	mediaType := baseMediaType(report.MimeType)
+	if !p.isTypeAllowed(mediaType) {
+		violations = append(violations, Violation{
+			Code:    ViolationTypeNotAllowed,
+			Message: fmt.Sprintf("files of type %q are not allowed", mediaType),
+		})
+	}
+	if p.CheckExtension {
+		violations = append(violations, checkExtension(filename, mediaType)...)
+	}
+	if p.BlockDangerousTypes {
+		if v := checkDangerousType(filename, mediaType); v != nil {
+			violations = append(violations, *v)
+		}
+	}
+
+	if res := report.Validation; res != nil && !res.Valid {
+		switch res.Reason {
+		case ReasonTooLarge:
+			violations = append(violations, Violation{
+				Code:    ViolationImageTooLarge,
+				Message: ErrMsgImageTooLarge,
+			})
+		case ReasonUnsupportedType:
+			// the type is handled by AllowedTypes
+		default:
+			violations = append(violations, Violation{
+				Code:    ViolationInvalidImage,
+				Message: fmt.Sprintf("%s: %s", ErrMsgInvalidImage, res.Reason),
+			})
+		}
+	}
+	return violations
+}
+
+// isTypeAllowed checks if a media type is in the list of allowed types
+func (p *Policy) isTypeAllowed(mediaType string) bool {
+	if len(p.AllowedTypes) == 0 {
+		return true
+	}
+	for _, allowed := range p.AllowedTypes {
+		allowed = strings.ToLower(allowed)
+		isWildcard := strings.HasSuffix(allowed, "/*")
+		if allowed == mediaType || allowed == "*/*" || (isWildcard && strings.HasPrefix(mediaType, strings.TrimSuffix(allowed, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// baseMediaType returns a mimetype without its parameters
+// ("text/plain; charset=utf-8" becomes "text/plain")
+func baseMediaType(mimeType string) string {
+	if i := strings.IndexByte(mimeType, ';'); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(mimeType))
+}
@@ -0,0 +1,193 @@
+package filetype_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Nivl/go-types/filetype"
+	"github.com/Nivl/go-types/octets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadPolicy(t *testing.T) {
+	testCases := []struct {
+		description string
+		policy      string
+		expected    *filetype.Policy
+		expectedErr bool
+	}{
+		{
+			"valid policy",
+			`{"allowed_types": ["image/png", "image/jpeg"], "check_extension": true, "max_size": 5242880, "image": {"max_width": 4096, "max_height": 4096}}`,
+			&filetype.Policy{
+				AllowedTypes:   []string{"image/png", "image/jpeg"},
+				CheckExtension: true,
+				MaxSize:        octets.Size(5 * octets.MiB),
+				Image:          &filetype.ValidateOptions{MaxWidth: 4096, MaxHeight: 4096},
+			},
+			false,
+		},
+		{
+			"sizes with units",
+			`{"min_size": "1KB", "max_size": "5MiB"}`,
+			&filetype.Policy{MinSize: octets.Size(octets.KB), MaxSize: octets.Size(5 * octets.MiB)},
+			false,
+		},
+		{"empty policy", `{}`, &filetype.Policy{}, false},
+		{"unknown field", `{"max_sizes": 10}`, nil, true},
+		{"invalid json", `{`, nil, true},
+		{"min bigger than max", `{"min_size": 10, "max_size": 5}`, nil, true},
+		{"negative size", `{"min_size": -1}`, nil, true},
+		{"invalid size", `{"max_size": "5 potatoes"}`, nil, true},
+		{"invalid type", `{"allowed_types": ["png"]}`, nil, true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			p, err := filetype.LoadPolicy(strings.NewReader(tc.policy))
+			if tc.expectedErr {
+				require.Error(t, err, "LoadPolicy() should have failed")
+				assert.Nil(t, p, "LoadPolicy() should not have returned a policy")
+				return
+			}
+			require.NoError(t, err, "LoadPolicy() should have succeed")
+			assert.Equal(t, tc.expected, p, "invalid policy")
+		})
+	}
+}
+
+func TestPolicyEvaluate(t *testing.T) {
+	png := readFixture(t, "black_pixel.png")
+
+	testCases := []struct {
+		description string
+		policy      *filetype.Policy
+		content     []byte
+		filename    string
+		expected    []filetype.ViolationCode
+	}{
+		{"empty policy", &filetype.Policy{}, png, "pixel.png", nil},
+		{"allowed type", &filetype.Policy{AllowedTypes: []string{"image/jpeg", "image/png"}}, png, "pixel.png", nil},
+		{"allowed category", &filetype.Policy{AllowedTypes: []string{"image/*"}}, png, "pixel.png", nil},
+		{"everything allowed", &filetype.Policy{AllowedTypes: []string{"*/*"}}, png, "pixel.png", nil},
+		{
+			"type not allowed",
+			&filetype.Policy{AllowedTypes: []string{"image/jpeg"}},
+			png, "pixel.png",
+			[]filetype.ViolationCode{filetype.ViolationTypeNotAllowed},
+		},
+		{
+			"category not allowed",
+			&filetype.Policy{AllowedTypes: []string{"image/*"}},
+			readFixture(t, "black_pixel.pdf"), "pixel.pdf",
+			[]filetype.ViolationCode{filetype.ViolationTypeNotAllowed},
+		},
+		{"matching extension", &filetype.Policy{CheckExtension: true}, png, "pixel.PNG", nil},
+		{"text file", &filetype.Policy{CheckExtension: true}, readFixture(t, "LICENSE"), "LICENSE.txt", nil},
+		{
+			"extension mismatch",
+			&filetype.Policy{CheckExtension: true},
+			png, "pixel.jpg",
+			[]filetype.ViolationCode{filetype.ViolationExtensionMismatch},
+		},
+		{
+			"no extension",
+			&filetype.Policy{CheckExtension: true},
+			png, "pixel",
+			[]filetype.ViolationCode{filetype.ViolationExtensionMismatch},
+		},
+		{
+			"double extension",
+			&filetype.Policy{CheckExtension: true},
+			png, "pixel.jpg.png",
+			[]filetype.ViolationCode{filetype.ViolationDoubleExtension},
+		},
+		{
+			"dangerous type",
+			&filetype.Policy{BlockDangerousTypes: true},
+			[]byte("<html><body>hello</body></html>"), "page.txt",
+			[]filetype.ViolationCode{filetype.ViolationDangerousType},
+		},
+		{"dangerous types allowed", &filetype.Policy{}, []byte("<html><body>hello</body></html>"), "page.html", nil},
+		{"size in range", &filetype.Policy{MinSize: 10, MaxSize: octets.Size(octets.KB)}, png, "pixel.png", nil},
+		{
+			"file too small",
+			&filetype.Policy{MinSize: octets.Size(octets.KB)},
+			png, "pixel.png",
+			[]filetype.ViolationCode{filetype.ViolationFileTooSmall},
+		},
+		{
+			"file too large",
+			&filetype.Policy{MaxSize: 10},
+			png, "pixel.png",
+			[]filetype.ViolationCode{filetype.ViolationFileTooLarge},
+		},
+		{
+			"image too large",
+			&filetype.Policy{Image: &filetype.ValidateOptions{MaxBytes: 10}},
+			png, "pixel.png",
+			[]filetype.ViolationCode{filetype.ViolationImageTooLarge},
+		},
+		{
+			"invalid image",
+			&filetype.Policy{},
+			png[:len(png)-20], "pixel.png",
+			[]filetype.ViolationCode{filetype.ViolationInvalidImage},
+		},
+		{
+			"multiple violations",
+			&filetype.Policy{AllowedTypes: []string{"image/jpeg"}, CheckExtension: true, MaxSize: 10},
+			png, "pixel.jpg",
+			[]filetype.ViolationCode{filetype.ViolationFileTooLarge, filetype.ViolationTypeNotAllowed, filetype.ViolationExtensionMismatch},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			violations, err := tc.policy.Evaluate(bytes.NewReader(tc.content), tc.filename)
+			require.NoError(t, err, "Evaluate() should have succeed")
+
+			codes := make([]filetype.ViolationCode, 0, len(violations))
+			for _, v := range violations {
+				assert.NotEmpty(t, v.Error(), "violations should have a message")
+				codes = append(codes, v.Code)
+			}
+			assert.ElementsMatch(t, tc.expected, codes, "invalid violations")
+		})
+	}
+}
+
+func TestPolicyEvaluateSizeMessages(t *testing.T) {
+	t.Parallel()
+
+	png := readFixture(t, "black_pixel.png")
+
+	p := &filetype.Policy{MinSize: octets.Size(octets.KiB)}
+	violations, err := p.Evaluate(bytes.NewReader(png), "pixel.png")
+	require.NoError(t, err, "Evaluate() should have succeed")
+	require.Len(t, violations, 1, "the file should be too small")
+	assert.Equal(t, "the file must be at least 1KiB", violations[0].Message)
+
+	p = &filetype.Policy{MaxSize: 10}
+	violations, err = p.Evaluate(bytes.NewReader(png), "pixel.png")
+	require.NoError(t, err, "Evaluate() should have succeed")
+	require.Len(t, violations, 1, "the file should be too large")
+	assert.Equal(t, "the file must be at most 10B", violations[0].Message)
+}
+
+func TestPolicyEvaluateReadFail(t *testing.T) {
+	t.Parallel()
+
+	p := &filetype.Policy{}
+	violations, err := p.Evaluate(&failingReader{err: assert.AnError}, "file.png")
+	require.Error(t, err, "Evaluate() should have failed")
+	assert.Nil(t, violations, "Evaluate() should not have returned violations")
+}
@@ -0,0 +1,114 @@
+package filetype
+
+import (
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"sort"
+	"sync"
+)
+
+// ImageFormat represents an image type that can be validated by IsImage()
+type ImageFormat struct {
+	// MimeType is the mimetype of the format, as returned by MimeType()
+	MimeType string
+
+	// Decode decodes an image of this format. Can be nil if the format
+	// cannot be decoded
+	Decode ImageDecoder
+
+	// Validate checks that a file is a valid image of this format
+	Validate FileValidator
+
+	// Check checks that a file is a valid image of this format, and
+	// returns the reason why it's not
+	Check Checker
+}
+
+// imageFormats contains all the image formats supported by IsImage(),
+// indexed by mimetype
+var imageFormats = struct {
+	sync.RWMutex
+	formats map[string]ImageFormat
+}{
+	formats: map[string]ImageFormat{
+		"image/jpeg":    {MimeType: "image/jpeg", Decode: jpeg.Decode, Validate: IsJPG, Check: CheckJPG},
+		"image/png":     {MimeType: "image/png", Decode: png.Decode, Validate: IsPNG, Check: CheckPNG},
+		"image/gif":     {MimeType: "image/gif", Decode: gif.Decode, Validate: IsGIF, Check: CheckGIF},
+		"image/webp":    {MimeType: "image/webp", Validate: IsWebP, Check: headerChecker(parseWebP)},
+		"image/bmp":     {MimeType: "image/bmp", Validate: IsBMP, Check: headerChecker(parseBMP)},
+		"image/tiff":    {MimeType: "image/tiff", Validate: IsTIFF, Check: headerChecker(parseTIFF)},
+		"image/x-icon":  {MimeType: "image/x-icon", Validate: IsICO, Check: headerChecker(parseICO)},
+		"image/heic":    {MimeType: "image/heic", Validate: IsHEIF, Check: headerChecker(parseHEIF)},
+		"image/heif":    {MimeType: "image/heif", Validate: IsHEIF, Check: headerChecker(parseHEIF)},
+		"image/avif":    {MimeType: "image/avif", Validate: IsHEIF, Check: headerChecker(parseHEIF)},
+		"image/svg+xml": {MimeType: "image/svg+xml", Validate: IsSVG, Check: headerChecker(parseSVG)},
+	},
+}
+
+// RegisterImageFormat registers an image format that will be used by
+// IsImage() to validate the files of the given mimetype.
+// If a format already exists for this mimetype, it will be replaced.
+// If validate is nil, the file will be validated by being fully decoded
+// using decode. RegisterImageFormat panics if both decode and validate
+// are nil, since the files could not be validated.
+//
+// Example to use the WebP decoder of golang.org/x/image instead of the
+// default validator:
+//
+//	filetype.RegisterImageFormat("image/webp", webp.Decode, nil)
+func RegisterImageFormat(mimeType string, decode ImageDecoder, validate FileValidator) {
+	if decode == nil && validate == nil {
+		panic("filetype: RegisterImageFormat() needs a decoder or a validator for " + mimeType)
+	}
+
+	check := validatorChecker(validate)
+	if validate == nil {
+		check = func(r io.ReadSeeker) (*ValidationResult, error) {
+			return CheckDecode(r, decode)
+		}
+		validate = func(r io.ReadSeeker) (bool, error) {
+			return isValid(check(r))
+		}
+	}
+
+	imageFormats.Lock()
+	defer imageFormats.Unlock()
+	imageFormats.formats[mimeType] = ImageFormat{
+		MimeType: mimeType,
+		Decode:   decode,
+		Validate: validate,
+		Check:    check,
+	}
+}
+
+// UnregisterImageFormat removes the format of the given mimetype from
+// the list of formats supported by IsImage()
+func UnregisterImageFormat(mimeType string) {
+	imageFormats.Lock()
+	defer imageFormats.Unlock()
+	delete(imageFormats.formats, mimeType)
+}
+
+// LookupImageFormat returns the image format registered for the given
+// mimetype
+func LookupImageFormat(mimeType string) (format ImageFormat, found bool) {
+	imageFormats.RLock()
+	defer imageFormats.RUnlock()
+	format, found = imageFormats.formats[mimeType]
+	return format, found
+}
+
+// ImageFormats returns the sorted list of mimetypes supported by IsImage()
+func ImageFormats() []string {
+	imageFormats.RLock()
+	defer imageFormats.RUnlock()
+
+	mimeTypes := make([]string, 0, len(imageFormats.formats))
+	for mimeType := range imageFormats.formats {
+		mimeTypes = append(mimeTypes, mimeType)
+	}
+	sort.Strings(mimeTypes)
+	return mimeTypes
+}
@@ -0,0 +1,63 @@
+package filetype_test
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"io"
+	"testing"
+
+	"github.com/Nivl/go-types/filetype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageFormats(t *testing.T) {
+	formats := filetype.ImageFormats()
+	for _, mimeType := range []string{"image/jpeg", "image/png", "image/gif", "image/webp", "image/bmp", "image/tiff", "image/x-icon", "image/svg+xml", "image/heic", "image/avif"} {
+		assert.Contains(t, formats, mimeType, "the format should be supported by default")
+
+		format, found := filetype.LookupImageFormat(mimeType)
+		assert.True(t, found, "LookupImageFormat() should have found the format")
+		assert.Equal(t, mimeType, format.MimeType, "invalid mimetype")
+		assert.NotNil(t, format.Validate, "the format should have a validator")
+	}
+
+	_, found := filetype.LookupImageFormat("application/pdf")
+	assert.False(t, found, "pdf should not be a supported image format")
+}
+
+func TestRegisterImageFormat(t *testing.T) {
+	// This test updates the list of the supported formats and therefore
+	// cannot be run in parallel
+	pdf := readFixture(t, "black_pixel.pdf")
+
+	filetype.RegisterImageFormat("application/pdf", nil, func(r io.ReadSeeker) (bool, error) {
+		return true, nil
+	})
+	isValid, mimeType, err := filetype.IsImage(bytes.NewReader(pdf))
+	require.NoError(t, err, "IsImage() should have succeed")
+	assert.True(t, isValid, "the custom validator should have been used")
+	assert.Equal(t, "application/pdf", mimeType, "invalid mimetype")
+
+	// Without validator, the decoder should be used
+	filetype.RegisterImageFormat("application/pdf", func(r io.Reader) (image.Image, error) {
+		return nil, errors.New("cannot decode")
+	}, nil)
+	isValid, _, err = filetype.IsImage(bytes.NewReader(pdf))
+	require.NoError(t, err, "IsImage() should have succeed")
+	assert.False(t, isValid, "the decoder should have been used")
+
+	filetype.UnregisterImageFormat("application/pdf")
+	_, _, err = filetype.IsImage(bytes.NewReader(pdf))
+	require.Error(t, err, "IsImage() should have failed")
+	assert.Equal(t, filetype.ErrMsgUnsupportedImageFormat, err.Error(), "invalid error")
+}
+
+func TestRegisterImageFormatWithoutDecoderNorValidator(t *testing.T) {
+	assert.PanicsWithValue(t, "filetype: RegisterImageFormat() needs a decoder or a validator for image/x-test", func() {
+		filetype.RegisterImageFormat("image/x-test", nil, nil)
+	})
+	_, found := filetype.LookupImageFormat("image/x-test")
+	assert.False(t, found, "the format should not have been registered")
+}
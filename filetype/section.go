@@ -0,0 +1,150 @@
+package filetype
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// errTruncated is returned by section when trying to access data that
+// are outside of the file
+var errTruncated = errors.New("unexpected end of file")
+
+// errMalformed is returned by the structure checks when a file doesn't
+// have the expected structure
+var errMalformed = errors.New("malformed file")
+
+// errUnsupportedFormat is returned when trying to parse a file of an
+// unsupported type
+var errUnsupportedFormat = errors.New("unsupported format")
+
+// imageHeader contains the data extracted from the header of an image
+type imageHeader struct {
+	width       int
+	height      int
+	colorModel  string
+	bitDepth    int
+	frames      int
+	orientation int
+}
+
+// headerParser represents a function that parses the header of an image
+type headerParser func(s *section) (*imageHeader, error)
+
+// section gives random access to the content of a file, starting at the
+// position the reader was at when the section got created.
+// It is used by the validators that only need to check the structure
+// of a file without decoding it
+type section struct {
+	r     io.ReadSeeker
+	start int64
+	size  int64
+	// lastOffset is the offset of the last data read
+	lastOffset int64
+}
+
+// newSection creates a new section starting at the current position of the
+// provided reader.
+// The reader is not put back to its original position
+func newSection(r io.ReadSeeker) (*section, error) {
+	start, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	return &section{r: r, start: start, size: end - start}, nil
+}
+
+// read returns n bytes located at the provided offset.
+// errTruncated is returned if the file is too small
+func (s *section) read(off, n int64) ([]byte, error) {
+	s.lastOffset = off
+	if off < 0 || n < 0 || off+n > s.size {
+		return nil, errTruncated
+	}
+	if _, err := s.r.Seek(s.start+off, io.SeekStart); err != nil {
+		return nil, err
+	}
+	buff := make([]byte, n)
+	if _, err := io.ReadFull(s.r, buff); err != nil {
+		if err == io.ErrUnexpectedEOF || err == io.EOF {
+			return nil, errTruncated
+		}
+		return nil, err
+	}
+	return buff, nil
+}
+
+// ReadAt implements the io.ReaderAt interface
+// https://golang.org/pkg/io/#ReaderAt
+func (s *section) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 || off >= s.size {
+		return 0, io.EOF
+	}
+	n := int(minInt64(int64(len(p)), s.size-off))
+	if _, err := s.r.Seek(s.start+off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n, err := io.ReadFull(s.r, p[:n])
+	if err == nil && n < len(p) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// reader returns a reader that contains the whole section
+func (s *section) reader() (io.Reader, error) {
+	if _, err := s.r.Seek(s.start, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return io.LimitReader(s.r, s.size), nil
+}
+
+// readUint16 returns the uint16 located at the provided offset
+func (s *section) readUint16(off int64, order binary.ByteOrder) (uint16, error) {
+	b, err := s.read(off, 2)
+	if err != nil {
+		return 0, err
+	}
+	return order.Uint16(b), nil
+}
+
+// readUint32 returns the uint32 located at the provided offset
+func (s *section) readUint32(off int64, order binary.ByteOrder) (uint32, error) {
+	b, err := s.read(off, 4)
+	if err != nil {
+		return 0, err
+	}
+	return order.Uint32(b), nil
+}
+
+// validateStructure runs a function that checks the structure of a file,
+// and puts the reader back to its original position.
+// The file is considered invalid if check returns errTruncated or
+// errMalformed. Any other error is returned as is.
+func validateStructure(r io.ReadSeeker, check func(s *section) error) (bool, error) {
+	res, err := checkStructure(r, check)
+	if err != nil {
+		return false, err
+	}
+	return res.Valid, nil
+}
+
+// validateHeader checks that the header of an image can be parsed
+func validateHeader(r io.ReadSeeker, parse headerParser) (bool, error) {
+	return validateStructure(r, func(s *section) error {
+		_, err := parse(s)
+		return err
+	})
+}
+
+// minInt64 returns the smallest of the two provided values
+func minInt64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,300 @@
+package filetype
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/http"
+	"strings"
+)
+
+// sniffer represents a function that returns the mimetype of a file using
+// its header, or an empty string if the type is unknown
+type sniffer func(header []byte) string
+
+// sniffers contains all the types we know how to detect that are not
+// supported by http.DetectContentType().
+// They are checked in order, before falling back to http.DetectContentType()
+var sniffers = []sniffer{
+	sniffTIFF,
+	sniffWebP,
+	sniffFtyp,
+	sniffSVG,
+	sniffExecutable,
+	sniffTar,
+	sniffODF,
+	sniffCFB,
+	sniffMatroska,
+	sniffOgg,
+	sniffFLAC,
+	sniffMP3,
+}
+
+// ftypBrands contains the mimetypes of the ISO-BMFF brands we support.
+// The mimetypes of the major brand is used if it exists, otherwise we
+// use the first compatible brand that matches
+var ftypBrands = map[string]string{
+	"avif": "image/avif",
+	"avis": "image/avif",
+	"heic": "image/heic",
+	"heix": "image/heic",
+	"heim": "image/heic",
+	"heis": "image/heic",
+	"hevc": "image/heic-sequence",
+	"hevx": "image/heic-sequence",
+	"mif1": "image/heif",
+	"msf1": "image/heif-sequence",
+	"qt  ": "video/quicktime",
+	"M4A ": "audio/mp4",
+	"M4B ": "audio/mp4",
+	"M4V ": "video/mp4",
+	"isom": "video/mp4",
+	"iso2": "video/mp4",
+	"mp41": "video/mp4",
+	"mp42": "video/mp4",
+	"avc1": "video/mp4",
+	"dash": "video/mp4",
+}
+
+// detectContentType returns the mimetype of the provided data, using at
+// most the first 512 bytes.
+// It works like http.DetectContentType() but supports more formats.
+func detectContentType(data []byte) string {
+	if len(data) > sniffLen {
+		data = data[:sniffLen]
+	}
+	for _, sniff := range sniffers {
+		if mimeType := sniff(data); mimeType != "" {
+			return mimeType
+		}
+	}
+	return http.DetectContentType(data)
+}
+
+// sniffTIFF detects little-endian and big-endian TIFF files
+func sniffTIFF(header []byte) string {
+	if bytes.HasPrefix(header, []byte("II*\x00")) || bytes.HasPrefix(header, []byte("MM\x00*")) {
+		return "image/tiff"
+	}
+	return ""
+}
+
+// sniffWebP detects WebP files. http.DetectContentType() only detects
+// some of the WebP files (those starting by a VP8 chunk)
+func sniffWebP(header []byte) string {
+	if len(header) >= 12 &&
+		bytes.Equal(header[:4], []byte("RIFF")) &&
+		bytes.Equal(header[8:12], []byte("WEBP")) {
+		return "image/webp"
+	}
+	return ""
+}
+
+// sniffFtyp detects ISO-BMFF files (HEIF, AVIF, etc.) using the
+// brands of their ftyp box
+func sniffFtyp(header []byte) string {
+	if len(header) < 16 || !bytes.Equal(header[4:8], []byte("ftyp")) {
+		return ""
+	}
+	// The size of the box is the first 4 bytes in big-endian
+	size := int(header[0])<<24 | int(header[1])<<16 | int(header[2])<<8 | int(header[3])
+	if size < 16 || size%4 != 0 {
+		return ""
+	}
+	if size > len(header) {
+		size = len(header)
+	}
+
+	// The major brand is at offset 8, followed by a 4 bytes minor version,
+	// followed by the list of compatible brands
+	if mimeType, ok := ftypBrands[string(header[8:12])]; ok {
+		return mimeType
+	}
+	for i := 16; i+4 <= size; i += 4 {
+		if mimeType, ok := ftypBrands[string(header[i:i+4])]; ok {
+			return mimeType
+		}
+	}
+	return ""
+}
+
+// sniffSVG detects SVG files by skipping the XML prolog and checking the
+// root element is <svg>
+func sniffSVG(header []byte) string {
+	data := bytes.TrimPrefix(header, []byte("\xEF\xBB\xBF"))
+	for {
+		data = bytes.TrimLeft(data, "\t\n\r ")
+		switch {
+		case bytes.HasPrefix(data, []byte("<?")):
+			data = skipPast(data, "?>")
+		case bytes.HasPrefix(data, []byte("<!--")):
+			data = skipPast(data, "-->")
+		case bytes.HasPrefix(data, []byte("<!")):
+			// DOCTYPE. We don't try to deal with internal subsets since
+			// they are not expected in SVG files
+			data = skipPast(data, ">")
+		case bytes.HasPrefix(data, []byte("<svg")):
+			if len(data) > 4 && bytes.IndexByte([]byte("\t\n\r />"), data[4]) != -1 {
+				return "image/svg+xml"
+			}
+			return ""
+		default:
+			return ""
+		}
+	}
+}
+
+// sniffExecutable detects native executables (Windows PE, ELF and
+// Mach-O) and scripts starting with a shebang
+func sniffExecutable(header []byte) string {
+	switch {
+	case isPE(header):
+		return "application/vnd.microsoft.portable-executable"
+	case bytes.HasPrefix(header, []byte("\x7fELF")):
+		return "application/x-executable"
+	case bytes.HasPrefix(header, []byte("\xfe\xed\xfa\xce")),
+		bytes.HasPrefix(header, []byte("\xfe\xed\xfa\xcf")),
+		bytes.HasPrefix(header, []byte("\xce\xfa\xed\xfe")),
+		bytes.HasPrefix(header, []byte("\xcf\xfa\xed\xfe")):
+		return "application/x-mach-binary"
+	case bytes.HasPrefix(header, []byte("#!")):
+		return "text/x-shellscript"
+	}
+	return ""
+}
+
+// sniffTar detects POSIX and GNU tar files using the magic of their
+// first header. Old v7 tar files have no magic and are not detected
+func sniffTar(header []byte) string {
+	if len(header) >= 262 && bytes.Equal(header[257:262], []byte("ustar")) {
+		return "application/x-tar"
+	}
+	return ""
+}
+
+// sniffODF detects OpenDocument files, which are zip files starting by
+// an uncompressed file named "mimetype" containing their type
+func sniffODF(header []byte) string {
+	const prefix = "application/vnd.oasis.opendocument."
+	if len(header) < 38 || !bytes.HasPrefix(header, []byte("PK\x03\x04")) ||
+		!bytes.Equal(header[26:38], []byte("\x08\x00\x00\x00mimetype")) {
+		return ""
+	}
+	content := header[38:]
+	if !bytes.HasPrefix(content, []byte(prefix)) {
+		return ""
+	}
+	// The size is 0 when it's written in a data descriptor after the
+	// content, in which case the content ends with the next zip signature
+	size := int(binary.LittleEndian.Uint32(header[18:]))
+	if size == 0 {
+		size = bytes.Index(content, []byte("PK"))
+	}
+	if size <= 0 || size > len(content) {
+		return ""
+	}
+	return string(content[:size])
+}
+
+// sniffCFB detects the Compound File Binary files (OLE), used by legacy
+// office documents, encrypted OOXML documents, MSI installers, etc.
+func sniffCFB(header []byte) string {
+	if bytes.HasPrefix(header, []byte("\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1")) {
+		return "application/x-ole-storage"
+	}
+	return ""
+}
+
+// sniffMatroska detects Matroska and WebM files using the DocType of
+// their EBML header. http.DetectContentType() reports all the EBML files
+// as WebM
+func sniffMatroska(header []byte) string {
+	if !bytes.HasPrefix(header, []byte("\x1A\x45\xDF\xA3")) {
+		return ""
+	}
+	i := bytes.Index(header, []byte{0x42, 0x82})
+	if i == -1 {
+		return ""
+	}
+	size, n := ebmlVint(header[i+2:], false)
+	if n == 0 || uint64(len(header)-i-2-n) < size {
+		return ""
+	}
+	docType := string(header[i+2+n : i+2+n+int(size)])
+	return matroskaDocTypes[strings.TrimRight(docType, "\x00")]
+}
+
+// sniffOgg detects the Ogg files containing audio or video using the
+// first packet of their logical streams. http.DetectContentType() reports
+// all the Ogg files as application/ogg
+func sniffOgg(header []byte) string {
+	mimeType := ""
+	for data := header; len(data) >= oggPageHeaderSize && bytes.HasPrefix(data, []byte("OggS\x00")); {
+		// Only the first pages of the streams are needed
+		if data[5]&0x02 == 0 {
+			break
+		}
+		start := oggPageHeaderSize + int(data[26])
+		if start > len(data) {
+			break
+		}
+		bodySize := 0
+		for _, size := range data[oggPageHeaderSize:start] {
+			bodySize += int(size)
+		}
+		body := data[start:minInt(start+bodySize, len(data))]
+		switch parseOggStream(body, &oggStream{}, &MediaInfo{}) {
+		case "video":
+			return "video/ogg"
+		case "audio":
+			mimeType = "audio/ogg"
+		}
+		data = data[minInt(start+bodySize, len(data)):]
+	}
+	return mimeType
+}
+
+// sniffFLAC detects FLAC files
+func sniffFLAC(header []byte) string {
+	if bytes.HasPrefix(header, []byte("fLaC")) {
+		return "audio/flac"
+	}
+	return ""
+}
+
+// sniffMP3 detects the MP3 files that don't start with an ID3 tag, using
+// the header of their first frame. http.DetectContentType() only detects
+// the MP3 files that have an ID3 tag.
+// When the header contains the beginning of the next frame, we also make
+// sure it is valid
+func sniffMP3(header []byte) string {
+	frame := parseMP3FrameHeader(header)
+	if frame == nil {
+		return ""
+	}
+	if len(header) >= frame.size+4 && parseMP3FrameHeader(header[frame.size:]) == nil {
+		return ""
+	}
+	return "audio/mpeg"
+}
+
+// isPE checks if the header is the one of a Windows PE file (.exe, .dll,
+// etc.). The "MZ" signature alone is too short to be reliable, so we also
+// look for the PE signature that is located at the offset stored at 0x3c
+func isPE(header []byte) bool {
+	if len(header) < 0x40 || !bytes.HasPrefix(header, []byte("MZ")) {
+		return false
+	}
+	offset := int64(binary.LittleEndian.Uint32(header[0x3c:]))
+	return offset >= 0x40 && offset <= int64(len(header)-4) &&
+		bytes.Equal(header[offset:offset+4], []byte("PE\x00\x00"))
+}
+
+// skipPast returns the data located after the first occurrence of sep, or
+// nil if sep cannot be found
+func skipPast(data []byte, sep string) []byte {
+	i := bytes.Index(data, []byte(sep))
+	if i == -1 {
+		return nil
+	}
+	return data[i+len(sep):]
+}
@@ -0,0 +1,62 @@
+package filetype_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Nivl/go-types/filetype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMimeTypeExtraFormats(t *testing.T) {
+	testCases := []struct {
+		description string
+		content     []byte
+		expected    string
+	}{
+		{"bmp", readFixture(t, "black_pixel.bmp"), "image/bmp"},
+		{"ico", readFixture(t, "black_pixel.ico"), "image/x-icon"},
+		{"webp", readFixture(t, "black_pixel.webp"), "image/webp"},
+		{"tiff little-endian", readFixture(t, "black_pixel.tiff"), "image/tiff"},
+		{"tiff big-endian", []byte("MM\x00*\x00\x00\x00\x08"), "image/tiff"},
+		{"heic", heifFile("heic", "pict"), "image/heic"},
+		{"avif", heifFile("avif", "pict"), "image/avif"},
+		{"svg with prolog", readFixture(t, "black_pixel.svg"), "image/svg+xml"},
+		{"svg without prolog", []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`), "image/svg+xml"},
+		{"svg with doctype", []byte(`<!DOCTYPE svg><!-- a comment --><svg>`), "image/svg+xml"},
+		{"svg-like tag", []byte(`<svgfoo/>`), "text/plain; charset=utf-8"},
+		{"xml", []byte(`<?xml version="1.0"?><root/>`), "text/xml; charset=utf-8"},
+		{"windows executable", peFile(), "application/vnd.microsoft.portable-executable"},
+		{"MZ without PE header", append([]byte("MZ"), make([]byte, 100)...), "application/octet-stream"},
+		{"elf", []byte("\x7fELF\x02\x01\x01\x00"), "application/x-executable"},
+		{"mach-o", []byte("\xcf\xfa\xed\xfe\x07\x00\x00\x01"), "application/x-mach-binary"},
+		{"tar", tarFile(t, archiveFile{"a.txt", []byte("a")}), "application/x-tar"},
+		{"odt", odfFile(t, "application/vnd.oasis.opendocument.text", ""), "application/vnd.oasis.opendocument.text"},
+		{"ods", odfFile(t, "application/vnd.oasis.opendocument.spreadsheet", ""), "application/vnd.oasis.opendocument.spreadsheet"},
+		{"ole", cfbFile(), "application/x-ole-storage"},
+		{"shell script", []byte("#!/bin/sh\necho hello\n"), "text/x-shellscript"},
+		{"mp4", mp4File("mp42", mp4VideoTrack("avc1", 10, 10)), "video/mp4"},
+		{"mov", mp4File("qt  ", mp4VideoTrack("avc1", 10, 10)), "video/quicktime"},
+		{"m4a", mp4File("M4A ", mp4AudioTrack("mp4a", 44100, 2)), "audio/mp4"},
+		{"webm", matroskaFile("webm"), "video/webm"},
+		{"mkv", matroskaFile("matroska"), "video/x-matroska"},
+		{"opus", oggPage(0x02, 0, 1, opusHead(2, 0)), "audio/ogg"},
+		{"ogg theora", oggPage(0x02, 0, 1, theoraHeader(10, 10)), "video/ogg"},
+		{"flac", flacFile(44100, 2, 0), "audio/flac"},
+		{"mp3 without ID3", mp3File(1000, nil), "audio/mpeg"},
+		{"single mp3 frame", mp3File(417, nil), "audio/mpeg"},
+		{"invalid mp3 frames", append(mp3File(417, nil), 0, 0, 0, 0), "application/octet-stream"},
+		{"utf-16 text", []byte("\xff\xfeh\x00e\x00l\x00l\x00o\x00"), "text/plain; charset=utf-16le"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			mime, err := filetype.MimeType(bytes.NewReader(tc.content))
+			assert.NoError(t, err, "MimeType() should have succeed")
+			assert.Equal(t, tc.expected, mime, "invalid mimetype")
+		})
+	}
+}
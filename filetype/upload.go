@@ -0,0 +1,297 @@
+package filetype
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"sort"
+
+	"github.com/Nivl/go-types/octets"
+)
+
+// DefaultUploadMaxMemory is the default amount of memory used to store the
+// files of a multipart request. The rest is stored on disk
+const DefaultUploadMaxMemory = octets.Size(32 * octets.MiB)
+
+// ProblemContentType is the content type of the errors returned by
+// UploadMiddleware() (RFC 7807)
+const ProblemContentType = "application/problem+json"
+
+// UploadOptions contains the options used by UploadMiddleware().
+// The sizes can be numbers of bytes, or strings using the units of the
+// octets package ("25MiB", "1.5 GB")
+type UploadOptions struct {
+	// MaxBodySize is the maximum size of the request body. Requests with
+	// a bigger body are rejected with a 413. Not enforced if 0
+	MaxBodySize octets.Size `json:"max_body_size,omitempty" yaml:"max_body_size,omitempty"`
+
+	// MaxMemory is the maximum amount of memory used to store the files.
+	// The rest is stored on disk.
+	// Defaults to DefaultUploadMaxMemory
+	MaxMemory octets.Size `json:"max_memory,omitempty" yaml:"max_memory,omitempty"`
+
+	// Policy contains the rules the files must respect. All the files are
+	// accepted if nil
+	Policy *Policy `json:"policy,omitempty" yaml:"policy,omitempty"`
+}
+
+// UploadedFile contains the information of a file that has been
+// validated by UploadMiddleware()
+type UploadedFile struct {
+	// Field is the name of the form field containing the file
+	Field string `json:"field"`
+
+	// Filename is the name of the file provided by the user
+	Filename string `json:"filename"`
+
+	// Size is the size of the file, in bytes
+	Size int64 `json:"size"`
+
+	// MimeType is the detected type of the file. Empty if the file is empty
+	MimeType string `json:"mime_type"`
+
+	// SHA256 is the SHA256 sum of the file, encoded in hexadecimal
+	SHA256 string `json:"sha256"`
+
+	// Image contains the information of the image. nil if the file is
+	// not an image
+	Image *ImageMetadata `json:"image,omitempty"`
+
+	// Header can be used to open the file
+	Header *multipart.FileHeader `json:"-"`
+}
+
+// Open opens the file for reading
+func (f *UploadedFile) Open() (multipart.File, error) {
+	return f.Header.Open()
+}
+
+// UploadProblem represents the problem+json document (RFC 7807) returned
+// by UploadMiddleware() when a request is rejected
+type UploadProblem struct {
+	// Type is a URI identifying the problem
+	Type string `json:"type"`
+
+	// Title is a short summary of the problem
+	Title string `json:"title"`
+
+	// Status is the HTTP status code of the response
+	Status int `json:"status"`
+
+	// Detail is a human readable explanation of the problem
+	Detail string `json:"detail,omitempty"`
+
+	// Files contains the files that have been rejected, if any
+	Files []RejectedFile `json:"files,omitempty"`
+}
+
+// RejectedFile contains a file rejected by UploadMiddleware(), and the
+// rules of the policy it doesn't respect
+type RejectedFile struct {
+	// Field is the name of the form field containing the file
+	Field string `json:"field"`
+
+	// Filename is the name of the file provided by the user
+	Filename string `json:"filename"`
+
+	// MimeType is the detected type of the file
+	MimeType string `json:"mime_type"`
+
+	// Violations contains the rules the file doesn't respect
+	Violations []Violation `json:"violations"`
+}
+
+// uploadedFilesKey is the key used to store the uploaded files in the
+// context of a request
+type uploadedFilesKey struct{}
+
+// UploadedFiles returns the files validated by UploadMiddleware(), sorted
+// by field name.
+// nil is returned if the request didn't go through the middleware, or
+// if it didn't contain any files
+func UploadedFiles(ctx context.Context) []*UploadedFile {
+	files, _ := ctx.Value(uploadedFilesKey{}).([]*UploadedFile)
+	return files
+}
+
+// UploadMiddleware returns an http middleware that limits the size of the
+// request body, and sniffs and validates all the files of the
+// multipart/form-data requests.
+// Requests containing a file that doesn't respect the policy are
+// rejected with a problem+json response (415 if the type of a file is
+// not allowed, 422 otherwise), and are not passed to the next handler.
+// The validated files are available to the next handler using
+// UploadedFiles(r.Context()), and the form using r.MultipartForm.
+// The requests that are not multipart are passed as is, with their body
+// limited to opts.MaxBodySize.
+// opts can be nil.
+func UploadMiddleware(opts *UploadOptions) func(http.Handler) http.Handler {
+	if opts == nil {
+		opts = &UploadOptions{}
+	}
+	maxMemory := opts.MaxMemory
+	if maxMemory <= 0 {
+		maxMemory = DefaultUploadMaxMemory
+	}
+	maxBodySize := opts.MaxBodySize
+	policy := opts.Policy
+	if policy == nil {
+		policy = &Policy{}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body *countingReader
+			if maxBodySize > 0 {
+				if r.ContentLength > maxBodySize.Bytes() {
+					writeBodyTooLarge(w, maxBodySize)
+					return
+				}
+				// the counter is below MaxBytesReader so we can know if the
+				// limit has been reached
+				body = &countingReader{r: r.Body}
+				r.Body = http.MaxBytesReader(w, struct {
+					io.Reader
+					io.Closer
+				}{body, r.Body}, maxBodySize.Bytes())
+			}
+
+			mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err != nil || mediaType != "multipart/form-data" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if err = r.ParseMultipartForm(maxMemory.Bytes()); err != nil {
+				if body != nil && body.n > maxBodySize.Bytes() {
+					writeBodyTooLarge(w, maxBodySize)
+					return
+				}
+				WriteProblem(w, &UploadProblem{
+					Status: http.StatusBadRequest,
+					Detail: fmt.Sprintf("invalid multipart body: %s", err.Error()),
+				})
+				return
+			}
+			// The files stored on disk are removed once the request is
+			// done. The http server does it too, so the error is ignored
+			defer func() { _ = r.MultipartForm.RemoveAll() }()
+
+			files, problem, err := inspectUploadedFiles(r.MultipartForm, policy)
+			if err != nil {
+				WriteProblem(w, &UploadProblem{
+					Status: http.StatusBadRequest,
+					Detail: fmt.Sprintf("could not read the files: %s", err.Error()),
+				})
+				return
+			}
+			if problem != nil {
+				WriteProblem(w, problem)
+				return
+			}
+			if len(files) > 0 {
+				r = r.WithContext(context.WithValue(r.Context(), uploadedFilesKey{}, files))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// WriteProblem writes a problem+json document to an http response.
+// The Type and Title of the problem are set if they are empty
+func WriteProblem(w http.ResponseWriter, problem *UploadProblem) {
+	if problem.Type == "" {
+		problem.Type = "about:blank"
+	}
+	if problem.Title == "" {
+		problem.Title = http.StatusText(problem.Status)
+	}
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(problem.Status)
+	// Nothing can be done if the response cannot be written
+	_ = json.NewEncoder(w).Encode(problem)
+}
+
+// writeBodyTooLarge rejects a request that has a body bigger than max
+func writeBodyTooLarge(w http.ResponseWriter, max octets.Size) {
+	WriteProblem(w, &UploadProblem{
+		Status: http.StatusRequestEntityTooLarge,
+		Detail: fmt.Sprintf("the request body must be at most %s", max),
+	})
+}
+
+// inspectUploadedFiles sniffs and validates all the files of a form.
+// A problem is returned if at least one file doesn't respect the policy
+func inspectUploadedFiles(form *multipart.Form, policy *Policy) ([]*UploadedFile, *UploadProblem, error) {
+	files := []*UploadedFile{}
+	rejected := []RejectedFile{}
+	status := http.StatusUnprocessableEntity
+	// The fields are sorted to always return the files in the same order
+	fields := make([]string, 0, len(form.File))
+	for field := range form.File {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		for _, header := range form.File[field] {
+			report, err := inspectFileHeader(header, policy)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			violations := policy.EvaluateReport(report, header.Filename)
+			if len(violations) > 0 {
+				rejected = append(rejected, RejectedFile{
+					Field:      field,
+					Filename:   header.Filename,
+					MimeType:   report.MimeType,
+					Violations: violations,
+				})
+				for _, v := range violations {
+					if v.Code == ViolationTypeNotAllowed {
+						status = http.StatusUnsupportedMediaType
+					}
+				}
+				continue
+			}
+
+			files = append(files, &UploadedFile{
+				Field:    field,
+				Filename: header.Filename,
+				Size:     report.Size,
+				MimeType: report.MimeType,
+				SHA256:   report.Digests[SHA256].Hex(),
+				Image:    report.Image,
+				Header:   header,
+			})
+		}
+	}
+
+	if len(rejected) > 0 {
+		return nil, &UploadProblem{
+			Status: status,
+			Detail: fmt.Sprintf("%d file(s) have been rejected", len(rejected)),
+			Files:  rejected,
+		}, nil
+	}
+	return files, nil, nil
+}
+
+// inspectFileHeader opens and inspects a file of a multipart form
+func inspectFileHeader(header *multipart.FileHeader, policy *Policy) (report *FileReport, err error) {
+	f, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil && closeErr != nil {
+			err = closeErr
+		}
+	}()
+	return Inspect(f, &InspectOptions{Validate: policy.Image})
+}
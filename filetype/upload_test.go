@@ -0,0 +1,251 @@
+package filetype_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Nivl/go-types/filetype"
+	"github.com/Nivl/go-types/octets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// formFile represents a file sent in a multipart form
+type formFile struct {
+	field    string
+	filename string
+	content  []byte
+}
+
+// uploadRequest returns a multipart/form-data request containing the
+// provided files
+func uploadRequest(t *testing.T, files ...formFile) *http.Request {
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	require.NoError(t, w.WriteField("title", "my files"), "WriteField() should have succeed")
+	for _, f := range files {
+		part, err := w.CreateFormFile(f.field, f.filename)
+		require.NoError(t, err, "CreateFormFile() should have succeed")
+		_, err = part.Write(f.content)
+		require.NoError(t, err, "Write() should have succeed")
+	}
+	require.NoError(t, w.Close(), "Close() should have succeed")
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+// serveUpload sends a request through the upload middleware and returns
+// the response, and the files received by the handler. The handler is
+// not called if the request is rejected
+func serveUpload(opts *filetype.UploadOptions, req *http.Request) (*httptest.ResponseRecorder, []*filetype.UploadedFile, bool) {
+	var files []*filetype.UploadedFile
+	called := false
+	handler := filetype.UploadMiddleware(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		files = filetype.UploadedFiles(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec, files, called
+}
+
+// decodeProblem decodes the problem+json document of a response
+func decodeProblem(t *testing.T, rec *httptest.ResponseRecorder) *filetype.UploadProblem {
+	assert.Equal(t, filetype.ProblemContentType, rec.Header().Get("Content-Type"))
+	problem := &filetype.UploadProblem{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(problem), "the problem should be valid json")
+	assert.Equal(t, rec.Code, problem.Status, "the status of the problem should match the response")
+	assert.Equal(t, "about:blank", problem.Type)
+	assert.Equal(t, http.StatusText(rec.Code), problem.Title)
+	return problem
+}
+
+func TestUploadMiddleware(t *testing.T) {
+	png := readFixture(t, "black_pixel.png")
+	pdf := readFixture(t, "black_pixel.pdf")
+	opts := &filetype.UploadOptions{
+		MaxBodySize: octets.Size(octets.MB),
+		Policy: &filetype.Policy{
+			AllowedTypes:   []string{"image/*", "application/pdf"},
+			CheckExtension: true,
+		},
+	}
+
+	req := uploadRequest(t,
+		formFile{"document", "black_pixel.pdf", pdf},
+		formFile{"avatar", "black_pixel.png", png},
+	)
+	rec, files, called := serveUpload(opts, req)
+	require.True(t, called, "the handler should have been called")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	require.Len(t, files, 2)
+
+	avatar := files[0]
+	assert.Equal(t, "avatar", avatar.Field)
+	assert.Equal(t, "black_pixel.png", avatar.Filename)
+	assert.Equal(t, "image/png", avatar.MimeType)
+	assert.Equal(t, int64(len(png)), avatar.Size)
+	assert.Equal(t, sha256Hex(png), avatar.SHA256)
+	require.NotNil(t, avatar.Image, "the image should have been parsed")
+	assert.Equal(t, 1, avatar.Image.Width)
+
+	document := files[1]
+	assert.Equal(t, "document", document.Field)
+	assert.Equal(t, "application/pdf", document.MimeType)
+	assert.Nil(t, document.Image)
+
+	// The handler must be able to read the files
+	f, err := document.Open()
+	require.NoError(t, err, "Open() should have succeed")
+	content, err := ioutil.ReadAll(f)
+	require.NoError(t, err, "ReadAll() should have succeed")
+	require.NoError(t, f.Close(), "Close() should have succeed")
+	assert.Equal(t, pdf, content)
+}
+
+func TestUploadMiddlewareRejections(t *testing.T) {
+	png := readFixture(t, "black_pixel.png")
+	opts := &filetype.UploadOptions{
+		MaxBodySize: octets.Size(4 * octets.KB),
+		Policy: &filetype.Policy{
+			AllowedTypes:   []string{"image/png"},
+			CheckExtension: true,
+		},
+	}
+
+	testCases := []struct {
+		description    string
+		files          []formFile
+		expectedStatus int
+		expectedCodes  []filetype.ViolationCode
+	}{
+		{
+			"type not allowed",
+			[]formFile{
+				{"avatar", "black_pixel.png", png},
+				{"notes", "notes.txt", []byte("hello world")},
+			},
+			http.StatusUnsupportedMediaType,
+			[]filetype.ViolationCode{filetype.ViolationTypeNotAllowed},
+		},
+		{
+			"disguised file",
+			[]formFile{{"avatar", "avatar.png", []byte("<html><script>alert(1)</script></html>")}},
+			http.StatusUnsupportedMediaType,
+			[]filetype.ViolationCode{filetype.ViolationTypeNotAllowed, filetype.ViolationExtensionMismatch},
+		},
+		{
+			"invalid image",
+			[]formFile{{"avatar", "avatar.png", png[:len(png)-10]}},
+			http.StatusUnprocessableEntity,
+			[]filetype.ViolationCode{filetype.ViolationInvalidImage},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			rec, _, called := serveUpload(opts, uploadRequest(t, tc.files...))
+			assert.False(t, called, "the handler should not have been called")
+			require.Equal(t, tc.expectedStatus, rec.Code)
+
+			problem := decodeProblem(t, rec)
+			require.Len(t, problem.Files, 1, "only one file should have been rejected")
+			codes := []filetype.ViolationCode{}
+			for _, v := range problem.Files[0].Violations {
+				codes = append(codes, v.Code)
+			}
+			assert.Equal(t, tc.expectedCodes, codes)
+		})
+	}
+}
+
+func TestUploadMiddlewareBodySize(t *testing.T) {
+	opts := &filetype.UploadOptions{MaxBodySize: octets.Size(octets.KiB)}
+	content := bytes.Repeat([]byte("a"), int(2*octets.KiB))
+
+	t.Run("declared content length", func(t *testing.T) {
+		t.Parallel()
+
+		rec, _, called := serveUpload(opts, uploadRequest(t, formFile{"file", "a.txt", content}))
+		assert.False(t, called, "the handler should not have been called")
+		require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+		problem := decodeProblem(t, rec)
+		assert.Equal(t, "the request body must be at most 1KiB", problem.Detail)
+	})
+
+	t.Run("unknown content length", func(t *testing.T) {
+		t.Parallel()
+
+		req := uploadRequest(t, formFile{"file", "a.txt", content})
+		req.ContentLength = -1
+		rec, _, called := serveUpload(opts, req)
+		assert.False(t, called, "the handler should not have been called")
+		require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+		decodeProblem(t, rec)
+	})
+
+	t.Run("not multipart", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodPost, "/upload", bytes.NewReader(content))
+		req.ContentLength = -1
+		var readErr error
+		handler := filetype.UploadMiddleware(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, readErr = ioutil.ReadAll(r.Body)
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		assert.Error(t, readErr, "the body should have been limited")
+	})
+}
+
+func TestUploadOptionsJSON(t *testing.T) {
+	opts := &filetype.UploadOptions{}
+	err := json.Unmarshal([]byte(`{"max_body_size": "25MiB", "max_memory": 1048576}`), opts)
+	require.NoError(t, err, "Unmarshal() should have succeed")
+	assert.Equal(t, octets.Size(25*octets.MiB), opts.MaxBodySize)
+	assert.Equal(t, octets.Size(octets.MiB), opts.MaxMemory)
+}
+
+func TestUploadMiddlewareInvalidRequests(t *testing.T) {
+	t.Run("malformed multipart body", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("not multipart"))
+		req.Header.Set("Content-Type", "multipart/form-data; boundary=xxx")
+		rec, _, called := serveUpload(nil, req)
+		assert.False(t, called, "the handler should not have been called")
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+		decodeProblem(t, rec)
+	})
+
+	t.Run("no files", func(t *testing.T) {
+		t.Parallel()
+
+		rec, files, called := serveUpload(nil, uploadRequest(t))
+		assert.True(t, called, "the handler should have been called")
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Nil(t, files)
+	})
+
+	t.Run("json body", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		_, files, called := serveUpload(nil, req)
+		assert.True(t, called, "the handler should have been called")
+		assert.Nil(t, files)
+	})
+}
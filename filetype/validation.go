@@ -0,0 +1,288 @@
+package filetype
+
+import (
+	"context"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+)
+
+// Reason represents the reason why a file is not valid
+type Reason string
+
+// List of all the reasons a file can be invalid
+const (
+	// ReasonUnsupportedType means the type of the file is not supported
+	ReasonUnsupportedType Reason = "unsupported_type"
+
+	// ReasonTypeMismatch means the file is not of the expected type
+	ReasonTypeMismatch Reason = "type_mismatch"
+
+	// ReasonTruncated means the file ended before it was supposed to
+	ReasonTruncated Reason = "truncated"
+
+	// ReasonCorrupted means the data of the file are invalid
+	ReasonCorrupted Reason = "corrupted"
+
+	// ReasonUnsupportedFeature means the file uses a feature of its
+	// format that is not supported by the decoder (ex. a variant of the
+	// format)
+	ReasonUnsupportedFeature Reason = "unsupported_feature"
+
+	// ReasonTooLarge means the file exceeds one of the limits set in
+	// ValidateOptions
+	ReasonTooLarge Reason = "too_large"
+)
+
+// ValidationResult contains the detailed result of the validation
+// of a file
+type ValidationResult struct {
+	// Valid is true if the file is valid
+	Valid bool `json:"valid"`
+
+	// MimeType is the detected type of the file. Can be empty if the
+	// type was not needed to validate the file
+	MimeType string `json:"mime_type,omitempty"`
+
+	// Reason contains the reason why the file is not valid. Empty for
+	// valid files
+	Reason Reason `json:"reason,omitempty"`
+
+	// Err is the error returned by the decoder or the parser, if any
+	Err error `json:"-"`
+
+	// Offset is the position in the file, relative to the position of the
+	// reader when the validation started, where the validation failed.
+	// -1 if the file is valid or if the position is unknown
+	Offset int64 `json:"offset"`
+}
+
+// Checker represents a function that validates a file and returns the
+// detailed result of the validation.
+// An error is only returned if the file could not be read.
+type Checker func(r io.ReadSeeker) (*ValidationResult, error)
+
+// newInvalidResult returns a ValidationResult for an invalid file
+func newInvalidResult(mimeType string, reason Reason, err error, offset int64) *ValidationResult {
+	return &ValidationResult{
+		MimeType: mimeType,
+		Reason:   reason,
+		Err:      err,
+		Offset:   offset,
+	}
+}
+
+// CheckPNG validates a PNG file
+func CheckPNG(r io.ReadSeeker) (*ValidationResult, error) {
+	return checkImageType(r, "image/png", png.Decode)
+}
+
+// CheckJPG validates a JPG file
+func CheckJPG(r io.ReadSeeker) (*ValidationResult, error) {
+	return checkImageType(r, "image/jpeg", jpeg.Decode)
+}
+
+// CheckGIF validates a GIF file
+func CheckGIF(r io.ReadSeeker) (*ValidationResult, error) {
+	return checkImageType(r, "image/gif", gif.Decode)
+}
+
+// CheckDecode validates an image by decoding it.
+// The type of the image is not checked, which means MimeType will be empty
+func CheckDecode(r io.ReadSeeker, decode ImageDecoder) (*ValidationResult, error) {
+	return checkDecode(context.Background(), r, decode)
+}
+
+// CheckImage checks the specified reader contains an image of one of the
+// supported formats.
+// The list of supported formats can be changed using RegisterImageFormat()
+func CheckImage(r io.ReadSeeker) (*ValidationResult, error) {
+	return CheckImageWithOptions(r, nil)
+}
+
+// CheckImageWithOptions checks the specified reader contains an image of one
+// of the supported formats that respects the provided limits.
+// The limits are checked before the image gets validated. opts can be nil.
+func CheckImageWithOptions(r io.ReadSeeker, opts *ValidateOptions) (*ValidationResult, error) {
+	mimeType, err := MimeType(r)
+	if err == errEmptyFile {
+		return newInvalidResult("", ReasonTruncated, err, 0), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	format, found := LookupImageFormat(mimeType)
+	if !found {
+		return newInvalidResult(mimeType, ReasonUnsupportedType, nil, 0), nil
+	}
+
+	if opts != nil {
+		res, err := opts.checkLimits(r, format.Decode)
+		if err != nil || !res.Valid {
+			if res != nil {
+				res.MimeType = mimeType
+			}
+			return res, err
+		}
+	}
+
+	res, err := format.Check(r)
+	if err != nil {
+		return nil, err
+	}
+	if res.MimeType == "" {
+		res.MimeType = mimeType
+	}
+	return res, nil
+}
+
+// checkImageType makes sure the file is of the expected type before
+// decoding it
+func checkImageType(r io.ReadSeeker, expectedType string, decode ImageDecoder) (*ValidationResult, error) {
+	mimeType, err := MimeType(r)
+	if err == errEmptyFile {
+		return newInvalidResult("", ReasonTruncated, err, 0), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if mimeType != expectedType {
+		return newInvalidResult(mimeType, ReasonTypeMismatch, nil, 0), nil
+	}
+
+	res, err := CheckDecode(r, decode)
+	if err != nil {
+		return nil, err
+	}
+	res.MimeType = mimeType
+	return res, nil
+}
+
+// checkDecode validates an image by decoding it. The decoding is
+// aborted if the context is done, in which case the error of the context
+// is returned
+func checkDecode(ctx context.Context, r io.ReadSeeker, decode ImageDecoder) (*ValidationResult, error) {
+	initialPos, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse the whole file
+	dr := &decodeReader{ctx: ctx, r: r}
+	_, decodeErr := decode(dr)
+
+	// revert the pointer back to its original position
+	if _, err = r.Seek(initialPos, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		return nil, ctx.Err()
+	case dr.err != nil && dr.err != io.EOF:
+		// The decoder failed because we couldn't read the file
+		return nil, dr.err
+	case decodeErr == nil:
+		return &ValidationResult{Valid: true, Offset: -1}, nil
+	}
+	return newInvalidResult("", decodeErrorReason(decodeErr, dr.err == io.EOF), decodeErr, dr.n), nil
+}
+
+// decodeErrorReason returns the Reason matching an error returned by
+// a decoder. reachedEOF should be true if the decoder tried to read
+// past the end of the file
+func decodeErrorReason(err error, reachedEOF bool) Reason {
+	switch err.(type) {
+	case png.UnsupportedError, jpeg.UnsupportedError:
+		return ReasonUnsupportedFeature
+	}
+	// Some decoders (like image/gif) don't return io.ErrUnexpectedEOF as is
+	// but use its message in their own error
+	if reachedEOF || err == io.EOF || strings.Contains(err.Error(), io.ErrUnexpectedEOF.Error()) {
+		return ReasonTruncated
+	}
+	return ReasonCorrupted
+}
+
+// checkStructure runs a function that checks the structure of a file,
+// and puts the reader back to its original position.
+// The file is considered invalid if check returns errTruncated or
+// errMalformed. Any other error is returned as is.
+func checkStructure(r io.ReadSeeker, check func(s *section) error) (res *ValidationResult, err error) {
+	s, err := newSection(r)
+	if err != nil {
+		return nil, err
+	}
+	// revert the pointer back to its original position
+	defer func() {
+		_, seekErr := r.Seek(s.start, io.SeekStart)
+		if err == nil && seekErr != nil {
+			res = nil
+			err = seekErr
+		}
+	}()
+
+	switch err = check(s); err {
+	case nil:
+		return &ValidationResult{Valid: true, Offset: -1}, nil
+	case errTruncated:
+		return newInvalidResult("", ReasonTruncated, err, s.lastOffset), nil
+	case errMalformed:
+		return newInvalidResult("", ReasonCorrupted, err, s.lastOffset), nil
+	default:
+		return nil, err
+	}
+}
+
+// headerChecker returns a Checker that validates a file by parsing
+// its header
+func headerChecker(parse headerParser) Checker {
+	return func(r io.ReadSeeker) (*ValidationResult, error) {
+		return checkStructure(r, func(s *section) error {
+			_, err := parse(s)
+			return err
+		})
+	}
+}
+
+// validatorChecker returns a Checker that uses a FileValidator
+func validatorChecker(validate FileValidator) Checker {
+	return func(r io.ReadSeeker) (*ValidationResult, error) {
+		isValid, err := validate(r)
+		if err != nil {
+			return nil, err
+		}
+		if !isValid {
+			return newInvalidResult("", ReasonCorrupted, nil, -1), nil
+		}
+		return &ValidationResult{Valid: true, Offset: -1}, nil
+	}
+}
+
+// decodeReader is the reader given to the decoders. It keeps track of
+// the number of bytes read, and stops working once its context is done
+type decodeReader struct {
+	ctx context.Context
+	r   io.Reader
+	// n is the number of bytes read
+	n int64
+	// err is the last error returned by r
+	err error
+}
+
+// Read implements the io.Reader interface
+// https://golang.org/pkg/io/#Reader
+func (r *decodeReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	if err != nil {
+		r.err = err
+	}
+	return n, err
+}
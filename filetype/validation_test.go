@@ -0,0 +1,146 @@
+package filetype_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image/png"
+	"testing"
+
+	"github.com/Nivl/go-types/filetype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pngWithIHDR returns the PNG fixture with its IHDR updated by the
+// provided function. The checksum of the chunk is updated accordingly
+func pngWithIHDR(t *testing.T, update func(ihdr []byte)) []byte {
+	content := readFixture(t, "black_pixel.png")
+	// The IHDR chunk starts after the 8 bytes of signature, and has 4 bytes
+	// of length, 4 bytes of type, 13 bytes of data and 4 bytes of CRC
+	update(content[16:29])
+	binary.BigEndian.PutUint32(content[29:33], crc32.ChecksumIEEE(content[12:29]))
+	return content
+}
+
+func TestCheckPNG(t *testing.T) {
+	png := readFixture(t, "black_pixel.png")
+	corrupted := readFixture(t, "black_pixel.png")
+	corrupted[30]++ // invalid checksum for IHDR
+
+	testCases := []struct {
+		description    string
+		content        []byte
+		expectedValid  bool
+		expectedMime   string
+		expectedReason filetype.Reason
+		expectedOffset int64
+	}{
+		{"valid png", png, true, "image/png", "", -1},
+		{"gif", readFixture(t, "black_pixel.gif"), false, "image/gif", filetype.ReasonTypeMismatch, 0},
+		{"empty", []byte{}, false, "", filetype.ReasonTruncated, 0},
+		{"truncated", png[:60], false, "image/png", filetype.ReasonTruncated, 60},
+		{"corrupted", corrupted, false, "image/png", filetype.ReasonCorrupted, 33},
+		{
+			"unsupported compression",
+			pngWithIHDR(t, func(ihdr []byte) { ihdr[10] = 1 }),
+			false, "image/png", filetype.ReasonUnsupportedFeature, 29,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			r := bytes.NewReader(tc.content)
+			res, err := filetype.CheckPNG(r)
+			require.NoError(t, err, "CheckPNG() should not have failed")
+			assert.Equal(t, tc.expectedValid, res.Valid, "invalid Valid")
+			assert.Equal(t, tc.expectedMime, res.MimeType, "invalid MimeType")
+			assert.Equal(t, tc.expectedReason, res.Reason, "invalid Reason")
+			assert.Equal(t, tc.expectedOffset, res.Offset, "invalid Offset")
+			if tc.expectedValid {
+				assert.NoError(t, res.Err, "Err should be empty for a valid file")
+			} else if tc.expectedReason != filetype.ReasonTypeMismatch {
+				assert.Error(t, res.Err, "Err should contain the error of the decoder")
+			}
+			assert.Equal(t, len(tc.content), r.Len(), "the reader should be at its original position")
+		})
+	}
+}
+
+func TestCheckJPGAndGIF(t *testing.T) {
+	jpg := readFixture(t, "black_pixel.jpg")
+	gif := readFixture(t, "black_pixel.gif")
+
+	testCases := []struct {
+		description    string
+		checker        filetype.Checker
+		content        []byte
+		expectedValid  bool
+		expectedReason filetype.Reason
+	}{
+		{"valid jpg", filetype.CheckJPG, jpg, true, ""},
+		{"truncated jpg", filetype.CheckJPG, jpg[:len(jpg)/2], false, filetype.ReasonTruncated},
+		{"png as jpg", filetype.CheckJPG, readFixture(t, "black_pixel.png"), false, filetype.ReasonTypeMismatch},
+		{"valid gif", filetype.CheckGIF, gif, true, ""},
+		{"truncated gif", filetype.CheckGIF, gif[:len(gif)-10], false, filetype.ReasonTruncated},
+		{"jpg as gif", filetype.CheckGIF, jpg, false, filetype.ReasonTypeMismatch},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			res, err := tc.checker(bytes.NewReader(tc.content))
+			require.NoError(t, err, "the checker should not have failed")
+			assert.Equal(t, tc.expectedValid, res.Valid, "invalid Valid")
+			assert.Equal(t, tc.expectedReason, res.Reason, "invalid Reason")
+		})
+	}
+}
+
+func TestCheckDecode(t *testing.T) {
+	res, err := filetype.CheckDecode(bytes.NewReader(readFixture(t, "black_pixel.gif")), png.Decode)
+	require.NoError(t, err, "CheckDecode() should not have failed")
+	assert.False(t, res.Valid, "a gif should not be decoded by the png decoder")
+	assert.Empty(t, res.MimeType, "CheckDecode() should not detect the mimetype")
+	assert.Equal(t, filetype.ReasonCorrupted, res.Reason, "invalid Reason")
+	assert.Error(t, res.Err, "Err should contain the error of the decoder")
+}
+
+func TestCheckImage(t *testing.T) {
+	webp := readFixture(t, "black_pixel.webp")
+
+	testCases := []struct {
+		description    string
+		content        []byte
+		opts           *filetype.ValidateOptions
+		expectedValid  bool
+		expectedMime   string
+		expectedReason filetype.Reason
+	}{
+		{"png", readFixture(t, "black_pixel.png"), nil, true, "image/png", ""},
+		{"webp", webp, nil, true, "image/webp", ""},
+		{"truncated webp", webp[:len(webp)-4], nil, false, "image/webp", filetype.ReasonTruncated},
+		{"pdf", readFixture(t, "black_pixel.pdf"), nil, false, "application/pdf", filetype.ReasonUnsupportedType},
+		{"empty", []byte{}, nil, false, "", filetype.ReasonTruncated},
+		{"bomb", pngBomb(), &filetype.ValidateOptions{MaxPixels: 100}, false, "image/png", filetype.ReasonTooLarge},
+		{"too many bytes", webp, &filetype.ValidateOptions{MaxBytes: 10}, false, "image/webp", filetype.ReasonTooLarge},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			res, err := filetype.CheckImageWithOptions(bytes.NewReader(tc.content), tc.opts)
+			require.NoError(t, err, "CheckImageWithOptions() should not have failed")
+			assert.Equal(t, tc.expectedValid, res.Valid, "invalid Valid")
+			assert.Equal(t, tc.expectedMime, res.MimeType, "invalid MimeType")
+			assert.Equal(t, tc.expectedReason, res.Reason, "invalid Reason")
+		})
+	}
+}
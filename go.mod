@@ -1,6 +1,6 @@
 module github.com/Nivl/go-types
 
-go 1.12
+go 1.16
 
 require (
 	github.com/golang/mock v1.2.0
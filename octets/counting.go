@@ -0,0 +1,117 @@
+package octets
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// ProgressFunc represents the function called by a CountingReader or a
+// CountingWriter to report the number of bytes transferred so far
+type ProgressFunc func(total int64)
+
+// progress counts the bytes transferred and reports the progress every
+// interval bytes
+type progress struct {
+	// total is first to be 64-bit aligned on 32-bit platforms, for the
+	// atomic operations
+	total    int64
+	reported int64
+	interval int64
+	fn       ProgressFunc
+}
+
+// add counts n more bytes, and calls the callback if an interval has
+// been crossed. The callback is called at most once per call
+func (p *progress) add(n int) {
+	if n <= 0 {
+		return
+	}
+	total := atomic.AddInt64(&p.total, int64(n))
+	if p.fn == nil {
+		return
+	}
+	if p.interval <= 0 || total/p.interval > p.reported/p.interval {
+		p.reported = total
+		p.fn(total)
+	}
+}
+
+// done reports the final count, if it has not been reported yet
+func (p *progress) done() {
+	total := atomic.LoadInt64(&p.total)
+	if p.fn != nil && total != p.reported {
+		p.reported = total
+		p.fn(total)
+	}
+}
+
+// CountingReader is a reader that counts the number of bytes read, and
+// reports the progress
+type CountingReader struct {
+	progress progress
+	r        io.Reader
+}
+
+// NewCountingReader returns a reader that reads from r and calls fn each
+// time another interval bytes have been read, and once r returns io.EOF.
+// fn is called on every read if interval is not positive. fn can be nil
+func NewCountingReader(r io.Reader, interval int64, fn ProgressFunc) *CountingReader {
+	return &CountingReader{
+		r:        r,
+		progress: progress{interval: interval, fn: fn},
+	}
+}
+
+// Read implements the io.Reader interface
+// https://golang.org/pkg/io/#Reader
+func (r *CountingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.progress.add(n)
+	if err == io.EOF {
+		r.progress.done()
+	}
+	return n, err
+}
+
+// Count returns the number of bytes read so far. It's safe to call
+// Count() while the data are being read
+func (r *CountingReader) Count() int64 {
+	return atomic.LoadInt64(&r.progress.total)
+}
+
+// CountingWriter is a writer that counts the number of bytes written, and
+// reports the progress
+type CountingWriter struct {
+	progress progress
+	w        io.Writer
+}
+
+// NewCountingWriter returns a writer that writes to w and calls fn each
+// time another interval bytes have been written.
+// fn is called on every write if interval is not positive. fn can be nil
+func NewCountingWriter(w io.Writer, interval int64, fn ProgressFunc) *CountingWriter {
+	return &CountingWriter{
+		w:        w,
+		progress: progress{interval: interval, fn: fn},
+	}
+}
+
+// Write implements the io.Writer interface
+// https://golang.org/pkg/io/#Writer
+func (w *CountingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.progress.add(n)
+	return n, err
+}
+
+// Count returns the number of bytes written so far. It's safe to call
+// Count() while the data are being written
+func (w *CountingWriter) Count() int64 {
+	return atomic.LoadInt64(&w.progress.total)
+}
+
+// Done reports the number of bytes written so far, if it has not been
+// reported yet. It should be called once all the data have been written
+func (w *CountingWriter) Done() {
+	w.progress.done()
+}
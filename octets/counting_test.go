@@ -0,0 +1,89 @@
+package octets_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+	"testing/iotest"
+
+	"github.com/Nivl/go-types/octets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountingReader(t *testing.T) {
+	everyByte := make([]int64, 100)
+	for i := range everyByte {
+		everyByte[i] = int64(i + 1)
+	}
+
+	testCases := []struct {
+		description string
+		reader      func(r io.Reader) io.Reader
+		interval    int64
+		expected    []int64
+	}{
+		{"interval", iotest.OneByteReader, 40, []int64{40, 80, 100}},
+		{"interval matching the size", iotest.OneByteReader, 50, []int64{50, 100}},
+		{"one read crossing many intervals", func(r io.Reader) io.Reader { return r }, 10, []int64{100}},
+		{"no interval", iotest.OneByteReader, 0, everyByte},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			content := bytes.Repeat([]byte("a"), 100)
+			reports := []int64{}
+			r := octets.NewCountingReader(tc.reader(bytes.NewReader(content)), tc.interval, func(total int64) {
+				reports = append(reports, total)
+			})
+
+			data, err := ioutil.ReadAll(r)
+			require.NoError(t, err, "ReadAll() should have succeed")
+			assert.Equal(t, content, data)
+			assert.Equal(t, int64(100), r.Count())
+			assert.Equal(t, tc.expected, reports)
+		})
+	}
+}
+
+func TestCountingReaderWithoutCallback(t *testing.T) {
+	r := octets.NewCountingReader(bytes.NewReader(make([]byte, 100)), 10, nil)
+	_, err := ioutil.ReadAll(r)
+	require.NoError(t, err, "ReadAll() should have succeed")
+	assert.Equal(t, int64(100), r.Count())
+}
+
+func TestCountingWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	reports := []int64{}
+	w := octets.NewCountingWriter(buf, 40, func(total int64) {
+		reports = append(reports, total)
+	})
+
+	for i := 0; i < 10; i++ {
+		_, err := w.Write(make([]byte, 10))
+		require.NoError(t, err, "Write() should have succeed")
+	}
+	assert.Equal(t, []int64{40, 80}, reports)
+
+	w.Done()
+	assert.Equal(t, []int64{40, 80, 100}, reports, "the final count should have been reported")
+	w.Done()
+	assert.Equal(t, []int64{40, 80, 100}, reports, "the final count should only be reported once")
+	assert.Equal(t, int64(100), w.Count())
+	assert.Equal(t, 100, buf.Len())
+}
+
+func TestCountingWriterWithLimit(t *testing.T) {
+	w := octets.NewCountingWriter(octets.LimitWriter(ioutil.Discard, 15), 0, nil)
+
+	_, err := w.Write(make([]byte, 10))
+	require.NoError(t, err, "Write() should have succeed")
+	_, err = w.Write(make([]byte, 10))
+	require.Error(t, err, "Write() should have failed")
+	assert.Equal(t, int64(10), w.Count(), "the rejected write should not have been counted")
+}
@@ -0,0 +1,120 @@
+package octets
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrMsgSizeExceeded represents the error message returned when more data
+// than allowed is read or written
+var ErrMsgSizeExceeded = "size exceeded"
+
+// ErrSizeExceeded is the error matched by errors.Is() when a limit set by
+// LimitReader() or LimitWriter() is exceeded. Use errors.As() with a
+// *SizeExceededError to get the details
+var ErrSizeExceeded = errors.New(ErrMsgSizeExceeded)
+
+// SizeExceededError is the error returned when a limit set by
+// LimitReader() or LimitWriter() is exceeded
+type SizeExceededError struct {
+	// Limit is the maximum number of bytes allowed
+	Limit int64
+
+	// Observed is the number of bytes that have been seen when the limit
+	// was exceeded. For readers, it's at least Limit+1. For writers, it's
+	// the number of bytes written plus the size of the rejected write
+	Observed int64
+}
+
+// Error implements the error interface
+// https://golang.org/pkg/builtin/#error
+func (e *SizeExceededError) Error() string {
+	return fmt.Sprintf("%s: got at least %s, the limit is %s", ErrMsgSizeExceeded, Size(e.Observed), Size(e.Limit))
+}
+
+// Is makes the error match ErrSizeExceeded
+// https://golang.org/pkg/errors/#Is
+func (e *SizeExceededError) Is(target error) bool {
+	return target == ErrSizeExceeded
+}
+
+// LimitReader returns a reader that reads from r, and fails with a
+// *SizeExceededError if r contains more than limit bytes.
+// Unlike io.LimitReader(), the data are never silently truncated: all the
+// bytes up to the limit are returned, then the error is returned instead
+// of io.EOF
+func LimitReader(r io.Reader, limit int64) io.Reader {
+	if limit < 0 {
+		limit = 0
+	}
+	return &limitedReader{r: r, limit: limit}
+}
+
+// limitedReader is a reader that fails when it reads more than limit
+// bytes
+type limitedReader struct {
+	r     io.Reader
+	limit int64
+	read  int64
+	err   error
+}
+
+// Read implements the io.Reader interface
+// https://golang.org/pkg/io/#Reader
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	// We read one more byte than allowed to know if the limit is exceeded.
+	// remaining+1 is not computed since it overflows when the limit is
+	// math.MaxInt64
+	remaining := r.limit - r.read
+	if remaining < int64(len(p)) {
+		p = p[:remaining+1]
+	}
+	n, err := r.r.Read(p)
+	r.read += int64(n)
+	if r.read > r.limit {
+		r.err = &SizeExceededError{Limit: r.limit, Observed: r.read}
+		return n - int(r.read-r.limit), r.err
+	}
+	if err != nil {
+		r.err = err
+	}
+	return n, err
+}
+
+// LimitWriter returns a writer that writes to w, and fails with a
+// *SizeExceededError when more than limit bytes are written.
+// The write that exceeds the limit is rejected as a whole, nothing is
+// written to w
+func LimitWriter(w io.Writer, limit int64) io.Writer {
+	if limit < 0 {
+		limit = 0
+	}
+	return &limitedWriter{w: w, limit: limit}
+}
+
+// limitedWriter is a writer that fails when more than limit bytes are
+// written
+type limitedWriter struct {
+	w       io.Writer
+	limit   int64
+	written int64
+}
+
+// Write implements the io.Writer interface
+// https://golang.org/pkg/io/#Writer
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) > w.limit-w.written {
+		return 0, &SizeExceededError{Limit: w.limit, Observed: w.written + int64(len(p))}
+	}
+	n, err := w.w.Write(p)
+	w.written += int64(n)
+	return n, err
+}
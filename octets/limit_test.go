@@ -0,0 +1,138 @@
+package octets_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/Nivl/go-types/octets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitReader(t *testing.T) {
+	testCases := []struct {
+		description      string
+		size             int
+		limit            int64
+		expectedObserved int64
+	}{
+		{"smaller than the limit", 10, 20, 0},
+		{"same size as the limit", 20, 20, 0},
+		{"one byte too big", 21, 20, 21},
+		{"way too big", 1000, 20, 21},
+		{"empty with no limit", 0, 0, 0},
+		{"no limit", 1, 0, 1},
+		{"negative limit", 1, -1, 1},
+		{"max limit", 10, math.MaxInt64, 0},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			content := bytes.Repeat([]byte("a"), tc.size)
+			data, err := ioutil.ReadAll(octets.LimitReader(bytes.NewReader(content), tc.limit))
+			if tc.expectedObserved == 0 {
+				require.NoError(t, err, "ReadAll() should have succeed")
+				assert.Equal(t, content, data)
+				return
+			}
+
+			require.Error(t, err, "ReadAll() should have failed")
+			assert.True(t, errors.Is(err, octets.ErrSizeExceeded), "unexpected error: %v", err)
+			var sizeErr *octets.SizeExceededError
+			require.True(t, errors.As(err, &sizeErr), "unexpected error: %v", err)
+			assert.Equal(t, octets.SizeExceededError{Limit: maxInt64(tc.limit, 0), Observed: tc.expectedObserved}, *sizeErr)
+			assert.Len(t, data, int(maxInt64(tc.limit, 0)), "all the data up to the limit should have been returned")
+		})
+	}
+}
+
+func TestLimitReaderMaxLimitWithCopy(t *testing.T) {
+	n, err := io.Copy(ioutil.Discard, octets.LimitReader(strings.NewReader("hello"), math.MaxInt64))
+	require.NoError(t, err, "Copy() should have succeed")
+	assert.Equal(t, int64(5), n)
+}
+
+func TestLimitReaderSmallReads(t *testing.T) {
+	content := bytes.Repeat([]byte("a"), 100)
+	r := octets.LimitReader(iotest.OneByteReader(bytes.NewReader(content)), 50)
+
+	data, err := ioutil.ReadAll(r)
+	require.Error(t, err, "ReadAll() should have failed")
+	assert.True(t, errors.Is(err, octets.ErrSizeExceeded), "unexpected error: %v", err)
+	assert.Len(t, data, 50)
+
+	// the error must be sticky
+	n, err := r.Read(make([]byte, 10))
+	assert.Equal(t, 0, n)
+	assert.True(t, errors.Is(err, octets.ErrSizeExceeded), "unexpected error: %v", err)
+}
+
+func TestLimitWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := octets.LimitWriter(buf, 10)
+
+	n, err := w.Write([]byte("hello"))
+	require.NoError(t, err, "Write() should have succeed")
+	assert.Equal(t, 5, n)
+
+	n, err = w.Write([]byte(" world"))
+	require.Error(t, err, "Write() should have failed")
+	assert.Equal(t, 0, n, "nothing should have been written")
+	assert.True(t, errors.Is(err, octets.ErrSizeExceeded), "unexpected error: %v", err)
+	assert.Equal(t, "size exceeded: got at least 11B, the limit is 10B", err.Error())
+	var sizeErr *octets.SizeExceededError
+	require.True(t, errors.As(err, &sizeErr), "unexpected error: %v", err)
+	assert.Equal(t, int64(10), sizeErr.Limit)
+	assert.Equal(t, int64(11), sizeErr.Observed)
+
+	n, err = w.Write([]byte("!!!!!"))
+	require.NoError(t, err, "Write() should have succeed")
+	assert.Equal(t, 5, n)
+	assert.Equal(t, "hello!!!!!", buf.String())
+}
+
+func TestLimitWriterLimits(t *testing.T) {
+	t.Run("negative limit", func(t *testing.T) {
+		t.Parallel()
+
+		w := octets.LimitWriter(ioutil.Discard, -1)
+		n, err := w.Write(nil)
+		require.NoError(t, err, "empty writes should succeed")
+		assert.Equal(t, 0, n)
+
+		_, err = w.Write([]byte("a"))
+		require.Error(t, err, "Write() should have failed")
+		var sizeErr *octets.SizeExceededError
+		require.True(t, errors.As(err, &sizeErr), "unexpected error: %v", err)
+		assert.Equal(t, octets.SizeExceededError{Limit: 0, Observed: 1}, *sizeErr)
+	})
+
+	t.Run("max limit", func(t *testing.T) {
+		t.Parallel()
+
+		n, err := io.Copy(octets.LimitWriter(ioutil.Discard, math.MaxInt64), strings.NewReader("hello"))
+		require.NoError(t, err, "Copy() should have succeed")
+		assert.Equal(t, int64(5), n)
+	})
+}
+
+func TestLimitWriterWithCopy(t *testing.T) {
+	_, err := io.Copy(octets.LimitWriter(ioutil.Discard, octets.KiB), bytes.NewReader(make([]byte, 2*octets.KiB)))
+	assert.True(t, errors.Is(err, octets.ErrSizeExceeded), "unexpected error: %v", err)
+}
+
+func maxInt64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -2,15 +2,15 @@
 // file sizes
 package octets
 
-// List of all different supported type
+// List of the decimal (SI) units, which are multiples of 1000
 const (
-	Byte int64 = 1.0 << (10 * iota)
-	KiloByte
-	MegaByte
-	GigaByte
-	TeraByte
-	PetaByte
-	ExaByte
+	Byte     int64 = 1
+	KiloByte int64 = 1000 * Byte
+	MegaByte int64 = 1000 * KiloByte
+	GigaByte int64 = 1000 * MegaByte
+	TeraByte int64 = 1000 * GigaByte
+	PetaByte int64 = 1000 * TeraByte
+	ExaByte  int64 = 1000 * PetaByte
 
 	B  int64 = Byte
 	KB int64 = KiloByte
@@ -24,7 +24,7 @@ const (
 	KiloOctet int64 = KiloByte
 	MegaOctet int64 = MegaByte
 	GigaOctet int64 = GigaByte
-	TeraOctet int64 = GigaByte
+	TeraOctet int64 = TeraByte
 	PetaOctet int64 = PetaByte
 	ExaOctet  int64 = ExaByte
 
@@ -36,3 +36,34 @@ const (
 	Po int64 = PetaOctet
 	Eo int64 = ExaOctet
 )
+
+// List of the binary (IEC) units, which are multiples of 1024
+const (
+	KibiByte int64 = 1 << 10
+	MebiByte int64 = 1 << 20
+	GibiByte int64 = 1 << 30
+	TebiByte int64 = 1 << 40
+	PebiByte int64 = 1 << 50
+	ExbiByte int64 = 1 << 60
+
+	KiB int64 = KibiByte
+	MiB int64 = MebiByte
+	GiB int64 = GibiByte
+	TiB int64 = TebiByte
+	PiB int64 = PebiByte
+	EiB int64 = ExbiByte
+
+	KibiOctet int64 = KibiByte
+	MebiOctet int64 = MebiByte
+	GibiOctet int64 = GibiByte
+	TebiOctet int64 = TebiByte
+	PebiOctet int64 = PebiByte
+	ExbiOctet int64 = ExbiByte
+
+	Kio int64 = KibiOctet
+	Mio int64 = MebiOctet
+	Gio int64 = GibiOctet
+	Tio int64 = TebiOctet
+	Pio int64 = PebiOctet
+	Eio int64 = ExbiOctet
+)
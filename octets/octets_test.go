@@ -0,0 +1,84 @@
+package octets_test
+
+import (
+	"testing"
+
+	"github.com/Nivl/go-types/octets"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConstants(t *testing.T) {
+	testCases := []struct {
+		description string
+		value       int64
+		expected    int64
+	}{
+		{"Byte", octets.Byte, 1},
+		{"KiloByte", octets.KiloByte, 1000},
+		{"MegaByte", octets.MegaByte, 1000000},
+		{"GigaByte", octets.GigaByte, 1000000000},
+		{"TeraByte", octets.TeraByte, 1000000000000},
+		{"PetaByte", octets.PetaByte, 1000000000000000},
+		{"ExaByte", octets.ExaByte, 1000000000000000000},
+
+		{"B", octets.B, 1},
+		{"KB", octets.KB, 1000},
+		{"MB", octets.MB, 1000000},
+		{"GB", octets.GB, 1000000000},
+		{"TB", octets.TB, 1000000000000},
+		{"PB", octets.PB, 1000000000000000},
+		{"EB", octets.EB, 1000000000000000000},
+
+		{"Octet", octets.Octet, 1},
+		{"KiloOctet", octets.KiloOctet, 1000},
+		{"MegaOctet", octets.MegaOctet, 1000000},
+		{"GigaOctet", octets.GigaOctet, 1000000000},
+		{"TeraOctet", octets.TeraOctet, 1000000000000},
+		{"PetaOctet", octets.PetaOctet, 1000000000000000},
+		{"ExaOctet", octets.ExaOctet, 1000000000000000000},
+
+		{"O", octets.O, 1},
+		{"Ko", octets.Ko, 1000},
+		{"Mo", octets.Mo, 1000000},
+		{"Go", octets.Go, 1000000000},
+		{"To", octets.To, 1000000000000},
+		{"Po", octets.Po, 1000000000000000},
+		{"Eo", octets.Eo, 1000000000000000000},
+
+		{"KibiByte", octets.KibiByte, 1024},
+		{"MebiByte", octets.MebiByte, 1048576},
+		{"GibiByte", octets.GibiByte, 1073741824},
+		{"TebiByte", octets.TebiByte, 1099511627776},
+		{"PebiByte", octets.PebiByte, 1125899906842624},
+		{"ExbiByte", octets.ExbiByte, 1152921504606846976},
+
+		{"KiB", octets.KiB, 1024},
+		{"MiB", octets.MiB, 1048576},
+		{"GiB", octets.GiB, 1073741824},
+		{"TiB", octets.TiB, 1099511627776},
+		{"PiB", octets.PiB, 1125899906842624},
+		{"EiB", octets.EiB, 1152921504606846976},
+
+		{"KibiOctet", octets.KibiOctet, 1024},
+		{"MebiOctet", octets.MebiOctet, 1048576},
+		{"GibiOctet", octets.GibiOctet, 1073741824},
+		{"TebiOctet", octets.TebiOctet, 1099511627776},
+		{"PebiOctet", octets.PebiOctet, 1125899906842624},
+		{"ExbiOctet", octets.ExbiOctet, 1152921504606846976},
+
+		{"Kio", octets.Kio, 1024},
+		{"Mio", octets.Mio, 1048576},
+		{"Gio", octets.Gio, 1073741824},
+		{"Tio", octets.Tio, 1099511627776},
+		{"Pio", octets.Pio, 1125899906842624},
+		{"Eio", octets.Eio, 1152921504606846976},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, tc.value)
+		})
+	}
+}
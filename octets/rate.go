@@ -0,0 +1,156 @@
+package octets
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// ErrMsgInvalidRate represents the error message returned when a rate
+// cannot be parsed
+var ErrMsgInvalidRate = "invalid rate"
+
+// ErrMsgRateOutOfRange represents the error message returned when a rate
+// doesn't fit in an int64
+var ErrMsgRateOutOfRange = "rate out of range"
+
+// Rate represents a transfer rate, in bytes per second
+type Rate int64
+
+// bitPrefixes contains the prefixes accepted by ParseRate() for the bit
+// rates ("kbps", "Mibit/s", etc.), and their value
+var bitPrefixes = map[string]int64{
+	"":   1,
+	"k":  KB,
+	"K":  KB,
+	"M":  MB,
+	"G":  GB,
+	"T":  TB,
+	"P":  PB,
+	"E":  EB,
+	"Ki": KiB,
+	"Mi": MiB,
+	"Gi": GiB,
+	"Ti": TiB,
+	"Pi": PiB,
+	"Ei": EiB,
+}
+
+// bitSuffixes contains the suffixes of the bit rates. Since "b" means
+// bits and "B" means bytes, they are case sensitive
+var bitSuffixes = []string{"bps", "bit/s", "b/s"}
+
+// byteSuffixes contains the suffixes of the byte rates. They follow a
+// unit accepted by Parse() ("MB/s", "MiBps", "Mo/s")
+var byteSuffixes = []string{"/s", "ps"}
+
+// NewRate returns the rate needed to transfer size in d.
+// 0 is returned if d is not positive
+func NewRate(size Size, d time.Duration) Rate {
+	if d <= 0 {
+		return 0
+	}
+	return Rate(float64(size) / d.Seconds())
+}
+
+// ParseRate parses a human readable transfer rate, made of a number
+// followed by a unit ("100Mbps", "12 MiB/s", "1.5GB/s", "800kbit/s").
+// The bit rates use a lower case "b" ("Mb/s", "Mbps") and the byte
+// rates an upper case "B" ("MB/s", "MBps"). The byte units are the ones
+// accepted by Parse(). A number without unit is a number of bytes per
+// second.
+// Fractions of bytes are truncated.
+func ParseRate(s string) (Rate, error) {
+	number, unitName, ok := splitNumber(s)
+	if !ok {
+		return 0, errors.New(ErrMsgInvalidRate)
+	}
+	multiple, found := rateMultiple(unitName)
+	if !found {
+		return 0, errors.New(ErrMsgInvalidRate)
+	}
+	bytes, ok := scaleNumber(number, multiple)
+	if !ok {
+		return 0, errors.New(ErrMsgInvalidRate)
+	}
+	if !bytes.IsInt64() {
+		return 0, errors.New(ErrMsgRateOutOfRange)
+	}
+	return Rate(bytes.Int64()), nil
+}
+
+// rateMultiple returns the number of bytes per second of a rate unit
+func rateMultiple(unitName string) (multiple *big.Rat, found bool) {
+	if unitName == "" {
+		return big.NewRat(1, 1), true
+	}
+	for _, suffix := range bitSuffixes {
+		if strings.HasSuffix(unitName, suffix) {
+			bits, found := bitPrefixes[strings.TrimSuffix(unitName, suffix)]
+			if !found {
+				return nil, false
+			}
+			return big.NewRat(bits, 8), true
+		}
+	}
+	for _, suffix := range byteSuffixes {
+		prefix := strings.TrimSuffix(unitName, suffix)
+		if prefix == unitName || prefix == "" {
+			continue
+		}
+		u, found := parsedUnits[strings.ToLower(prefix)]
+		if !found {
+			return nil, false
+		}
+		return new(big.Rat).SetInt(u.multiple(SI)), true
+	}
+	return nil, false
+}
+
+// String returns the rate using the biggest IEC unit that represents it
+// exactly with at most 3 decimals ("12MiB/s").
+// The returned value can be parsed back using ParseRate().
+// https://golang.org/pkg/fmt/#Stringer
+func (r Rate) String() string {
+	return Size(r).String() + "/s"
+}
+
+// Format returns the rate using the biggest IEC unit smaller than the
+// rate, rounded to the given number of decimals ("1.46KiB/s" for 1500
+// with a precision of 2).
+// A negative precision returns String()
+func (r Rate) Format(precision int) string {
+	return Size(r).Format(precision) + "/s"
+}
+
+// FormatBits returns the rate in bits per second, using the biggest SI
+// unit smaller than the rate, rounded to the given number of decimals
+// ("100Mbps" for 12500000). A negative precision uses 3 decimals
+func (r Rate) FormatBits(precision int) string {
+	if precision < 0 {
+		precision = maxExactDecimals
+	}
+	bits := math.Abs(float64(r)) * 8
+	str := formatValue(r < 0, bits, decimalUnits, precision)
+	return strings.TrimSuffix(str, "B") + "bps"
+}
+
+// Duration returns the time needed to transfer size at the current rate.
+// The maximum duration is returned if the rate is not positive
+func (r Rate) Duration(size Size) time.Duration {
+	if r <= 0 {
+		return math.MaxInt64
+	}
+	d := float64(size) / float64(r) * float64(time.Second)
+	if d >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(d)
+}
+
+// Size returns the amount of data transferred in d at the current rate
+func (r Rate) Size(d time.Duration) Size {
+	return Size(float64(r) * d.Seconds())
+}
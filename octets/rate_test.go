@@ -0,0 +1,115 @@
+package octets_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Nivl/go-types/octets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRate(t *testing.T) {
+	testCases := []struct {
+		description string
+		input       string
+		expected    octets.Rate
+	}{
+		{"bytes per second without unit", "512", 512},
+		{"bytes per second", "512B/s", 512},
+		{"megabits per second", "100Mbps", 12500000},
+		{"megabits per second with a slash", "100Mb/s", 12500000},
+		{"kilobits with an upper case prefix", "800 Kbit/s", 100000},
+		{"binary bits", "8Mibps", octets.Rate(octets.MiB)},
+		{"bits", "16bps", 2},
+		{"fraction of a byte", "1bps", 0},
+		{"megabytes per second", "1.5MB/s", 1500000},
+		{"mebibytes per second", "12 MiB/s", octets.Rate(12 * octets.MiB)},
+		{"bytes with ps", "10MBps", octets.Rate(10 * octets.MB)},
+		{"French unit", "2Mo/s", octets.Rate(2 * octets.Mo)},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			rate, err := octets.ParseRate(tc.input)
+			require.NoError(t, err, "ParseRate() should have succeed")
+			assert.Equal(t, tc.expected, rate)
+		})
+	}
+}
+
+func TestParseRateInvalid(t *testing.T) {
+	testCases := []struct {
+		description string
+		input       string
+		expectedErr string
+	}{
+		{"empty", "", octets.ErrMsgInvalidRate},
+		{"size", "10MB", octets.ErrMsgInvalidRate},
+		{"unknown bit prefix", "10Xbps", octets.ErrMsgInvalidRate},
+		{"unknown byte unit", "10XB/s", octets.ErrMsgInvalidRate},
+		{"per second only", "10/s", octets.ErrMsgInvalidRate},
+		{"overflow", "10EB/s", octets.ErrMsgRateOutOfRange},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := octets.ParseRate(tc.input)
+			require.Error(t, err, "ParseRate() should have failed")
+			assert.Equal(t, tc.expectedErr, err.Error())
+		})
+	}
+}
+
+func TestRateFormat(t *testing.T) {
+	testCases := []struct {
+		description  string
+		rate         octets.Rate
+		expected     string
+		expectedRate string
+		expectedBits string
+	}{
+		{"zero", 0, "0B/s", "0B/s", "0bps"},
+		{"bytes", 100, "100B/s", "100B/s", "800bps"},
+		{"megabits", 12500000, "12500000B/s", "11.92MiB/s", "100Mbps"},
+		{"mebibytes", octets.Rate(12 * octets.MiB), "12MiB/s", "12MiB/s", "100.66Mbps"},
+		{"negative", -1500, "-1500B/s", "-1.46KiB/s", "-12kbps"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, tc.rate.String())
+			assert.Equal(t, tc.expectedRate, tc.rate.Format(2))
+			assert.Equal(t, tc.expectedBits, tc.rate.FormatBits(2))
+
+			parsed, err := octets.ParseRate(tc.rate.String())
+			require.NoError(t, err, "ParseRate() should have succeed")
+			assert.Equal(t, tc.rate, parsed, "the rate should round-trip")
+		})
+	}
+}
+
+func TestRateConversions(t *testing.T) {
+	rate := octets.Rate(octets.MiB)
+
+	assert.Equal(t, 10*time.Second, rate.Duration(octets.Size(10*octets.MiB)))
+	assert.Equal(t, 500*time.Millisecond, rate.Duration(octets.Size(512*octets.KiB)))
+	assert.Equal(t, time.Duration(math.MaxInt64), octets.Rate(0).Duration(1), "nothing can be transferred at 0B/s")
+	assert.Equal(t, time.Duration(math.MaxInt64), octets.Rate(1).Duration(math.MaxInt64), "the duration should not overflow")
+
+	assert.Equal(t, octets.Size(10*octets.MiB), rate.Size(10*time.Second))
+	assert.Equal(t, octets.Size(512*octets.KiB), rate.Size(500*time.Millisecond))
+
+	assert.Equal(t, rate, octets.NewRate(octets.Size(10*octets.MiB), 10*time.Second))
+	assert.Equal(t, octets.Rate(0), octets.NewRate(octets.Size(10*octets.MiB), 0))
+}
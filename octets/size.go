@@ -0,0 +1,313 @@
+package octets
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ErrMsgInvalidSize represents the error message returned when a size
+// cannot be parsed
+var ErrMsgInvalidSize = "invalid size"
+
+// ErrMsgSizeOutOfRange represents the error message returned when a size
+// doesn't fit in an int64
+var ErrMsgSizeOutOfRange = "size out of range"
+
+// Size represents a number of bytes
+type Size int64
+
+// UnitSystem represents the set of units used to parse and format sizes
+type UnitSystem int
+
+// List of all the supported unit systems
+const (
+	// IEC formats the sizes using the binary units (KiB, MiB, etc.),
+	// which are multiples of 1024.
+	// When parsing, the decimal units (KB, MB, Ko, etc.) are also
+	// considered multiples of 1024, like many operating systems do
+	IEC UnitSystem = iota
+
+	// SI formats the sizes using the decimal units (kB, MB, etc.), which
+	// are multiples of 1000.
+	// When parsing, the binary units (KiB, MiB, Kio, etc.) are still
+	// multiples of 1024
+	SI
+)
+
+// unit represents a multiple of a byte
+type unit struct {
+	name string
+	size uint64
+}
+
+// binaryUnits contains the IEC units, from the biggest to the smallest
+var binaryUnits = []unit{
+	{"EiB", uint64(EiB)},
+	{"PiB", uint64(PiB)},
+	{"TiB", uint64(TiB)},
+	{"GiB", uint64(GiB)},
+	{"MiB", uint64(MiB)},
+	{"KiB", uint64(KiB)},
+	{"B", uint64(B)},
+}
+
+// decimalUnits contains the SI units, from the biggest to the smallest
+var decimalUnits = []unit{
+	{"EB", uint64(EB)},
+	{"PB", uint64(PB)},
+	{"TB", uint64(TB)},
+	{"GB", uint64(GB)},
+	{"MB", uint64(MB)},
+	{"kB", uint64(KB)},
+	{"B", uint64(B)},
+}
+
+// parsedUnit represents a unit accepted by Parse()
+type parsedUnit struct {
+	// power is the power of 1000 or 1024 of the unit (2 for MB)
+	power int
+
+	// binary is true if the unit is always a multiple of 1024
+	binary bool
+}
+
+// parsedUnits contains all the units accepted by Parse(), in lower case.
+// The French units (Ko, Kio, etc.) use octets instead of bytes
+var parsedUnits = map[string]parsedUnit{
+	"":  {0, false},
+	"b": {0, false},
+	"o": {0, false},
+
+	"kb": {1, false}, "ko": {1, false},
+	"mb": {2, false}, "mo": {2, false},
+	"gb": {3, false}, "go": {3, false},
+	"tb": {4, false}, "to": {4, false},
+	"pb": {5, false}, "po": {5, false},
+	"eb": {6, false}, "eo": {6, false},
+
+	"kib": {1, true}, "kio": {1, true},
+	"mib": {2, true}, "mio": {2, true},
+	"gib": {3, true}, "gio": {3, true},
+	"tib": {4, true}, "tio": {4, true},
+	"pib": {5, true}, "pio": {5, true},
+	"eib": {6, true}, "eio": {6, true},
+}
+
+// maxExactDecimals is the maximum number of decimals String() uses
+// before falling back to a smaller unit
+const maxExactDecimals = 3
+
+// Parse parses a human readable size, made of a number followed by an
+// optional unit ("512", "10MB", "1.5 GiB", "2Mo").
+// Decimal units (kB, MB, etc.) are multiples of 1000, and binary units
+// (KiB, MiB, etc.) are multiples of 1024. The French units (Ko, Mo, Kio,
+// Mio, etc.) are also accepted. Units are case insensitive.
+// Fractions of bytes are truncated.
+// Use IEC.Parse() to parse the decimal units as multiples of 1024.
+func Parse(s string) (Size, error) {
+	return SI.Parse(s)
+}
+
+// String returns the name of the unit system
+// https://golang.org/pkg/fmt/#Stringer
+func (sys UnitSystem) String() string {
+	if sys == SI {
+		return "SI"
+	}
+	return "IEC"
+}
+
+// Parse parses a human readable size using the unit system to know the
+// meaning of the decimal units (see Parse())
+func (sys UnitSystem) Parse(s string) (Size, error) {
+	number, unitName, ok := splitNumber(s)
+	if !ok {
+		return 0, errors.New(ErrMsgInvalidSize)
+	}
+	u, found := parsedUnits[strings.ToLower(unitName)]
+	if !found {
+		return 0, errors.New(ErrMsgInvalidSize)
+	}
+	bytes, ok := scaleNumber(number, new(big.Rat).SetInt(u.multiple(sys)))
+	if !ok {
+		return 0, errors.New(ErrMsgInvalidSize)
+	}
+	if !bytes.IsInt64() {
+		return 0, errors.New(ErrMsgSizeOutOfRange)
+	}
+	return Size(bytes.Int64()), nil
+}
+
+// multiple returns the number of bytes in the unit
+func (u parsedUnit) multiple(sys UnitSystem) *big.Int {
+	base := int64(1000)
+	if u.binary || sys == IEC {
+		base = 1024
+	}
+	return new(big.Int).Exp(big.NewInt(base), big.NewInt(int64(u.power)), nil)
+}
+
+// splitNumber splits a string made of a decimal number followed by a
+// unit. The spaces around the unit are removed
+func splitNumber(s string) (number, unitName string, ok bool) {
+	s = strings.TrimSpace(s)
+	i := 0
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		i++
+	}
+	dot := false
+	for ; i < len(s); i++ {
+		if s[i] == '.' && !dot {
+			dot = true
+			continue
+		}
+		if s[i] < '0' || s[i] > '9' {
+			break
+		}
+	}
+	number = strings.TrimLeft(s[:i], "+")
+	if digits := strings.TrimLeft(number, "-"); digits == "" || digits == "." {
+		return "", "", false
+	}
+	return number, strings.TrimSpace(s[i:]), true
+}
+
+// scaleNumber multiplies a decimal number by multiple, and truncates the
+// result. big.Rat is used to not lose any precision
+func scaleNumber(number string, multiple *big.Rat) (n *big.Int, ok bool) {
+	value, ok := new(big.Rat).SetString(number)
+	if !ok {
+		return nil, false
+	}
+	value.Mul(value, multiple)
+	return new(big.Int).Quo(value.Num(), value.Denom()), true
+}
+
+// Bytes returns the size as a number of bytes
+func (s Size) Bytes() int64 {
+	return int64(s)
+}
+
+// String returns the size using the biggest IEC unit that represents it
+// exactly with at most 3 decimals ("1.5KiB", "25MiB", "1025B").
+// The returned value can be parsed back using Parse().
+// https://golang.org/pkg/fmt/#Stringer
+func (s Size) String() string {
+	return IEC.Format(s, -1)
+}
+
+// Format returns the size using the biggest IEC unit smaller than the
+// size, rounded to the given number of decimals ("1.46KiB" for 1500
+// with a precision of 2). The trailing zeros are removed.
+// A negative precision returns String()
+func (s Size) Format(precision int) string {
+	return IEC.Format(s, precision)
+}
+
+// FormatSI works like Format() but uses the SI units ("1.5kB" for 1500)
+func (s Size) FormatSI(precision int) string {
+	return SI.Format(s, precision)
+}
+
+// Format returns the size using the biggest unit of the system smaller
+// than the size, rounded to the given number of decimals. The trailing
+// zeros are removed.
+// A negative precision uses the biggest unit that represents the size
+// exactly with at most 3 decimals, which can be parsed back without
+// losing any precision
+func (sys UnitSystem) Format(s Size, precision int) string {
+	units := binaryUnits
+	if sys == SI {
+		units = decimalUnits
+	}
+	if precision < 0 {
+		return s.formatExact(units)
+	}
+	return s.format(units, precision)
+}
+
+// formatExact returns the size using the biggest of the provided units
+// that represents it exactly with at most maxExactDecimals decimals
+func (s Size) formatExact(units []unit) string {
+	sign, n := s.abs()
+	for _, u := range units {
+		if n < u.size && u.size > 1 {
+			continue
+		}
+		if str, exact := divideExact(n, u.size); exact {
+			return sign + str + u.name
+		}
+	}
+	// Unreachable since the smallest unit is a byte
+	return sign + strconv.FormatUint(n, 10) + "B"
+}
+
+// format returns the size using the biggest of the provided units that
+// is smaller than the size, rounded to the given number of decimals
+func (s Size) format(units []unit, precision int) string {
+	_, n := s.abs()
+	return formatValue(s < 0, float64(n), units, precision)
+}
+
+// formatValue returns n using the biggest of the provided units that is
+// smaller than n, rounded to the given number of decimals. The trailing
+// zeros are removed.
+// If the rounded value reaches the next unit, the next unit is used
+// instead ("1MiB" instead of "1024KiB")
+func formatValue(negative bool, n float64, units []unit, precision int) string {
+	i := len(units) - 1
+	for j, candidate := range units {
+		if n >= float64(candidate.size) {
+			i = j
+			break
+		}
+	}
+	value := strconv.FormatFloat(n/float64(units[i].size), 'f', precision, 64)
+	if i > 0 {
+		base := float64(units[i-1].size / units[i].size)
+		if rounded, err := strconv.ParseFloat(value, 64); err == nil && rounded >= base {
+			i--
+			value = strconv.FormatFloat(n/float64(units[i].size), 'f', precision, 64)
+		}
+	}
+	u := units[i]
+	if strings.Contains(value, ".") {
+		value = strings.TrimRight(strings.TrimRight(value, "0"), ".")
+	}
+	if negative {
+		value = "-" + value
+	}
+	return value + u.name
+}
+
+// abs returns the sign and the absolute value of the size
+func (s Size) abs() (sign string, n uint64) {
+	if s >= 0 {
+		return "", uint64(s)
+	}
+	if s == math.MinInt64 {
+		return "-", uint64(math.MaxInt64) + 1
+	}
+	return "-", uint64(-s)
+}
+
+// divideExact returns n divided by unit, using at most maxExactDecimals
+// decimals. exact is false if the result cannot be represented exactly
+func divideExact(n, unit uint64) (str string, exact bool) {
+	str = strconv.FormatUint(n/unit, 10)
+	rem := n % unit
+	if rem == 0 {
+		return str, true
+	}
+	decimals := make([]byte, 0, maxExactDecimals)
+	// rem*10 cannot overflow since the units are at most 2^60
+	for rem != 0 && len(decimals) < maxExactDecimals {
+		rem *= 10
+		decimals = append(decimals, byte('0'+rem/unit))
+		rem %= unit
+	}
+	return str + "." + string(decimals), rem == 0
+}
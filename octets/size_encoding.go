@@ -0,0 +1,150 @@
+package octets
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// MarshalJSON returns the size as a JSON string ("25MiB")
+// https://golang.org/pkg/encoding/json/#Marshaler
+func (s Size) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// maxJSONExponent is the biggest exponent accepted in a JSON number.
+// Bigger exponents would allocate huge numbers, and cannot be valid sizes
+const maxJSONExponent = 100
+
+// UnmarshalJSON parses a size from a JSON number of bytes (26214400 or
+// 2.5e7), or from a JSON string accepted by Parse() ("25MiB").
+// JSON numbers must be a whole number of bytes
+// https://golang.org/pkg/encoding/json/#Unmarshaler
+func (s *Size) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var str string
+		if err := json.Unmarshal(data, &str); err != nil {
+			return err
+		}
+		return s.Set(str)
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	size, err := parseJSONNumber(n.String())
+	if err != nil {
+		return err
+	}
+	*s = size
+	return nil
+}
+
+// parseJSONNumber parses a JSON number that must be a whole number of
+// bytes. big.Rat is used to not lose any precision
+func parseJSONNumber(number string) (Size, error) {
+	if i := strings.IndexAny(number, "eE"); i >= 0 {
+		exp, err := strconv.Atoi(number[i+1:])
+		if err != nil || exp > maxJSONExponent || exp < -maxJSONExponent {
+			return 0, errors.New(ErrMsgInvalidSize)
+		}
+	}
+	value, ok := new(big.Rat).SetString(number)
+	if !ok || !value.IsInt() {
+		return 0, errors.New(ErrMsgInvalidSize)
+	}
+	if !value.Num().IsInt64() {
+		return 0, errors.New(ErrMsgSizeOutOfRange)
+	}
+	return Size(value.Num().Int64()), nil
+}
+
+// MarshalText returns the size as text ("25MiB"). Used by the YAML and
+// TOML encoders
+// https://golang.org/pkg/encoding/#TextMarshaler
+func (s Size) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText parses a size using Parse(). Used by the YAML and TOML
+// decoders
+// https://golang.org/pkg/encoding/#TextUnmarshaler
+func (s *Size) UnmarshalText(text []byte) error {
+	return s.Set(string(text))
+}
+
+// Set parses a size using Parse()
+// https://golang.org/pkg/flag/#Value
+func (s *Size) Set(value string) error {
+	size, err := Parse(value)
+	if err != nil {
+		return err
+	}
+	*s = size
+	return nil
+}
+
+// Type returns the name of the type, as displayed by
+// github.com/spf13/pflag in the usage of the flags
+func (s *Size) Type() string {
+	return "size"
+}
+
+// Value returns the number of bytes, to be stored as a BIGINT
+// https://golang.org/pkg/database/sql/driver/#Valuer
+func (s Size) Value() (driver.Value, error) {
+	return int64(s), nil
+}
+
+// Scan assigns a number of bytes from a database driver
+// https://golang.org/pkg/database/sql/#Scanner
+func (s *Size) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case int64:
+		*s = Size(v)
+		return nil
+	case []byte:
+		return s.scanString(string(v))
+	case string:
+		return s.scanString(v)
+	default:
+		return fmt.Errorf("%s: cannot scan %T", ErrMsgInvalidSize, value)
+	}
+}
+
+// scanString assigns a number of bytes stored as text, which is how some
+// drivers return BIGINTs
+func (s *Size) scanString(value string) error {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return errors.New(ErrMsgInvalidSize)
+	}
+	*s = Size(n)
+	return nil
+}
+
+// Getenv parses the size stored in the environment variable named by
+// key, using Parse(). fallback is returned if the variable is not set or
+// is empty
+func Getenv(key string, fallback Size) (Size, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	size, err := Parse(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+	return size, nil
+}
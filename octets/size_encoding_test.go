@@ -0,0 +1,184 @@
+package octets_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/Nivl/go-types/octets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Make sure Size implements all the interfaces
+var (
+	_ json.Marshaler           = octets.Size(0)
+	_ json.Unmarshaler         = (*octets.Size)(nil)
+	_ encoding.TextMarshaler   = octets.Size(0)
+	_ encoding.TextUnmarshaler = (*octets.Size)(nil)
+	_ flag.Value               = (*octets.Size)(nil)
+	_ driver.Valuer            = octets.Size(0)
+	_ sql.Scanner              = (*octets.Size)(nil)
+)
+
+// config represents a configuration file using sizes
+type config struct {
+	MaxUpload octets.Size  `json:"max_upload"`
+	MaxBody   *octets.Size `json:"max_body,omitempty"`
+}
+
+func TestSizeUnmarshalJSON(t *testing.T) {
+	testCases := []struct {
+		description string
+		json        string
+		expected    octets.Size
+	}{
+		{"string with unit", `{"max_upload": "25MiB"}`, 25 * 1024 * 1024},
+		{"string without unit", `{"max_upload": "1024"}`, 1024},
+		{"number", `{"max_upload": 26214400}`, 25 * 1024 * 1024},
+		{"number with an exponent", `{"max_upload": 1e3}`, 1000},
+		{"number with decimals and an exponent", `{"max_upload": 2.5e7}`, 25 * 1000 * 1000},
+		{"number with a negative exponent", `{"max_upload": 1000E-3}`, 1},
+		{"whole number with decimals", `{"max_upload": 1024.0}`, 1024},
+		{"null", `{"max_upload": null}`, 0},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := &config{}
+			require.NoError(t, json.Unmarshal([]byte(tc.json), cfg), "json.Unmarshal() should have succeed")
+			assert.Equal(t, tc.expected, cfg.MaxUpload)
+		})
+	}
+}
+
+func TestSizeUnmarshalJSONInvalid(t *testing.T) {
+	testCases := []struct {
+		description string
+		json        string
+	}{
+		{"invalid string", `{"max_upload": "25 potatoes"}`},
+		{"fraction of a byte", `{"max_upload": 1.5}`},
+		{"fraction of a byte with an exponent", `{"max_upload": 15e-1}`},
+		{"out of range", `{"max_upload": 1e19}`},
+		{"huge exponent", `{"max_upload": 1e1000000000}`},
+		{"boolean", `{"max_upload": true}`},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := &config{}
+			require.Error(t, json.Unmarshal([]byte(tc.json), cfg), "json.Unmarshal() should have failed")
+		})
+	}
+}
+
+func TestSizeMarshalJSON(t *testing.T) {
+	body := octets.Size(1536)
+	cfg := &config{MaxUpload: 25 * 1024 * 1024, MaxBody: &body}
+
+	data, err := json.Marshal(cfg)
+	require.NoError(t, err, "json.Marshal() should have succeed")
+	assert.JSONEq(t, `{"max_upload": "25MiB", "max_body": "1.5KiB"}`, string(data))
+
+	decoded := &config{}
+	require.NoError(t, json.Unmarshal(data, decoded), "json.Unmarshal() should have succeed")
+	assert.Equal(t, cfg, decoded, "the config should round-trip")
+}
+
+func TestSizeText(t *testing.T) {
+	text, err := octets.Size(2 * 1024 * 1024).MarshalText()
+	require.NoError(t, err, "MarshalText() should have succeed")
+	assert.Equal(t, "2MiB", string(text))
+
+	var s octets.Size
+	require.NoError(t, s.UnmarshalText([]byte("2 Mo")), "UnmarshalText() should have succeed")
+	assert.Equal(t, octets.Size(2*1000*1000), s)
+
+	err = s.UnmarshalText([]byte("invalid"))
+	require.Error(t, err, "UnmarshalText() should have failed")
+	assert.Equal(t, octets.ErrMsgInvalidSize, err.Error())
+	assert.Equal(t, octets.Size(2*1000*1000), s, "the size should not have changed")
+}
+
+func TestSizeFlag(t *testing.T) {
+	maxUpload := octets.Size(1024)
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	flags.Var(&maxUpload, "max-upload", "maximum size of an upload")
+
+	assert.Equal(t, "1KiB", flags.Lookup("max-upload").DefValue)
+	require.NoError(t, flags.Parse([]string{"-max-upload", "25MiB"}), "Parse() should have succeed")
+	assert.Equal(t, octets.Size(25*1024*1024), maxUpload)
+	assert.Equal(t, "size", maxUpload.Type())
+
+	require.Error(t, flags.Parse([]string{"-max-upload", "big"}), "Parse() should have failed")
+}
+
+func TestSizeSQL(t *testing.T) {
+	value, err := octets.Size(1024).Value()
+	require.NoError(t, err, "Value() should have succeed")
+	assert.Equal(t, int64(1024), value)
+
+	testCases := []struct {
+		description string
+		value       interface{}
+		expected    octets.Size
+	}{
+		{"int64", int64(1024), 1024},
+		{"bytes", []byte("2048"), 2048},
+		{"string", "4096", 4096},
+		{"nil", nil, 42},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			s := octets.Size(42)
+			require.NoError(t, s.Scan(tc.value), "Scan() should have succeed")
+			assert.Equal(t, tc.expected, s)
+		})
+	}
+
+	t.Run("invalid values", func(t *testing.T) {
+		t.Parallel()
+
+		var s octets.Size
+		assert.Error(t, s.Scan(1.5), "floats should not be scanned")
+		assert.Error(t, s.Scan([]byte("1KiB")), "units should not be stored in the database")
+	})
+}
+
+func TestGetenv(t *testing.T) {
+	const key = "OCTETS_TEST_MAX_UPLOAD"
+	defer func() {
+		require.NoError(t, os.Unsetenv(key), "Unsetenv() should have succeed")
+	}()
+
+	size, err := octets.Getenv(key, 1024)
+	require.NoError(t, err, "Getenv() should have succeed")
+	assert.Equal(t, octets.Size(1024), size, "the fallback should have been returned")
+
+	require.NoError(t, os.Setenv(key, "25MiB"), "Setenv() should have succeed")
+	size, err = octets.Getenv(key, 1024)
+	require.NoError(t, err, "Getenv() should have succeed")
+	assert.Equal(t, octets.Size(25*1024*1024), size)
+
+	require.NoError(t, os.Setenv(key, "invalid"), "Setenv() should have succeed")
+	_, err = octets.Getenv(key, 1024)
+	require.Error(t, err, "Getenv() should have failed")
+	assert.Equal(t, key+": "+octets.ErrMsgInvalidSize, err.Error())
+}
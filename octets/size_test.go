@@ -0,0 +1,204 @@
+package octets_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Nivl/go-types/octets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		description string
+		input       string
+		expected    octets.Size
+	}{
+		{"bytes without unit", "512", 512},
+		{"bytes", "512B", 512},
+		{"octets", "512o", 512},
+		{"zero", "0", 0},
+		{"SI unit", "10MB", 10 * 1000 * 1000},
+		{"lower case SI unit", "10kb", 10 * 1000},
+		{"IEC unit", "10MiB", 10 * 1024 * 1024},
+		{"decimal with spaces", " 1.5 GiB ", 1.5 * 1024 * 1024 * 1024},
+		{"French SI unit", "2Mo", 2 * 1000 * 1000},
+		{"French IEC unit", "2Kio", 2 * 1024},
+		{"fraction of a byte", "1.0001KB", 1000},
+		{"leading dot", ".5KiB", 512},
+		{"negative", "-1KiB", -1024},
+		{"explicit sign", "+1KiB", 1024},
+		{"max value", "9223372036854775807", math.MaxInt64},
+		{"biggest unit", "7EiB", 7 << 60},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			size, err := octets.Parse(tc.input)
+			require.NoError(t, err, "Parse() should have succeed")
+			assert.Equal(t, tc.expected, size)
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	testCases := []struct {
+		description string
+		input       string
+		expectedErr string
+	}{
+		{"empty", "", octets.ErrMsgInvalidSize},
+		{"unit only", "MB", octets.ErrMsgInvalidSize},
+		{"dot only", ".MB", octets.ErrMsgInvalidSize},
+		{"sign only", "-", octets.ErrMsgInvalidSize},
+		{"unknown unit", "10XB", octets.ErrMsgInvalidSize},
+		{"two dots", "1.2.3MB", octets.ErrMsgInvalidSize},
+		{"exponent", "1e3", octets.ErrMsgInvalidSize},
+		{"bits", "10Mbps", octets.ErrMsgInvalidSize},
+		{"overflow", "8EiB", octets.ErrMsgSizeOutOfRange},
+		{"overflow without unit", "9223372036854775808", octets.ErrMsgSizeOutOfRange},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := octets.Parse(tc.input)
+			require.Error(t, err, "Parse() should have failed")
+			assert.Equal(t, tc.expectedErr, err.Error())
+		})
+	}
+}
+
+func TestSizeString(t *testing.T) {
+	testCases := []struct {
+		description string
+		size        octets.Size
+		expected    string
+	}{
+		{"zero", 0, "0B"},
+		{"bytes", 512, "512B"},
+		{"exact unit", 25 * 1024 * 1024, "25MiB"},
+		{"decimals", 1536, "1.5KiB"},
+		{"3 decimals", 1024 + 1, "1025B"},
+		{"smaller unit", 10 * 1000 * 1000, "9765.625KiB"},
+		{"negative", -1536, "-1.5KiB"},
+		{"max value", math.MaxInt64, "9223372036854775807B"},
+		{"min value", math.MinInt64, "-8EiB"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, tc.size.String())
+			if tc.size == math.MinInt64 {
+				// -8EiB cannot be parsed since 8EiB doesn't fit in an int64
+				return
+			}
+
+			parsed, err := octets.Parse(tc.expected)
+			require.NoError(t, err, "Parse() should have succeed")
+			assert.Equal(t, tc.size, parsed, "the size should round-trip")
+		})
+	}
+}
+
+func TestSizeFormat(t *testing.T) {
+	testCases := []struct {
+		description string
+		size        octets.Size
+		precision   int
+		expected    string
+		expectedSI  string
+	}{
+		{"bytes", 512, 2, "512B", "512B"},
+		{"rounded", 1500, 2, "1.46KiB", "1.5kB"},
+		{"no decimals", 1500, 0, "1KiB", "2kB"},
+		{"trailing zeros are removed", 2048, 3, "2KiB", "2.048kB"},
+		{"big values", 5 * 1000 * 1000 * 1000 * 1000, 1, "4.5TiB", "5TB"},
+		{"negative", -1500, 1, "-1.5KiB", "-1.5kB"},
+		{"negative precision", 10 * 1000 * 1000, -1, "9765.625KiB", "10MB"},
+		{"rounded to the next unit", 1024*1024 - 1, 2, "1MiB", "1.05MB"},
+		{"rounded to the next SI unit", 999999, 2, "976.56KiB", "1MB"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, tc.size.Format(tc.precision))
+			assert.Equal(t, tc.expectedSI, tc.size.FormatSI(tc.precision))
+		})
+	}
+}
+
+func TestSizeBytes(t *testing.T) {
+	assert.Equal(t, int64(1024), octets.Size(1024).Bytes())
+}
+
+func TestUnitSystemParse(t *testing.T) {
+	testCases := []struct {
+		description string
+		input       string
+		expectedSI  octets.Size
+		expectedIEC octets.Size
+	}{
+		{"bytes", "512B", 512, 512},
+		{"decimal unit", "10MB", octets.Size(10 * octets.MB), octets.Size(10 * octets.MiB)},
+		{"French decimal unit", "2Ko", octets.Size(2 * octets.Ko), octets.Size(2 * octets.Kio)},
+		{"binary unit", "10MiB", octets.Size(10 * octets.MiB), octets.Size(10 * octets.MiB)},
+		{"French binary unit", "2Gio", octets.Size(2 * octets.Gio), octets.Size(2 * octets.Gio)},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			size, err := octets.SI.Parse(tc.input)
+			require.NoError(t, err, "SI.Parse() should have succeed")
+			assert.Equal(t, tc.expectedSI, size)
+
+			size, err = octets.IEC.Parse(tc.input)
+			require.NoError(t, err, "IEC.Parse() should have succeed")
+			assert.Equal(t, tc.expectedIEC, size)
+		})
+	}
+}
+
+func TestUnitSystemFormat(t *testing.T) {
+	testCases := []struct {
+		description string
+		size        octets.Size
+		precision   int
+		expectedSI  string
+		expectedIEC string
+	}{
+		{"exact", octets.Size(3 * octets.MB), -1, "3MB", "3000000B"},
+		{"exact binary", octets.Size(3 * octets.MiB), -1, "3145.728kB", "3MiB"},
+		{"rounded", octets.Size(3 * octets.MiB), 1, "3.1MB", "3MiB"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expectedSI, octets.SI.Format(tc.size, tc.precision))
+			assert.Equal(t, tc.expectedIEC, octets.IEC.Format(tc.size, tc.precision))
+		})
+	}
+}
+
+func TestUnitSystemString(t *testing.T) {
+	assert.Equal(t, "SI", octets.SI.String())
+	assert.Equal(t, "IEC", octets.IEC.String())
+}
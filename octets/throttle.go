@@ -0,0 +1,156 @@
+package octets
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// Limiter limits the number of bytes transferred per second, using a
+// token bucket. The same limiter can be shared by multiple readers and
+// writers to limit their combined rate.
+// A Limiter is safe for concurrent use
+type Limiter struct {
+	mu     sync.Mutex
+	rate   Rate
+	burst  Size
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter returns a limiter allowing rate bytes per second, with
+// bursts of up to burst bytes. The burst defaults to one second worth of
+// data if not positive.
+// A rate of 0 or less disables the limit
+func NewLimiter(rate Rate, burst Size) *Limiter {
+	if burst <= 0 {
+		burst = Size(rate)
+	}
+	if burst <= 0 {
+		burst = 1
+	}
+	return &Limiter{
+		rate:   rate,
+		burst:  burst,
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Rate returns the rate of the limiter
+func (l *Limiter) Rate() Rate {
+	return l.rate
+}
+
+// Burst returns the maximum number of bytes that can be transferred at
+// once
+func (l *Limiter) Burst() Size {
+	return l.burst
+}
+
+// Wait blocks until n bytes can be transferred.
+// n can be bigger than the burst, in which case the next transfers will
+// wait longer
+func (l *Limiter) Wait(n int) {
+	if d := l.reserve(n); d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// reserve takes n tokens from the bucket, and returns how long the
+// caller needs to wait before using them. The bucket can go into debt so
+// the concurrent callers wait their turn
+func (l *Limiter) reserve(n int) time.Duration {
+	if l.rate <= 0 || n <= 0 {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * float64(l.rate)
+	if l.tokens > float64(l.burst) {
+		l.tokens = float64(l.burst)
+	}
+	l.last = now
+
+	l.tokens -= float64(n)
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / float64(l.rate) * float64(time.Second))
+}
+
+// chunkSize returns the number of bytes that can be transferred at once,
+// up to n. Transferring more than the burst would create spikes
+func (l *Limiter) chunkSize(n int) int {
+	if l.rate > 0 && int64(n) > int64(l.burst) {
+		return int(l.burst)
+	}
+	return n
+}
+
+// Reader returns a reader that reads from r at the rate of the limiter
+func (l *Limiter) Reader(r io.Reader) io.Reader {
+	return &throttledReader{r: r, limiter: l}
+}
+
+// Writer returns a writer that writes to w at the rate of the limiter
+func (l *Limiter) Writer(w io.Writer) io.Writer {
+	return &throttledWriter{w: w, limiter: l}
+}
+
+// ThrottleReader returns a reader that reads from r at the given rate,
+// with bursts of up to one second worth of data.
+// A rate of 0 or less disables the limit
+func ThrottleReader(r io.Reader, rate Rate) io.Reader {
+	return NewLimiter(rate, 0).Reader(r)
+}
+
+// ThrottleWriter returns a writer that writes to w at the given rate,
+// with bursts of up to one second worth of data.
+// A rate of 0 or less disables the limit
+func ThrottleWriter(w io.Writer, rate Rate) io.Writer {
+	return NewLimiter(rate, 0).Writer(w)
+}
+
+// throttledReader is a reader limited by a Limiter
+type throttledReader struct {
+	r       io.Reader
+	limiter *Limiter
+}
+
+// Read implements the io.Reader interface
+// https://golang.org/pkg/io/#Reader
+func (r *throttledReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p[:r.limiter.chunkSize(len(p))])
+	r.limiter.Wait(n)
+	return n, err
+}
+
+// throttledWriter is a writer limited by a Limiter
+type throttledWriter struct {
+	w       io.Writer
+	limiter *Limiter
+}
+
+// Write implements the io.Writer interface
+// https://golang.org/pkg/io/#Writer
+func (w *throttledWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p[:w.limiter.chunkSize(len(p))]
+		w.limiter.Wait(len(chunk))
+		n, err := w.w.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		if n < len(chunk) {
+			return written, io.ErrShortWrite
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
@@ -0,0 +1,120 @@
+package octets_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Nivl/go-types/octets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chunkRecorder is a writer that records the size of each write
+type chunkRecorder struct {
+	bytes.Buffer
+	chunks []int
+}
+
+// Write implements the io.Writer interface
+// https://golang.org/pkg/io/#Writer
+func (w *chunkRecorder) Write(p []byte) (int, error) {
+	w.chunks = append(w.chunks, len(p))
+	return w.Buffer.Write(p)
+}
+
+// shortWriter is a writer that never writes more than one byte
+type shortWriter struct{}
+
+// Write implements the io.Writer interface
+// https://golang.org/pkg/io/#Writer
+func (shortWriter) Write(p []byte) (int, error) {
+	return minInt(len(p), 1), nil
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func TestThrottleReader(t *testing.T) {
+	content := bytes.Repeat([]byte("a"), 30000)
+	// the burst is consumed immediately, the rest takes 200ms
+	limiter := octets.NewLimiter(100000, 10000)
+
+	start := time.Now()
+	data, err := ioutil.ReadAll(limiter.Reader(bytes.NewReader(content)))
+	elapsed := time.Since(start)
+
+	require.NoError(t, err, "ReadAll() should have succeed")
+	assert.Equal(t, content, data)
+	assert.True(t, elapsed >= 180*time.Millisecond, "the reader should have been throttled (%s)", elapsed)
+	assert.True(t, elapsed < 2*time.Second, "the reader has been throttled too much (%s)", elapsed)
+}
+
+func TestThrottleWriter(t *testing.T) {
+	content := bytes.Repeat([]byte("a"), 30000)
+	limiter := octets.NewLimiter(100000, 10000)
+	assert.Equal(t, octets.Rate(100000), limiter.Rate())
+	assert.Equal(t, octets.Size(10000), limiter.Burst())
+
+	w := &chunkRecorder{}
+	start := time.Now()
+	n, err := limiter.Writer(w).Write(content)
+	elapsed := time.Since(start)
+
+	require.NoError(t, err, "Write() should have succeed")
+	assert.Equal(t, len(content), n)
+	assert.Equal(t, content, w.Bytes())
+	assert.Equal(t, []int{10000, 10000, 10000}, w.chunks, "the data should have been written by chunks of the burst size")
+	assert.True(t, elapsed >= 180*time.Millisecond, "the writer should have been throttled (%s)", elapsed)
+	assert.True(t, elapsed < 2*time.Second, "the writer has been throttled too much (%s)", elapsed)
+}
+
+func TestThrottleSharedLimiter(t *testing.T) {
+	limiter := octets.NewLimiter(100000, 10000)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := io.Copy(ioutil.Discard, limiter.Reader(bytes.NewReader(make([]byte, 10000))))
+			assert.NoError(t, err, "Copy() should have succeed")
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	assert.True(t, elapsed >= 180*time.Millisecond, "the combined rate should have been throttled (%s)", elapsed)
+}
+
+func TestThrottleUnlimited(t *testing.T) {
+	content := bytes.Repeat([]byte("a"), 1000000)
+
+	w := &chunkRecorder{}
+	n, err := octets.ThrottleWriter(w, 0).Write(content)
+	require.NoError(t, err, "Write() should have succeed")
+	assert.Equal(t, len(content), n)
+	assert.Equal(t, []int{len(content)}, w.chunks, "the data should have been written at once")
+
+	data, err := ioutil.ReadAll(octets.ThrottleReader(bytes.NewReader(content), -1))
+	require.NoError(t, err, "ReadAll() should have succeed")
+	assert.Equal(t, content, data)
+}
+
+func TestThrottleWriterErrors(t *testing.T) {
+	t.Run("short write", func(t *testing.T) {
+		t.Parallel()
+
+		n, err := octets.ThrottleWriter(shortWriter{}, octets.Rate(octets.MB)).Write([]byte("abc"))
+		assert.True(t, errors.Is(err, io.ErrShortWrite), "unexpected error: %v", err)
+		assert.Equal(t, 1, n)
+	})
+}